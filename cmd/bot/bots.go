@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/bot"
+)
+
+// botEntry overrides the command line configuration for one of several bots.
+// Omitted fields keep the command line values.
+type botEntry struct {
+	Token             string           `json:"token"` // Token, or "$NAME" to read it from the environment
+	RedisPrefix       *string          `json:"redis_prefix"`
+	SpamThreshold     *float64         `json:"spam_threshold"`
+	NewUserThreshold  *int             `json:"new_user_threshold"`
+	WhitelistChannels []int64          `json:"whitelist_channels"`
+	LogChannels       map[string]int64 `json:"log_channels"`
+	DefaultLogChannel *int64           `json:"default_log_channel"`
+}
+
+// loadBotConfigs reads the bots file and derives a config per bot from base.
+// Bots without an explicit Redis prefix get their own "bot<id>:" namespace.
+func loadBotConfigs(path string, base bot.Config) ([]bot.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bots file: %w", err)
+	}
+
+	var entries []botEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing bots file: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no bots configured in %s", path)
+	}
+
+	configs := make([]bot.Config, 0, len(entries))
+	for i, entry := range entries {
+		config := base
+
+		config.Token = entry.Token
+		if strings.HasPrefix(entry.Token, "$") {
+			config.Token = os.Getenv(strings.TrimPrefix(entry.Token, "$"))
+		}
+		if config.Token == "" {
+			return nil, fmt.Errorf("bot %d has no token", i)
+		}
+
+		if entry.RedisPrefix != nil {
+			config.RedisPrefix = *entry.RedisPrefix
+		} else {
+			botID, _, _ := strings.Cut(config.Token, ":")
+			config.RedisPrefix = base.RedisPrefix + "bot" + botID + ":"
+		}
+		if entry.SpamThreshold != nil {
+			config.Threshold = *entry.SpamThreshold
+		}
+		if entry.NewUserThreshold != nil {
+			config.NewUserThreshold = *entry.NewUserThreshold
+		}
+		if entry.WhitelistChannels != nil {
+			config.WhitelistChannels = entry.WhitelistChannels
+		}
+		if entry.LogChannels != nil {
+			config.LogChannels = make(map[int64]int64, len(entry.LogChannels))
+			for workingChatID, logChannelID := range entry.LogChannels {
+				chatID, err := strconv.ParseInt(workingChatID, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("bot %d: invalid working chat ID: %v", i, err)
+				}
+				config.LogChannels[chatID] = logChannelID
+			}
+		}
+		if entry.DefaultLogChannel != nil {
+			config.DefaultLogChannel = *entry.DefaultLogChannel
+		}
+
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// historyPrefixes returns the Redis prefixes message counts are kept under: the command
+// line prefix, or with a bots file the namespace of each of its bots
+func historyPrefixes(botsPath, redisPrefix string) ([]string, error) {
+	if botsPath == "" {
+		return []string{redisPrefix}, nil
+	}
+	configs, err := loadBotConfigs(botsPath, bot.Config{RedisPrefix: redisPrefix})
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make([]string, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		if !seen[config.RedisPrefix] {
+			seen[config.RedisPrefix] = true
+			prefixes = append(prefixes, config.RedisPrefix)
+		}
+	}
+	return prefixes, nil
+}
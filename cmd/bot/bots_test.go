@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryPrefixes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.json")
+	bots := `[
+		{"token": "111:aaa"},
+		{"token": "222:bbb", "redis_prefix": "shared:"},
+		{"token": "333:ccc", "redis_prefix": "shared:"}
+	]`
+	if err := os.WriteFile(path, []byte(bots), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	prefixes, err := historyPrefixes(path, "giraffe:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"giraffe:bot111:", "shared:"}
+	if len(prefixes) != len(want) || prefixes[0] != want[0] || prefixes[1] != want[1] {
+		t.Errorf("prefixes = %q, want %q", prefixes, want)
+	}
+
+	if prefixes, err := historyPrefixes("", "giraffe:"); err != nil || len(prefixes) != 1 || prefixes[0] != "giraffe:" {
+		t.Errorf("prefixes without a bots file = %q, %v, want only the command line prefix", prefixes, err)
+	}
+}
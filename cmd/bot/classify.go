@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// classifyOutput is the JSON printed by the classify subcommand
+type classifyOutput struct {
+	SpamScore  float64            `json:"spam_score"`
+	Reasoning  string             `json:"reasoning"`
+	Labels     map[string]float64 `json:"labels,omitempty"`
+	Provider   string             `json:"provider"`
+	Model      string             `json:"model"`
+	DurationMS int64              `json:"duration_ms"`
+}
+
+// runClassify classifies a single message given with -text or on stdin and
+// prints the result as JSON, without connecting to Telegram or Redis
+func runClassify(logger *slog.Logger, providers providerConfig, promptPath string, args []string) error {
+	fs := flag.NewFlagSet("classify", flag.ContinueOnError)
+	text := fs.String("text", "", "Message text to classify, read from stdin if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	message := *text
+	if message == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("error reading stdin: %w", err)
+		}
+		message = strings.TrimSpace(string(data))
+	}
+	if message == "" {
+		return fmt.Errorf("usage: classify -text <message> or pipe the message to stdin")
+	}
+
+	prompt, err := loadPrompt(logger, promptPath)
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider(logger, providers)
+	if err != nil {
+		return err
+	}
+
+	output, err := classify(context.Background(), provider, prompt, message)
+	if err != nil {
+		return err
+	}
+	output.Provider = providers.Name
+	output.Model = providers.Model
+	return json.NewEncoder(os.Stdout).Encode(output)
+}
+
+func classify(ctx context.Context, provider ai.Provider, prompt, message string) (classifyOutput, error) {
+	// Chat-specific blocks such as spam examples aren't available outside a chat
+	prompt = ai.InjectExamples(prompt, nil)
+	prompt = ai.InjectChatContext(prompt, nil)
+	prompt = ai.InjectContext(prompt, nil)
+
+	start := time.Now()
+	response, err := provider.ProcessMessage(ctx, ai.RenderPrompt(message, prompt))
+	if err != nil {
+		return classifyOutput{}, fmt.Errorf("API error: %w", err)
+	}
+	result, err := ai.ParseResponse(response)
+	if err != nil {
+		return classifyOutput{}, err
+	}
+	return classifyOutput{
+		SpamScore:  result.SpamScore,
+		Reasoning:  strings.TrimSpace(result.Reasoning),
+		Labels:     result.Labels,
+		DurationMS: time.Since(start).Milliseconds(),
+	}, nil
+}
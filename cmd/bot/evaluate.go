@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/evalstore"
+)
+
+// runEvaluate classifies the sampled live messages again with the current provider
+// and prompt, printing how scores and decisions at the threshold changed. Samples
+// stored without their text only count towards the recorded score summary.
+func runEvaluate(ctx context.Context, logger *slog.Logger, store *evalstore.Store, providers providerConfig, promptPath string, threshold float64, args []string) error {
+	fs := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "Evaluate only this many of the most recent samples, all if 0")
+	export := fs.String("export", "", "Write the texts of the samples to this CSV file for the prompt evaluator instead of classifying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	samples, err := store.List(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("no eval samples stored, enable them with -eval-sample-rate")
+	}
+	if *export != "" {
+		return exportSamples(samples, *export)
+	}
+
+	prompt, err := loadPrompt(logger, promptPath)
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider(logger, providers)
+	if err != nil {
+		return err
+	}
+
+	var (
+		recordedSpam, evaluated, failed, flagged, cleared int
+		recordedTotal, difference                         float64
+	)
+	for _, sample := range samples {
+		recordedTotal += sample.SpamScore
+		if sample.SpamScore > threshold {
+			recordedSpam++
+		}
+		if sample.Text == "" {
+			continue
+		}
+
+		output, err := classify(ctx, provider, prompt, sample.Text)
+		if err != nil {
+			logger.Warn("Failed to classify eval sample", "error", err, "chatID", sample.ChatID, "messageID", sample.MessageID)
+			failed++
+			continue
+		}
+		evaluated++
+		difference += math.Abs(output.SpamScore - sample.SpamScore)
+
+		wasSpam, isSpam := sample.SpamScore > threshold, output.SpamScore > threshold
+		if wasSpam == isSpam {
+			continue
+		}
+		if isSpam {
+			flagged++
+		} else {
+			cleared++
+		}
+		fmt.Printf("%d:%d %.2f -> %.2f (%s)\n", sample.ChatID, sample.MessageID, sample.SpamScore, output.SpamScore, sample.Model)
+	}
+
+	fmt.Printf("Samples: %d, oldest from %s\n", len(samples), samples[len(samples)-1].CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Recorded: mean score %.2f, %d above the %.2f threshold\n", recordedTotal/float64(len(samples)), recordedSpam, threshold)
+	if evaluated == 0 {
+		fmt.Printf("Evaluated: none, %d failed; samples only keep their text with -eval-keep-content\n", failed)
+		return nil
+	}
+	fmt.Printf("Evaluated: %d, %d failed, mean score difference %.3f\n", evaluated, failed, difference/float64(evaluated))
+	fmt.Printf("Decisions changed: %d newly spam, %d no longer spam\n", flagged, cleared)
+	return nil
+}
+
+// exportSamples writes the sample texts as a single-column CSV, the input format of prompt-evaluator
+func exportSamples(samples []evalstore.Sample, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating export file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	exported := 0
+	for _, sample := range samples {
+		if sample.Text == "" {
+			continue
+		}
+		if err := writer.Write([]string{sample.Text}); err != nil {
+			return fmt.Errorf("error writing export file: %w", err)
+		}
+		exported++
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing export file: %w", err)
+	}
+	fmt.Printf("Exported %d of %d samples to %s\n", exported, len(samples), path)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// lintFixture is the message classified by lint-prompt -live when no -text is given
+const lintFixture = "Earn $500 a day working from home! DM me for details"
+
+// runLintPrompt checks that a prompt file renders and asks for a response the bot
+// can parse, printing every problem found. With -live it also classifies a fixture
+// message with the configured provider.
+func runLintPrompt(logger *slog.Logger, providers providerConfig, promptPath string, args []string) error {
+	fs := flag.NewFlagSet("lint-prompt", flag.ContinueOnError)
+	path := fs.String("prompt", promptPath, "Path to the prompt file to check")
+	live := fs.Bool("live", false, "Also classify a fixture message with the provider and check the response parses")
+	text := fs.String("text", lintFixture, "Fixture message classified with -live")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	prompt, err := loadPrompt(logger, *path)
+	if err != nil {
+		return err
+	}
+
+	problems := ai.LintPrompt(prompt, providers.ForceJSON)
+	if *live && len(problems) == 0 {
+		provider, err := newProvider(logger, providers)
+		if err != nil {
+			return err
+		}
+		output, err := classify(context.Background(), provider, prompt, *text)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("live classification failed: %v", err))
+		case output.SpamScore < 0 || output.SpamScore > 1:
+			problems = append(problems, fmt.Sprintf("live classification returned spam_score %.2f outside [0, 1]", output.SpamScore))
+		default:
+			fmt.Fprintf(os.Stdout, "live: spam_score %.2f in %dms\n", output.SpamScore, output.DurationMS)
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stdout, "%s: %s\n", *path, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("prompt %s has %d problem(s)", *path, len(problems))
+	}
+	fmt.Fprintf(os.Stdout, "%s: ok\n", *path)
+	return nil
+}
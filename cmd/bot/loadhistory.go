@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/history"
+	"github.com/redis/go-redis/v9"
+)
+
+// runLoadHistory loads a Telegram export into Redis without starting the bot
+func runLoadHistory(ctx context.Context, rdb *redis.Client, prefix, historyFile string, historyBatchSize int, args []string) error {
+	fs := flag.NewFlagSet("load-history", flag.ContinueOnError)
+	file := fs.String("history", historyFile, "Path to the history file")
+	modeName := fs.String("mode", string(history.LoadModeMerge), "How loaded counts are combined with stored ones (merge, replace)")
+	dryRun := fs.Bool("dry-run", false, "Only count the messages and users in the file without writing to Redis")
+	batchSize := fs.Int("batch-size", historyBatchSize, "Number of user counts written to Redis per round trip, all at once if 0")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("usage: load-history -history <file> [-mode merge|replace] [-dry-run]")
+	}
+	mode, err := history.ParseLoadMode(*modeName)
+	if err != nil {
+		return err
+	}
+
+	result, err := history.Load(ctx, *file, rdb, prefix, mode, *dryRun, *batchSize)
+	if err != nil {
+		return err
+	}
+	for _, err := range result.Errors {
+		fmt.Printf("Skipped: %v\n", err)
+	}
+	if *dryRun {
+		fmt.Printf("Dry run: %d messages from %d users in chat %s, %d entries skipped\n", result.Loaded, result.Users, result.ChatID, result.Skipped)
+		return nil
+	}
+	fmt.Printf("Loaded %d messages from %d users in chat %s (%s), %d entries skipped\n", result.Loaded, result.Users, result.ChatID, mode, result.Skipped)
+	return nil
+}
@@ -5,15 +5,28 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
 	"github.com/ailabhub/giraffe-spam-crasher/internal/bot"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/debugstore"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/errreport"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/evalstore"
 	"github.com/ailabhub/giraffe-spam-crasher/internal/history"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/httpclient"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/locale"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/server"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/snapshot"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/telemetry"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -21,20 +34,371 @@ func main() { //nolint:gocyclo,gocognit
 	ctx := context.Background()
 	logLevel := flag.String("log-level", "info", "Logging level (debug, info, warn, error)")
 	historyFile := flag.String("history", "", "Path to the history file")
+	historyBatchSize := flag.Int("history-batch-size", 1000, "Number of user counts written to Redis per round trip when loading history, all at once if 0")
+	botsPath := flag.String("bots", "", "Path to a JSON file configuring several bots run by this process")
+	redisConnectRetries := flag.Int("redis-connect-retries", 5, "Retries with backoff when Redis is unreachable at startup before giving up")
+	redisConnectTimeout := flag.Duration("redis-connect-timeout", 5*time.Second, "Timeout of each Redis connection attempt at startup")
+	redisPrefix := flag.String("redis-prefix", "", "Prefix prepended to every Redis key, for sharing a Redis database with other instances or apps")
 
-	apiProvider := flag.String("provider", "openai", "API provider (openai or anthropic)")
+	apiProvider := flag.String("provider", "openai", "API provider (openai, anthropic, gemini, mistral, or stub for testing)")
 	model := flag.String("model", "gpt-4o-mini", "Model to use (e.g., gpt-4 for OpenAI, claude-2 for Anthropic)")
+	stubScore := flag.Float64("stub-score", 0, "Spam score returned by the stub provider for every message")
+	stubLatency := flag.Duration("stub-latency", 0, "Simulated latency of each stub provider call")
+	forceJSON := flag.Bool("force-json", false, "Use JSON mode with every model of providers supporting it, not only with models known to support it")
+	temperature := flag.Float64("temperature", 0, "Sampling temperature of classification requests, low values keep scores stable")
+	topP := flag.Float64("top-p", 0, "Nucleus sampling top_p of classification requests, the provider's default if 0")
+	var providerSampling providerSamplingFlag
+	flag.Var(&providerSampling, "provider-sampling", "Comma-separated list of per-provider overrides of -temperature and -top-p in the format 'provider=temperature[:top_p]'")
 	promptPath := flag.String("prompt", "", "Path to the prompt text file")
+	promptAdaptations := flag.String("prompt-adaptations", "", "Directory with optional <provider>.prefix.txt, <provider>.suffix.txt and <provider>.system.txt files adapting the prompt per provider")
 	threshold := flag.Float64("spam-threshold", 0.5, "Threshold for classifying a message as spam")
 	newUserThreshold := flag.Int("new-user-threshold", 1, "Threshold for classifying user as new")
+	tenureGrace := flag.Duration("tenure-grace", 0, "Members who joined the chat longer ago are treated as established regardless of their message count, disabled if 0")
+	firstMessageOnly := flag.Bool("first-message-only", false, "Classify only the first message of each user, checking later ones with heuristics only")
+	scanWindow := flag.Int("scan-window", 0, "Number of clean messages after which a user is no longer scanned by the AI (defaults to the new user threshold)")
+	sampleRate := flag.Float64("sample-rate", 0, "Fraction (0-1) of established users' messages that are still scanned")
+	scanFilter := flag.String("scan-filter", string(bot.ScanFilterAll), "Which messages of established users are scanned (all, links-media-only); new users are always scanned")
+	scanCommands := flag.Bool("scan-commands", true, "Scan commands not handled by this bot; if false, commands addressed to other bots and commands without arguments are skipped")
 	var whitelistChannels intSliceFlag
 	flag.Var(&whitelistChannels, "whitelist-channels", "Comma-separated list of whitelisted channel IDs")
 
+	httpProxy := flag.String("http-proxy", "", "Proxy URL for requests to AI providers and other external APIs, HTTPS_PROXY is honored if empty")
+	var httpHeaders headersFlag
+	flag.Var(&httpHeaders, "http-headers", "Comma-separated list of extra headers sent to AI providers and other external APIs in the format 'Name=value', a $ENV_VAR value is read from the environment")
+
+	adminCacheTTL := flag.Duration("admin-cache-ttl", 5*time.Minute, "How long chat administrator lists are cached in Redis")
+
+	var superAdmins intSliceFlag
+	flag.Var(&superAdmins, "super-admins", "Comma-separated list of user IDs notified about operational problems, such as the bot losing admin rights in a chat, who can also pause AI classification")
+	pauseAI := flag.Bool("pause-ai", false, "Start with AI classification paused, messages are handled by heuristics until a super-admin sends /resume_ai")
+
 	var logChannels logChannelsFlag
 	flag.Var(&logChannels, "log-channels", "Comma-separated list of working chat ID and log channel ID pairs in the format 'workingChatID1:logChannelID1,workingChatID2:logChannelID2'")
+	defaultLogChannel := flag.Int64("default-log-channel", 0, "Log channel ID receiving notifications about chats not listed in -log-channels, disabled if 0")
+
+	var labels labelsFlag
+	flag.Var(&labels, "labels", "Comma-separated list of additional classifier labels in the format 'label:threshold:action' (action is notify, delete, mute or ban), e.g. 'scam:0.7:ban,nsfw:0.8:delete'")
+
+	var lengthThresholds lengthThresholdsFlag
+	flag.Var(&lengthThresholds, "length-thresholds", "Comma-separated list of threshold offsets by message length in characters in the format 'min-max:offset', either bound may be empty, e.g. '-20:+0.1,2000-:+0.05'")
+
+	debugStoreEnabled := flag.Bool("debug-store", false, "Record rendered prompts and raw responses for every decision so they can be replayed")
+	debugStoreTTL := flag.Duration("debug-store-ttl", 72*time.Hour, "How long recorded prompts and responses are kept")
+	evalSampleRate := flag.Float64("eval-sample-rate", 0, "Fraction of live classifications kept with their scores in a rolling eval set for the evaluate subcommand, disabled if 0")
+	evalStoreSize := flag.Int("eval-store-size", 1000, "Number of most recent eval samples kept")
+	evalKeepContent := flag.Bool("eval-keep-content", false, "Keep message text in eval samples so they can be classified again, instead of only its hash")
+	auditKeepContent := flag.Bool("audit-keep-content", false, "Keep the original content of messages deleted by the bot behind the details button of their notification, for appeals")
+	auditContentTTL := flag.Duration("audit-content-ttl", 24*time.Hour, "How long the content of deleted messages is kept with -audit-keep-content")
+	decisionTTL := flag.Duration("decision-ttl", 30*24*time.Hour, "How long the score, reasoning, model and action of each decision are kept for /why, disabled if 0")
+	notificationDetails := flag.Duration("notification-details", 0, "Keep classification details for this long behind a button on log channel notifications instead of inlining the reasoning, disabled if 0")
+
+	dailyCostCap := flag.Float64("daily-cost-cap", 0, "Estimated daily spend on classification after which -cost-cap-fallback applies, disabled if 0")
+	costPer1KTokens := flag.Float64("cost-per-1k-tokens", 0.0005, "Price per 1000 tokens used to estimate the cost of a classification")
+	costCapFallback := flag.String("cost-cap-fallback", string(bot.CostCapFallbackOff), "How messages are handled once the daily cost cap is reached (off, heuristics-only, notify-only)")
+	onError := flag.String("on-error", string(bot.ErrorPolicyIgnore), "What to do when a message can't be classified (ignore, notify-admins, quarantine)")
+
+	spamExamples := flag.Int("spam-examples", 0, "Number of recent spam messages from the chat injected into the prompt as examples, disabled if 0")
+	spamExamplesChars := flag.Int("spam-examples-max-length", 2000, "Maximum total length in bytes of the injected spam examples")
+	contextMessages := flag.Int("context-messages", 0, "Number of preceding chat messages injected into the prompt as conversation context, disabled if 0")
+	contextMessagesChars := flag.Int("context-messages-max-length", 1500, "Maximum total length in bytes of the injected chat messages")
+
+	registerCommands := flag.Bool("register-commands", false, "Register the bot command menu with Telegram on startup")
+
+	cleanServiceMessages := flag.Bool("clean-service-messages", false, "Delete join and leave notifications")
+	cleanPinnedMessages := flag.Bool("clean-pinned-messages", false, "Also delete pin notifications, requires -clean-service-messages")
+	voice := flag.String("voice-policy", string(bot.VoicePolicyIgnore), "How to handle voice messages and video notes from new users, which can't be classified (ignore, restrict, notify)")
+	senderChat := flag.String("sender-chat-policy", string(bot.SenderChatPolicyIgnore), "How to handle messages sent on behalf of a chat or by anonymous admins (ignore, scan-but-never-ban)")
+
+	shadowProviderName := flag.String("shadow-provider", "", "Candidate API provider classifying every message alongside the primary one without affecting decisions")
+	shadowModel := flag.String("shadow-model", "", "Model for the shadow provider")
+
+	actionPolicy := flag.String("action-policy", string(bot.ActionPolicyBan), "Strictest action applied to flagged messages (delete-only, mute, ban), overridable per chat with /policy")
+	muteDuration := flag.Duration("mute-duration", 24*time.Hour, "How long users are muted by the mute action or policy")
+	staleAction := flag.String("stale-action", string(bot.ActionNotify), "Action for flagged messages too old for Telegram to delete (notify, mute, ban), applied if stricter than the original action")
+	deleteVerifyAttempts := flag.Int("delete-verify-attempts", 0, "Times a deleted message is checked and deleted again if it's still present, disabled if 0")
+	deleteVerifyDelay := flag.Duration("delete-verify-delay", 2*time.Second, "Pause before each check that a deleted message is gone")
+	warmupDetections := flag.Int("warmup-detections", 0, "Number of detections in a chat the bot was just added to that are only reported to admins before enforcing, disabled if 0")
+	warmupDuration := flag.Duration("warmup-duration", 0, "How long after being added to a chat detections are only reported to admins, disabled if 0")
+	notice := flag.String("notice", "", "Template of the notice posted in the chat when the bot deletes a message or restricts a user, e.g. '🧹 A {{.Label}} message from {{.User}} was {{.Action}}', disabled if empty")
+	noticeInterval := flag.Duration("notice-interval", time.Minute, "Minimum time between notices in a chat")
+	noticeTTL := flag.Duration("notice-ttl", time.Minute, "How long notices stay in the chat before they are deleted, kept if 0")
+	defaultLocale := flag.String("locale", locale.Default, "Language of replies and notifications in chats without their own set with /locale ("+strings.Join(locale.Codes(), ", ")+")")
+	undoWindow := flag.Duration("undo-window", 0, "How long admins can undo a deletion or ban by reacting to its log notification with -undo-reaction, disabled if 0")
+	undoReaction := flag.String("undo-reaction", "🕊", "Emoji reaction on a log notification that undoes the action")
+	inviterThreshold := flag.Int("inviter-threshold", 0, "Number of banned spammers invited by the same user that triggers -inviter-action, disabled if 0")
+	inviter := flag.String("inviter-action", string(bot.ActionNotify), "Action applied to inviters of repeated spammers (notify, mute, ban)")
+
+	embeddingsProvider := flag.String("embeddings-provider", "", "Embeddings provider for near-duplicate spam detection (openai or mistral), disabled if empty")
+	embeddingsModel := flag.String("embeddings-model", "text-embedding-3-small", "Embeddings model, e.g. text-embedding-3-small for OpenAI or mistral-embed for Mistral")
+	nearDuplicateThreshold := flag.Float64("near-duplicate-threshold", 0.92, "Cosine similarity to known spam at which a message is treated as a near-duplicate")
+	nearDuplicateMax := flag.Int("near-duplicate-max", 200, "Number of recent spam embeddings messages are compared against")
+
+	reportChannel := flag.Int64("report-channel", 0, "Channel ID receiving spammer reports with their flagged messages and scores")
+	reportDir := flag.String("report-dir", "", "Directory where spammer reports are saved as one JSON file per spammer and chat")
+	reportOnBan := flag.Bool("report-on-ban", false, "Report every banned user automatically, in addition to the /report command")
+
+	replyPolicy := flag.String("reply-to-admin-policy", string(bot.ReplyPolicyIgnore), "How replies are scanned (ignore-replies, skip-admin-replies, discount-admin-replies)")
+	replyToAdminWeight := flag.Float64("reply-to-admin-weight", 0.5, "Factor applied to the spam score of replies to admins with discount-admin-replies")
+	threadCleanMessages := flag.Int("thread-clean-messages", 0, "Clean replies in a thread after which an established user's replies there are weighted down, disabled if 0")
+	threadWeight := flag.Float64("thread-weight", 0.5, "Factor applied to the spam score of replies from thread regulars, skipping them if 0")
+
+	joinRequest := flag.String("join-request-policy", string(bot.JoinRequestPolicyOff), "How to handle chat join requests (off, decline-suspicious)")
+	useCAS := flag.Bool("cas", false, "Check joining users against the CAS (cas.chat) ban list")
+	newAccountID := flag.Int64("new-account-id", 0, "User IDs at or above this value are treated as recently created accounts, disabled if 0")
+	newAccountBoost := flag.Float64("new-account-boost", 0, "Value added to the spam score of messages from recently created accounts")
+	emojiRatio := flag.Float64("emoji-ratio", 0, "Share (0-1) of emoji and symbols among visible characters above which new users' messages are boosted, disabled if 0")
+	emojiRatioBoost := flag.Float64("emoji-ratio-boost", 0.3, "Value added to the spam score of emoji-flooded messages, 1 flags them as spam")
+	customEmoji := flag.String("custom-emoji", string(bot.CustomEmojiKeep), "How custom emoji are passed to the classifier (keep, strip, normalize)")
+	customEmojiRatio := flag.Float64("custom-emoji-ratio", 0, "Share (0-1) of custom emoji among visible characters above which new users' messages are boosted, disabled if 0")
+	languageBoost := flag.Float64("language-boost", 0.3, "Value added to the spam score of new users' messages written outside the languages set with /languages, 1 flags them as spam")
+	emailBoost := flag.Float64("email-boost", 0, "Value added to the spam score of new users' messages with an email address, disabled if 0; chats can override it with /signals")
+	mentionBoost := flag.Float64("mention-boost", 0, "Value added to the spam score of new users' messages with at least -mention-count mentions, disabled if 0; chats can override it with /signals")
+	mentionCount := flag.Int("mention-count", 3, "Number of @mentions in a message that triggers -mention-boost")
+	phoneBoost := flag.Float64("phone-boost", 0, "Value added to the spam score of new users' messages with a phone number, disabled if 0")
+	walletBoost := flag.Float64("wallet-boost", 0, "Value added to the spam score of new users' messages with a crypto wallet address, disabled if 0")
+	cardBoost := flag.Float64("card-boost", 0, "Value added to the spam score of new users' messages with a card number, disabled if 0")
+	bioPromptPath := flag.String("bio-prompt", "", "Path to a prompt file classifying the name and bio of users joining a chat, disabled if empty")
+	bioThreshold := flag.Float64("bio-threshold", 0.7, "Profile score from which new members are reported to the log channel and their messages boosted")
+	impersonationSimilarity := flag.Float64("impersonation-similarity", 0, "Similarity (0-1) of a new member's name or username to an admin's from which they are reported to the log channel as a possible impersonator, disabled if 0")
+	bioBoost := flag.Float64("bio-boost", 0.2, "Value added to the spam score of new users' messages if their profile scored at least -bio-threshold, disabled if 0")
+	repostWindow := flag.Duration("repost-window", 0, "How long new users' messages are remembered to spot deleted and reposted copies of them, disabled if 0")
+	repostBoost := flag.Float64("repost-boost", 0.3, "Value added to the spam score of new users' reposts")
+	repostAction := flag.String("repost-action", "", "Action applied without classification to new users' reposts (notify, delete, mute, ban), disabled if empty")
+	bareLinkBoost := flag.Float64("bare-link-boost", 0.2, "Value added to the spam score of new users' messages that are a link with at most 3 other words, disabled if 0")
+	bareLink := flag.String("bare-link-action", "", "Action applied without classification to new users' bare links (notify, delete, mute, ban), disabled if empty")
+	payment := flag.String("payment-action", "", "Action applied without classification to new users' messages with a phone number, card number or wallet (notify, delete, mute, ban), disabled if empty")
+	customEmojiBoost := flag.Float64("custom-emoji-boost", 0.3, "Value added to the spam score of messages dense with custom emoji, 1 flags them as spam")
+	uncertaintyBand := flag.Float64("uncertainty-band", 0, "Scores within this distance of the spam threshold are reported to the log channel for review instead of acted on, disabled if 0")
+	raidThreshold := flag.Float64("raid-threshold", 0.3, "Spam threshold applied while /raid is on, if stricter than the chat's threshold")
+	raidDuration := flag.Duration("raid-duration", time.Hour, "How long /raid on lasts unless a duration is given")
+	raidDetectRate := flag.Int("raid-detect-rate", 0, "Number of new users' messages within -raid-detect-window that starts raid mode automatically, disabled if 0")
+	raidDetectWindow := flag.Duration("raid-detect-window", time.Minute, "Window in which new users' messages are counted for raid detection")
+	ignoreCodeBlocks := flag.Bool("ignore-code-blocks", false, "Exclude inline code and code blocks from link and emoji heuristics and note them in the prompt")
+
+	floodDeleteWindow := flag.Duration("flood-delete-window", 0, "When a user is banned, also delete their other messages posted within this window in bulk (at most 48h), disabled if 0")
+	purgeWindow := flag.Duration("purge-window", 24*time.Hour, "How far back /purge deletes a user's messages (at most 48h)")
+
+	notifyDedupeWindow := flag.Duration("notify-dedupe-window", 0, "Collapse log channel notifications about the same user within this window, disabled if 0")
+	notifyRateLimit := flag.Int("notify-rate-limit", 0, "Maximum log channel notifications per minute, unlimited if 0")
+
+	learning := flag.Bool("learning", false, "Adjust per-chat spam thresholds from admin /spam and /notspam feedback")
+	learningInterval := flag.Duration("learning-interval", time.Hour, "How often per-chat thresholds are adjusted in learning mode")
+	learningTargetPrecision := flag.Float64("learning-target-precision", 0.9, "Share of detections that should be real spam; the threshold is raised while precision is below it")
+	learningStep := flag.Float64("learning-step", 0.02, "Maximum change of a chat's threshold per adjustment in learning mode")
+
+	maxTextLength := flag.Int("max-text-length", 16384, "Message texts are cut to this many bytes before processing, guarding against oversized messages; not cut if 0")
+	maxMessageAge := flag.Duration("max-message-age", 0, "Skip classifying messages older than this when they are processed, e.g. while catching up after a raid, disabled if 0")
+	workers := flag.Int("workers", 1, "Number of messages processed concurrently")
+	perUserOrder := flag.Bool("per-user-order", false, "With several workers, act on each user's messages in the order they arrived while still classifying them concurrently")
+	pollTimeout := flag.Duration("poll-timeout", 60*time.Second, "Long-poll timeout of getUpdates")
+	pollLimit := flag.Int("poll-limit", 0, "Maximum number of updates fetched per getUpdates call (1-100), Telegram's default of 100 if 0")
+	exitOnConflict := flag.Int("exit-on-conflict", 0, "Exit after this many consecutive getUpdates conflicts (409) caused by another instance polling with the same token, disabled if 0")
+	webhookURL := flag.String("webhook-url", "", "Public HTTPS base URL Telegram sends updates to instead of polling, e.g. https://bot.example.com; disabled if empty")
+	webhookAddr := flag.String("webhook-addr", ":8443", "Address the webhook server listens on")
+	webhookSecretValue := flag.String("webhook-secret", "", "Secret token Telegram sends with webhook updates, requests without it are rejected; or $ENV_VAR to read it from the environment")
+	pollJitter := flag.Duration("poll-jitter", 500*time.Millisecond, "Upper bound of the random pause after empty or failed getUpdates calls, disabled if 0")
+	providerConcurrency := flag.Int("provider-concurrency", 0, "Maximum number of in-flight calls to each AI provider, unlimited if 0")
+	requestsPerMinute := flag.Int("requests-per-minute", 0, "Maximum number of calls to each AI provider per minute, unlimited if 0")
+	requestsPerDay := flag.Int("requests-per-day", 0, "Maximum number of calls to each AI provider per UTC day, unlimited if 0")
+	requestBudgetPolicy := flag.String("request-budget-policy", "queue", "What happens to calls beyond the per-minute request budget (queue, shed), calls beyond the per-day budget are always shed")
+
+	snapshotDir := flag.String("snapshot-dir", "", "Directory for periodic snapshots of the bot's Redis keys, disabled if empty")
+	snapshotInterval := flag.Duration("snapshot-interval", 6*time.Hour, "How often a snapshot is written")
+	snapshotKeep := flag.Int("snapshot-keep", 7, "Number of most recent snapshots kept, all if 0")
+	restoreSnapshot := flag.Bool("restore-snapshot", false, "On startup, restore the latest snapshot if Redis has no keys under the prefix")
+
+	adminAddr := flag.String("admin-addr", "", "Address for the admin HTTP server with the health and metrics endpoints (e.g. :8080), disabled if empty")
+	metricsMinChatMessages := flag.Int("metrics-chat-min-messages", 100, "Scanned messages after which a chat gets its own label in the metrics")
+	sentryDSN := flag.String("sentry-dsn", "", "Sentry DSN receiving recovered panics and unexpected errors, or $ENV_VAR to read it from the environment (disabled if empty)")
+	metricsAuth := flag.String("metrics-auth", "", "Basic auth credentials required by the admin HTTP server in the format 'user:password', or $ENV_VAR to read them from the environment")
+	metricsTLSCert := flag.String("metrics-tls-cert", "", "TLS certificate file for the admin HTTP server")
+	metricsTLSKey := flag.String("metrics-tls-key", "", "TLS key file for the admin HTTP server")
+	metricsMaxChats := flag.Int("metrics-max-chats", 50, "Maximum number of chats with their own label in the metrics")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector (host:port) the metrics are pushed to, disabled if empty")
+	otlpInsecure := flag.Bool("otlp-insecure", false, "Push to the OTLP collector over plain HTTP")
+	otlpInterval := flag.Duration("otlp-interval", 30*time.Second, "How often metrics are pushed to the OTLP collector")
+	otlpTraces := flag.Bool("otlp-traces", false, "Also export spans around classification and actions to the OTLP collector")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
 
 	flag.Parse()
 
+	onErrorPolicy, err := bot.ParseErrorPolicy(*onError)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	costCapFallbackPolicy, err := bot.ParseCostCapFallback(*costCapFallback)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *temperature < 0 || *temperature > 2 {
+		fmt.Printf("invalid temperature: %.2f (expected a value between 0 and 2)\n", *temperature)
+		os.Exit(1)
+	}
+	if *uncertaintyBand < 0 || *uncertaintyBand > 0.5 {
+		fmt.Printf("invalid uncertainty band: %.2f (expected a value between 0 and 0.5)\n", *uncertaintyBand)
+		os.Exit(1)
+	}
+	if *stubScore < 0 || *stubScore > 1 {
+		fmt.Printf("invalid stub score: %.2f (expected a value between 0 and 1)\n", *stubScore)
+		os.Exit(1)
+	}
+	if *topP < 0 || *topP > 1 {
+		fmt.Printf("invalid top_p: %.2f (expected a value between 0 and 1)\n", *topP)
+		os.Exit(1)
+	}
+
+	if *requestBudgetPolicy != "queue" && *requestBudgetPolicy != "shed" {
+		fmt.Printf("unknown request budget policy: %s (expected queue or shed)\n", *requestBudgetPolicy)
+		os.Exit(1)
+	}
+
+	senderChatPolicy, err := bot.ParseSenderChatPolicy(*senderChat)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	voicePolicy, err := bot.ParseVoicePolicy(*voice)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if !locale.Supported(*defaultLocale) {
+		fmt.Printf("unsupported locale: %s\n", *defaultLocale)
+		os.Exit(1)
+	}
+
+	var noticeTemplate *template.Template
+	if *notice != "" {
+		noticeTemplate, err = bot.ParseNoticeTemplate(*notice)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	var repostActionValue bot.Action
+	if *repostAction != "" {
+		repostActionValue, err = bot.ParseAction(*repostAction)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	var paymentAction bot.Action
+	if *payment != "" {
+		paymentAction, err = bot.ParseAction(*payment)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	var bareLinkAction bot.Action
+	if *bareLink != "" {
+		bareLinkAction, err = bot.ParseAction(*bareLink)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	staleActionValue, err := bot.ParseAction(*staleAction)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	inviterAction, err := bot.ParseAction(*inviter)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	customEmojiMode, err := bot.ParseCustomEmojiMode(*customEmoji)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	joinRequestPolicy, err := bot.ParseJoinRequestPolicy(*joinRequest)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	scanFilterValue, err := bot.ParseScanFilter(*scanFilter)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	actionPolicyValue, err := bot.ParseActionPolicy(*actionPolicy)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	replyPolicyValue, err := bot.ParseReplyPolicy(*replyPolicy)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *learning && (*learningTargetPrecision <= 0 || *learningTargetPrecision > 1 || *learningStep <= 0 || *learningInterval <= 0) {
+		fmt.Println("learning-target-precision must be in (0, 1], learning-step and learning-interval must be positive")
+		os.Exit(1)
+	}
+
+	if *pollLimit < 0 || *pollLimit > 100 || *pollTimeout < 0 {
+		fmt.Println("poll-limit must be between 0 and 100 and poll-timeout must not be negative")
+		os.Exit(1)
+	}
+
+	webhookSecretToken, err := webhookSecret(*webhookSecretValue)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	adminOptions := server.Options{
+		BasicAuth: *metricsAuth,
+		TLSCert:   *metricsTLSCert,
+		TLSKey:    *metricsTLSKey,
+	}
+	adminOptions.BasicAuth, err = metricsCredentials(adminOptions.BasicAuth)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if (adminOptions.TLSCert == "") != (adminOptions.TLSKey == "") {
+		fmt.Println("metrics-tls-cert and metrics-tls-key must be set together")
+		os.Exit(1)
+	}
+
+	info := buildinfo.New(*apiProvider, *model, map[string]bool{
+		"whitelist_channels": len(whitelistChannels) > 0,
+		"log_channels":       len(logChannels) > 0 || *defaultLogChannel != 0,
+		"admin_server":       *adminAddr != "",
+		"labels":             len(labels) > 0,
+		"debug_store":        *debugStoreEnabled,
+		"eval_sampling":      *evalSampleRate > 0,
+		"learning":           *learning,
+		"spam_examples":      *spamExamples > 0,
+		"shadow_mode":        *shadowProviderName != "",
+		"join_requests":      joinRequestPolicy != bot.JoinRequestPolicyOff,
+		"cas":                *useCAS,
+		"near_duplicates":    *embeddingsProvider != "",
+		"otlp":               *otlpEndpoint != "",
+		"first_message_only": *firstMessageOnly,
+	})
+	if *showVersion {
+		fmt.Println(info.String())
+		os.Exit(0)
+	}
+
 	var logLevelValue slog.Level
 	switch strings.ToLower(*logLevel) {
 	case "debug":
@@ -52,6 +416,41 @@ func main() { //nolint:gocyclo,gocognit
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevelValue}))
 
+	if err := httpclient.Configure(httpclient.Options{Proxy: *httpProxy, Headers: http.Header(httpHeaders)}); err != nil {
+		logger.Error("Invalid HTTP client configuration", "error", err)
+		os.Exit(1)
+	}
+
+	rateLimit := 0.0
+	providers := providerConfig{
+		Name:           *apiProvider,
+		Model:          *model,
+		RateLimit:      rateLimit,
+		ForceJSON:      *forceJSON,
+		Sampling:       samplingConfig{Default: ai.Sampling{Temperature: *temperature, TopP: *topP}, Providers: providerSampling},
+		AdaptationsDir: *promptAdaptations,
+		StubScore:      *stubScore,
+		StubLatency:    *stubLatency,
+	}
+
+	// classify and lint-prompt work without Redis or a bot token, logging to stderr to keep stdout parseable
+	if flag.Arg(0) == "classify" {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevelValue}))
+		if err := runClassify(logger, providers, *promptPath, flag.Args()[1:]); err != nil {
+			logger.Error("Classification failed", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if flag.Arg(0) == "lint-prompt" {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevelValue}))
+		if err := runLintPrompt(logger, providers, *promptPath, flag.Args()[1:]); err != nil {
+			logger.Error("Prompt check failed", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	redisURL := os.Getenv("REDIS_URL")
 	if redisURL == "" {
 		logger.Error("REDIS_URL environment variable is not set")
@@ -65,8 +464,8 @@ func main() { //nolint:gocyclo,gocognit
 
 	rdb := redis.NewClient(redisOptions)
 
-	_, err = rdb.Ping(ctx).Result()
-	if err != nil {
+	ping := func(ctx context.Context) error { return rdb.Ping(ctx).Err() }
+	if err := connectRedis(ctx, logger, ping, *redisConnectRetries, *redisConnectTimeout); err != nil {
 		logger.Error("Failed to connect to Redis", "error", err)
 		os.Exit(1)
 	}
@@ -75,99 +474,340 @@ func main() { //nolint:gocyclo,gocognit
 
 	logger.Info("Connected to Redis", "url", redisURL)
 
+	if flag.Arg(0) == "replay" {
+		provider, err := newProvider(logger, providers)
+		if err != nil {
+			logger.Error("Failed to create AI provider", "error", err)
+			os.Exit(1)
+		}
+		if err := runReplay(ctx, debugstore.New(rdb, *redisPrefix, *debugStoreTTL), provider, flag.Arg(1)); err != nil {
+			logger.Error("Replay failed", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if flag.Arg(0) == "evaluate" {
+		if err := runEvaluate(ctx, logger, evalstore.New(rdb, *redisPrefix, *evalStoreSize), providers, *promptPath, *threshold, flag.Args()[1:]); err != nil {
+			logger.Error("Evaluation failed", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Bots of a bots file keep their message counts in their own namespaces
+	prefixes, err := historyPrefixes(*botsPath, *redisPrefix)
+	if err != nil {
+		logger.Error("Failed to load bots config", "error", err)
+		os.Exit(1)
+	}
+
+	if flag.Arg(0) == "load-history" {
+		for _, prefix := range prefixes {
+			if err := runLoadHistory(ctx, rdb, prefix, *historyFile, *historyBatchSize, flag.Args()[1:]); err != nil {
+				logger.Error("Loading history failed", "error", err, "redisPrefix", prefix)
+				os.Exit(1)
+			}
+		}
+		os.Exit(0)
+	}
+
+	var snapshotter *snapshot.Snapshotter
+	if *snapshotDir != "" {
+		snapshotter = snapshot.New(logger, rdb, *redisPrefix, *snapshotDir, *snapshotKeep)
+		if *restoreSnapshot {
+			restoreLatestSnapshot(ctx, logger, snapshotter)
+		}
+	}
+
 	// Load history if the flag is not empty and Redis is empty
 	if *historyFile != "" {
-		err := history.ProcessFile(*historyFile, rdb)
-		if err != nil {
-			logger.Error("Failed to load history", "error", err)
-			// Decide whether to continue or exit based on your requirements
-			// os.Exit(1)
-		} else {
-			logger.Info("History loaded", "file", *historyFile)
+		loaded := false
+		for _, prefix := range prefixes {
+			result, err := history.ProcessFile(*historyFile, rdb, prefix, *historyBatchSize)
+			logHistoryErrors(logger, result.Errors)
+			if err != nil {
+				logger.Error("Failed to load history", "error", err, "redisPrefix", prefix)
+				// Decide whether to continue or exit based on your requirements
+				// os.Exit(1)
+				continue
+			}
+			loaded = true
+			logger.Info("History loaded", "file", *historyFile, "redisPrefix", prefix, "loaded", result.Loaded, "skipped", result.Skipped, "errors", len(result.Errors))
+		}
+		if loaded {
 			logger.Info("Exiting, run without the --history flag to continue")
 			os.Exit(0)
 		}
 	} else {
 		// TODO: check by channel's ids for multiple channels
-		// Check if Redis is empty
-		keysCount, err := rdb.DBSize(ctx).Result()
+		// Check if each bot's history is empty, ignoring unrelated keys
+		for _, prefix := range prefixes {
+			keysCount, err := history.CountKeys(ctx, rdb, prefix)
+			if err != nil {
+				logger.Error("Failed to count history keys", "error", err, "redisPrefix", prefix)
+				os.Exit(1)
+			}
+			if keysCount == 0 {
+				logger.Warn("HISTORY IS EMPTY, STARTING WITH AN EMPTY HISTORY!", "redisPrefix", prefix)
+			} else {
+				logger.Info("Total history size", "count", keysCount, "redisPrefix", prefix)
+			}
+		}
+	}
+	provider, err := newProvider(logger, providers)
+	if err != nil {
+		logger.Error("Failed to create AI provider", "error", err)
+		os.Exit(1)
+	}
+	var shadowProvider ai.Provider
+	if *shadowProviderName != "" {
+		shadowProviders := providers
+		shadowProviders.Name, shadowProviders.Model = *shadowProviderName, *shadowModel
+		shadowProvider, err = newProvider(logger, shadowProviders)
 		if err != nil {
-			logger.Error("Failed to get Redis database size", "error", err)
+			logger.Error("Failed to create shadow AI provider", "error", err)
 			os.Exit(1)
 		}
-		if keysCount == 0 {
-			logger.Warn("REDIS DATABASE IS EMPTY, STARTING WITH AN EMPTY HISTORY!")
-		} else {
-			logger.Info("Total history size", "count", keysCount)
+	}
+	if *providerConcurrency > 0 {
+		provider = ai.NewLimitedProvider(provider, *providerConcurrency)
+		if shadowProvider != nil {
+			shadowProvider = ai.NewLimitedProvider(shadowProvider, *providerConcurrency)
 		}
 	}
-	// Read API key from environment variable
-	var apiKey string
-	var provider ai.Provider
-	rateLimit := 0.0
-	switch *apiProvider {
-	case "openai":
-		apiKey = os.Getenv("OPENAI_API_KEY")
-		if apiKey == "" {
-			fmt.Println("OPENAI_API_KEY environment variable is not set")
+	// Outside the concurrency limit, so queued calls don't hold a slot
+	if *requestsPerMinute > 0 || *requestsPerDay > 0 {
+		shed := *requestBudgetPolicy == "shed"
+		provider = budgetProvider("primary", provider, *requestsPerMinute, *requestsPerDay, shed)
+		if shadowProvider != nil {
+			shadowProvider = budgetProvider("shadow", shadowProvider, *requestsPerMinute, *requestsPerDay, shed)
+		}
+	}
+	prompt, err := loadPrompt(logger, *promptPath)
+	if err != nil {
+		logger.Error("Failed to load prompt", "error", err)
+		os.Exit(1)
+	}
+	var bioPrompt string
+	if *bioPromptPath != "" {
+		bioPrompt, err = loadPrompt(logger, *bioPromptPath)
+		if err != nil {
+			logger.Error("Failed to load bio prompt", "error", err)
 			os.Exit(1)
 		}
-		provider = ai.NewOpenAIProvider(apiKey, *model, rateLimit)
-		logger.Info("Using OpenAI API", "model", *model)
-	case "anthropic":
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-		if apiKey == "" {
-			fmt.Println("ANTHROPIC_API_KEY environment variable is not set")
+	}
+	var embedder ai.Embedder
+	if *embeddingsProvider != "" {
+		embedder, err = newEmbedder(logger, *embeddingsProvider, *embeddingsModel)
+		if err != nil {
+			logger.Error("Failed to create embeddings provider", "error", err)
 			os.Exit(1)
 		}
-		provider = ai.NewAnthropicProvider(apiKey, *model, rateLimit)
-		logger.Info("Using Anthropic API", "model", *model)
-	case "gemini":
-		apiKey := os.Getenv("GEMINI_API_KEY")
-		if apiKey == "" {
-			logger.Error("GEMINI_API_KEY environment variable is not set")
+	}
+
+	baseConfig := bot.Config{
+		Prompt:                  prompt,
+		ForceJSON:               *forceJSON,
+		Threshold:               *threshold,
+		NewUserThreshold:        *newUserThreshold,
+		FirstMessageOnly:        *firstMessageOnly,
+		ScanWindow:              *scanWindow,
+		WhitelistChannels:       whitelistChannels,
+		LogChannels:             logChannels,
+		DefaultLogChannel:       *defaultLogChannel,
+		BuildInfo:               info,
+		Labels:                  labels,
+		LengthThresholds:        lengthThresholds,
+		DebugStoreTTL:           durationIfEnabled(*debugStoreEnabled, *debugStoreTTL),
+		EvalSampleRate:          *evalSampleRate,
+		EvalStoreSize:           *evalStoreSize,
+		EvalKeepContent:         *evalKeepContent,
+		OnError:                 onErrorPolicy,
+		DailyCostCap:            *dailyCostCap,
+		CostPer1KTokens:         *costPer1KTokens,
+		CostCapFallback:         costCapFallbackPolicy,
+		SpamExamples:            *spamExamples,
+		SpamExamplesChars:       *spamExamplesChars,
+		RegisterCommands:        *registerCommands,
+		SenderChatPolicy:        senderChatPolicy,
+		VoicePolicy:             voicePolicy,
+		CleanServiceMessages:    *cleanServiceMessages,
+		CleanPinnedMessages:     *cleanPinnedMessages,
+		SampleRate:              *sampleRate,
+		ShadowProvider:          shadowProvider,
+		MetricsMinChatMessages:  *metricsMinChatMessages,
+		MetricsMaxChats:         *metricsMaxChats,
+		JoinRequestPolicy:       joinRequestPolicy,
+		UseCAS:                  *useCAS,
+		NewAccountID:            *newAccountID,
+		NewAccountBoost:         *newAccountBoost,
+		PurgeWindow:             *purgeWindow,
+		FloodDeleteWindow:       *floodDeleteWindow,
+		RedisPrefix:             *redisPrefix,
+		NotifyDedupeWindow:      *notifyDedupeWindow,
+		NotifyRateLimit:         *notifyRateLimit,
+		ScanFilter:              scanFilterValue,
+		ScanCommands:            *scanCommands,
+		LearningInterval:        durationIfEnabled(*learning, *learningInterval),
+		LearningTargetPrecision: *learningTargetPrecision,
+		LearningStep:            *learningStep,
+		Workers:                 *workers,
+		TenureGrace:             *tenureGrace,
+		PerUserOrder:            *perUserOrder,
+		PollTimeout:             *pollTimeout,
+		PollLimit:               *pollLimit,
+		PollJitter:              *pollJitter,
+		ExitOnConflict:          *exitOnConflict,
+		WebhookURL:              strings.TrimSuffix(*webhookURL, "/"),
+		WebhookSecret:           webhookSecretToken,
+		MaxMessageAge:           *maxMessageAge,
+		MaxTextLength:           *maxTextLength,
+		PauseAI:                 *pauseAI,
+		SuperAdmins:             superAdmins,
+		AdminCacheTTL:           *adminCacheTTL,
+		IgnoreCodeBlocks:        *ignoreCodeBlocks,
+		RaidThreshold:           *raidThreshold,
+		UncertaintyBand:         *uncertaintyBand,
+		RaidDuration:            *raidDuration,
+		RaidDetectRate:          *raidDetectRate,
+		RaidDetectWindow:        *raidDetectWindow,
+		NotificationDetailsTTL:  *notificationDetails,
+		DecisionTTL:             *decisionTTL,
+		AuditKeepContent:        *auditKeepContent,
+		AuditContentTTL:         *auditContentTTL,
+		ContextMessages:         *contextMessages,
+		ContextMessagesChars:    *contextMessagesChars,
+		ActionPolicy:            actionPolicyValue,
+		WarmupDetections:        *warmupDetections,
+		WarmupDuration:          *warmupDuration,
+		Notice:                  noticeTemplate,
+		NoticeInterval:          *noticeInterval,
+		NoticeTTL:               *noticeTTL,
+		UndoWindow:              *undoWindow,
+		UndoReaction:            *undoReaction,
+		Locale:                  *defaultLocale,
+		MuteDuration:            *muteDuration,
+		StaleAction:             staleActionValue,
+		DeleteVerifyAttempts:    *deleteVerifyAttempts,
+		DeleteVerifyDelay:       *deleteVerifyDelay,
+		InviterThreshold:        *inviterThreshold,
+		InviterAction:           inviterAction,
+		EmojiRatio:              *emojiRatio,
+		EmojiRatioBoost:         *emojiRatioBoost,
+		CustomEmoji:             customEmojiMode,
+		CustomEmojiRatio:        *customEmojiRatio,
+		CustomEmojiBoost:        *customEmojiBoost,
+		LanguageBoost:           *languageBoost,
+		PhoneBoost:              *phoneBoost,
+		EmailBoost:              *emailBoost,
+		MentionBoost:            *mentionBoost,
+		MentionCount:            *mentionCount,
+		WalletBoost:             *walletBoost,
+		CardBoost:               *cardBoost,
+		PaymentAction:           paymentAction,
+		BareLinkBoost:           *bareLinkBoost,
+		BareLinkAction:          bareLinkAction,
+		RepostWindow:            *repostWindow,
+		RepostBoost:             *repostBoost,
+		RepostAction:            repostActionValue,
+		BioPrompt:               bioPrompt,
+		BioThreshold:            *bioThreshold,
+		BioBoost:                *bioBoost,
+		ImpersonationSimilarity: *impersonationSimilarity,
+		Embedder:                embedder,
+		NearDuplicateThreshold:  *nearDuplicateThreshold,
+		NearDuplicateMax:        *nearDuplicateMax,
+		ReportChannel:           *reportChannel,
+		ReportDir:               *reportDir,
+		ReportOnBan:             *reportOnBan,
+		ReplyPolicy:             replyPolicyValue,
+		ReplyToAdminWeight:      *replyToAdminWeight,
+		ThreadCleanMessages:     *threadCleanMessages,
+		ThreadWeight:            *threadWeight,
+	}
+
+	if dsn := *sentryDSN; dsn != "" {
+		if strings.HasPrefix(dsn, "$") {
+			dsn = os.Getenv(strings.TrimPrefix(dsn, "$"))
+		}
+		reporter, err := errreport.NewSentry(logger, dsn)
+		if err != nil {
+			fmt.Println(err)
 			os.Exit(1)
 		}
+		baseConfig.Reporter = reporter
+		defer reporter.Flush(5 * time.Second)
+	}
 
-		geminiProvider, err := ai.NewGeminiProvider(apiKey, *model, rateLimit)
+	configs := []bot.Config{baseConfig}
+	if *botsPath != "" {
+		configs, err = loadBotConfigs(*botsPath, baseConfig)
 		if err != nil {
-			logger.Error("Error creating Gemini provider", "error", err)
+			logger.Error("Failed to load bots config", "error", err)
 			os.Exit(1)
 		}
-		provider = geminiProvider
-		logger.Info("Using Gemini API", "model", *model)
-	default:
-		fmt.Printf("Unsupported API provider: %s\n", *apiProvider)
-		os.Exit(1)
 	}
-	prompt := ""
-	if *promptPath != "" {
-		promptBytes, err := os.ReadFile(*promptPath)
+
+	bots := make([]*bot.Bot, 0, len(configs))
+	for i := range configs {
+		botLogger := logger
+		if len(configs) > 1 {
+			botLogger = logger.With("bot", i)
+		}
+		instance, err := bot.New(botLogger, rdb, provider, &configs[i])
 		if err != nil {
-			logger.Error("Failed to read prompt file", "error", err)
+			logger.Error("Failed to create bot", "error", err, "bot", i)
 			os.Exit(1)
 		}
-		prompt = string(promptBytes)
+		bots = append(bots, instance)
 	}
-	if prompt == "" {
-		fmt.Println("No prompt provided")
-		os.Exit(1)
+
+	if *otlpEndpoint != "" {
+		shutdownTelemetry, err := telemetry.Setup(ctx, telemetry.Config{
+			Endpoint: *otlpEndpoint,
+			Insecure: *otlpInsecure,
+			Interval: *otlpInterval,
+			Traces:   *otlpTraces,
+		}, info)
+		if err != nil {
+			logger.Error("Failed to set up OpenTelemetry export", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTelemetry(flushCtx); err != nil {
+				logger.Error("Failed to flush OpenTelemetry export", "error", err)
+			}
+		}()
+		logger.Info("Exporting telemetry over OTLP", "endpoint", *otlpEndpoint, "traces", *otlpTraces)
 	}
 
-	bot, err := bot.New(logger, rdb, provider, &bot.Config{
-		Prompt:            prompt,
-		Threshold:         *threshold,
-		NewUserThreshold:  *newUserThreshold,
-		WhitelistChannels: whitelistChannels,
-		LogChannels:       logChannels,
-	})
+	// Listening before the webhook is set, so no update is turned away
+	var webhooks *webhookServer
+	if *webhookURL != "" {
+		if webhookSecretToken == "" {
+			logger.Warn("Webhook without a secret token, anyone knowing its URL can send updates")
+		}
+		webhooks = newWebhookServer(logger, *webhookAddr, bots)
+		go webhooks.Start()
+	}
 
-	if err != nil {
-		logger.Error("Failed to create bot", "error", err)
-		os.Exit(1)
+	for _, instance := range bots {
+		go instance.Start()
+	}
+
+	if snapshotter != nil && *snapshotInterval > 0 {
+		go snapshotter.Start(*snapshotInterval)
 	}
 
-	go bot.Start()
+	var adminServer *server.Server
+	if *adminAddr != "" {
+		adminServer = server.New(logger, *adminAddr, info, adminOptions)
+		adminServer.Handle("/metrics", metrics.Handler())
+		go adminServer.Start()
+	}
 
 	// Wait for interrupt signal to gracefully shutdown the bot
 	quit := make(chan os.Signal, 1)
@@ -175,7 +815,100 @@ func main() { //nolint:gocyclo,gocognit
 	<-quit
 
 	logger.Info("Shutting down bot...")
-	bot.Stop()
+	if webhooks != nil {
+		webhooks.Stop()
+	}
+	if adminServer != nil {
+		adminServer.Stop()
+	}
+	if snapshotter != nil {
+		snapshotter.Stop()
+	}
+	for _, instance := range bots {
+		instance.Stop()
+	}
+}
+
+// restoreLatestSnapshot restores the most recent snapshot into an empty store
+func restoreLatestSnapshot(ctx context.Context, logger *slog.Logger, snapshotter *snapshot.Snapshotter) {
+	empty, err := snapshotter.IsEmpty(ctx)
+	if err != nil {
+		logger.Error("Failed to check Redis before restoring a snapshot", "error", err)
+		os.Exit(1)
+	}
+	if !empty {
+		logger.Info("Redis has data, not restoring a snapshot")
+		return
+	}
+	path, err := snapshotter.Latest()
+	if err != nil {
+		logger.Warn("No snapshot to restore", "error", err)
+		return
+	}
+	restored, err := snapshotter.Restore(ctx, path)
+	if err != nil {
+		logger.Error("Failed to restore snapshot", "error", err, "path", path)
+		os.Exit(1)
+	}
+	logger.Info("Restored snapshot", "path", path, "keys", restored)
+}
+
+// loadPrompt reads the prompt template, which must not be empty
+func loadPrompt(logger *slog.Logger, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("no prompt provided")
+	}
+	promptBytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading prompt file: %w", err)
+	}
+	prompt := strings.TrimSpace(string(promptBytes))
+	if prompt == "" {
+		return "", fmt.Errorf("prompt file %s is empty or contains only whitespace", path)
+	}
+	if !strings.Contains(prompt, ai.MessagePlaceholder) {
+		logger.Warn("Prompt has no message placeholder, the message text will not be sent to the model", "placeholder", ai.MessagePlaceholder, "path", path)
+	}
+	return prompt, nil
+}
+
+// maxLoggedHistoryErrors bounds how many entry errors of a history file are logged
+const maxLoggedHistoryErrors = 10
+
+func logHistoryErrors(logger *slog.Logger, errs []error) {
+	for i, err := range errs {
+		if i == maxLoggedHistoryErrors {
+			logger.Warn("More history entries failed to load", "count", len(errs)-i)
+			return
+		}
+		logger.Warn("Skipped history entry", "error", err)
+	}
+}
+
+// metricsCredentials resolves credentials given as $ENV_VAR and validates them.
+// A variable that is empty or unset is an error rather than serving the admin endpoints without auth.
+func metricsCredentials(value string) (string, error) {
+	if name, fromEnv := strings.CutPrefix(value, "$"); fromEnv {
+		value = os.Getenv(name)
+		if value == "" {
+			return "", fmt.Errorf("metrics-auth reads %s, which is empty or not set", name)
+		}
+	}
+	if value == "" {
+		return "", nil
+	}
+	if err := server.ParseBasicAuth(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// durationIfEnabled returns d, or zero to disable the feature it configures
+func durationIfEnabled(enabled bool, d time.Duration) time.Duration {
+	if !enabled {
+		return 0
+	}
+	return d
 }
 
 // intSliceFlag is a custom flag type for a slice of integers
@@ -247,3 +980,162 @@ func (l *logChannelsFlag) Set(value string) error {
 	}
 	return nil
 }
+
+// headersFlag is a custom flag type for extra HTTP headers
+type headersFlag http.Header
+
+func (h *headersFlag) String() string {
+	names := make([]string, 0, len(*h))
+	for name := range *h {
+		names = append(names, name) // Values may be secrets
+	}
+	return strings.Join(names, ",")
+}
+
+func (h *headersFlag) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+	if *h == nil {
+		*h = make(headersFlag)
+	}
+	for _, header := range strings.Split(value, ",") {
+		name, headerValue, ok := strings.Cut(strings.TrimSpace(header), "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid format for header, expected 'Name=value'")
+		}
+		if strings.HasPrefix(headerValue, "$") {
+			headerValue = os.Getenv(strings.TrimPrefix(headerValue, "$"))
+		}
+		http.Header(*h).Add(name, headerValue)
+	}
+	return nil
+}
+
+// lengthThresholdsFlag is a custom flag type for a list of length-bucketed threshold offsets
+type lengthThresholdsFlag []bot.LengthThreshold
+
+func (l *lengthThresholdsFlag) String() string {
+	buckets := make([]string, 0, len(*l))
+	for _, bucket := range *l {
+		maxLength := ""
+		if bucket.MaxLength > 0 {
+			maxLength = strconv.Itoa(bucket.MaxLength)
+		}
+		buckets = append(buckets, fmt.Sprintf("%d-%s:%+.2f", bucket.MinLength, maxLength, bucket.Offset))
+	}
+	return strings.Join(buckets, ",")
+}
+
+func (l *lengthThresholdsFlag) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, bucket := range strings.Split(value, ",") {
+		lengths, offsetValue, found := strings.Cut(strings.TrimSpace(bucket), ":")
+		minValue, maxValue, isRange := strings.Cut(lengths, "-")
+		if !found || !isRange {
+			return fmt.Errorf("invalid format for length threshold, expected 'min-max:offset'")
+		}
+
+		var parsed bot.LengthThreshold
+		var err error
+		if minValue != "" {
+			if parsed.MinLength, err = strconv.Atoi(minValue); err != nil || parsed.MinLength < 0 {
+				return fmt.Errorf("invalid minimum length in %s", bucket)
+			}
+		}
+		if maxValue != "" {
+			if parsed.MaxLength, err = strconv.Atoi(maxValue); err != nil || parsed.MaxLength <= parsed.MinLength {
+				return fmt.Errorf("invalid maximum length in %s", bucket)
+			}
+		}
+		if parsed.Offset, err = strconv.ParseFloat(offsetValue, 64); err != nil || parsed.Offset <= -1 || parsed.Offset >= 1 {
+			return fmt.Errorf("invalid offset in %s, expected a value between -1 and 1", bucket)
+		}
+
+		*l = append(*l, parsed)
+	}
+	return nil
+}
+
+// providerSamplingFlag is a custom flag type for a map of provider names to sampling
+// parameters, a negative TopP meaning the -top-p default
+type providerSamplingFlag map[string]ai.Sampling
+
+func (p *providerSamplingFlag) String() string {
+	overrides := make([]string, 0, len(*p))
+	for name, sampling := range *p {
+		if sampling.TopP < 0 {
+			overrides = append(overrides, fmt.Sprintf("%s=%.2f", name, sampling.Temperature))
+		} else {
+			overrides = append(overrides, fmt.Sprintf("%s=%.2f:%.2f", name, sampling.Temperature, sampling.TopP))
+		}
+	}
+	return strings.Join(overrides, ",")
+}
+
+func (p *providerSamplingFlag) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+	if *p == nil {
+		*p = make(providerSamplingFlag)
+	}
+	for _, override := range strings.Split(value, ",") {
+		name, parameters, ok := strings.Cut(strings.TrimSpace(override), "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid format for provider sampling, expected 'provider=temperature[:top_p]'")
+		}
+		temperatureValue, topPValue, hasTopP := strings.Cut(parameters, ":")
+
+		sampling := ai.Sampling{TopP: -1}
+		var err error
+		if sampling.Temperature, err = strconv.ParseFloat(temperatureValue, 64); err != nil || sampling.Temperature < 0 || sampling.Temperature > 2 {
+			return fmt.Errorf("invalid temperature for provider %s, expected a value between 0 and 2", name)
+		}
+		if hasTopP {
+			if sampling.TopP, err = strconv.ParseFloat(topPValue, 64); err != nil || sampling.TopP < 0 || sampling.TopP > 1 {
+				return fmt.Errorf("invalid top_p for provider %s, expected a value between 0 and 1", name)
+			}
+		}
+		(*p)[name] = sampling
+	}
+	return nil
+}
+
+// labelsFlag is a custom flag type for a list of label rules
+type labelsFlag []bot.LabelRule
+
+func (l *labelsFlag) String() string {
+	rules := make([]string, 0, len(*l))
+	for _, rule := range *l {
+		rules = append(rules, fmt.Sprintf("%s:%.2f:%s", rule.Name, rule.Threshold, rule.Action))
+	}
+	return strings.Join(rules, ",")
+}
+
+func (l *labelsFlag) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, rule := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid format for label rule, expected 'label:threshold:action'")
+		}
+
+		threshold, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid threshold for label %s: %v", parts[0], err)
+		}
+
+		action, err := bot.ParseAction(parts[2])
+		if err != nil {
+			return fmt.Errorf("invalid action for label %s: %v", parts[0], err)
+		}
+
+		*l = append(*l, bot.LabelRule{Name: parts[0], Threshold: threshold, Action: action})
+	}
+	return nil
+}
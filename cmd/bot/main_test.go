@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestMetricsCredentials(t *testing.T) {
+	t.Setenv("METRICS_CREDENTIALS", "prometheus:secret")
+	t.Setenv("EMPTY_CREDENTIALS", "")
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"prometheus:secret", "prometheus:secret", false},
+		{"$METRICS_CREDENTIALS", "prometheus:secret", false},
+		{"$EMPTY_CREDENTIALS", "", true},
+		{"$UNSET_CREDENTIALS", "", true},
+		{"prometheus", "", true},
+	}
+	for _, tt := range tests {
+		got, err := metricsCredentials(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("metricsCredentials(%q) = %q, %v, want %q (error %t)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+)
+
+// jsonModeProvider is implemented by providers that can be switched to JSON mode
+type jsonModeProvider interface {
+	ForceJSON()
+}
+
+// systemPromptProvider is implemented by providers accepting a system prompt
+type systemPromptProvider interface {
+	SetSystemPrompt(prompt string)
+}
+
+// samplingProvider is implemented by providers accepting sampling parameters
+type samplingProvider interface {
+	SetSampling(sampling ai.Sampling)
+}
+
+// samplingConfig holds the default sampling parameters and their overrides by provider name
+type samplingConfig struct {
+	Default   ai.Sampling
+	Providers providerSamplingFlag
+}
+
+// forProvider returns the sampling parameters of a provider, taking an override's
+// unset top_p from the default
+func (c samplingConfig) forProvider(name string) ai.Sampling {
+	sampling, ok := c.Providers[name]
+	if !ok {
+		return c.Default
+	}
+	if sampling.TopP < 0 {
+		sampling.TopP = c.Default.TopP
+	}
+	return sampling
+}
+
+// providerConfig configures the AI provider created by newProvider
+type providerConfig struct {
+	Name           string
+	Model          string
+	RateLimit      float64
+	ForceJSON      bool // Providers supporting JSON mode use it regardless of the model
+	Sampling       samplingConfig
+	AdaptationsDir string // The provider's prompt adaptations are loaded from here if set
+	StubScore      float64
+	StubLatency    time.Duration
+}
+
+// newProvider creates the AI provider reading its API key from the environment
+func newProvider(logger *slog.Logger, config providerConfig) (ai.Provider, error) {
+	provider, err := createProvider(logger, config)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := provider.(samplingProvider); ok {
+		p.SetSampling(config.Sampling.forProvider(config.Name))
+	}
+	if config.ForceJSON {
+		if p, ok := provider.(jsonModeProvider); ok {
+			p.ForceJSON()
+		} else {
+			logger.Warn("Provider doesn't support JSON mode, parsing text responses", "provider", config.Name)
+		}
+	}
+	if config.AdaptationsDir == "" {
+		return provider, nil
+	}
+
+	adaptation, err := loadPromptAdaptation(config.AdaptationsDir, config.Name)
+	if err != nil {
+		return nil, err
+	}
+	if adaptation.System != "" {
+		p, ok := provider.(systemPromptProvider)
+		if !ok {
+			return nil, fmt.Errorf("provider %s doesn't support a system prompt", config.Name)
+		}
+		p.SetSystemPrompt(adaptation.System)
+	}
+	if adaptation.Prefix != "" || adaptation.Suffix != "" {
+		provider = ai.NewAdaptedProvider(provider, adaptation)
+	}
+	logger.Info("Adapted prompt for provider", "provider", config.Name, "prefix", adaptation.Prefix != "", "suffix", adaptation.Suffix != "", "system", adaptation.System != "")
+	return provider, nil
+}
+
+// budgetProvider enforces the request budget on a provider, exporting its utilization under role
+func budgetProvider(role string, provider ai.Provider, perMinute, perDay int, shed bool) ai.Provider {
+	budgeted := ai.NewBudgetedProvider(provider, perMinute, perDay, shed)
+	metrics.RegisterProviderBudget(role, func() (float64, float64, uint64) {
+		usage := budgeted.Usage()
+		return usage.Minute, usage.Day, usage.Shed
+	})
+	return budgeted
+}
+
+// loadPromptAdaptation reads the optional <provider>.prefix.txt, <provider>.suffix.txt
+// and <provider>.system.txt files from dir
+func loadPromptAdaptation(dir, name string) (ai.PromptAdaptation, error) {
+	var adaptation ai.PromptAdaptation
+	for part, target := range map[string]*string{
+		"prefix": &adaptation.Prefix,
+		"suffix": &adaptation.Suffix,
+		"system": &adaptation.System,
+	} {
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s.txt", name, part))
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return ai.PromptAdaptation{}, fmt.Errorf("error reading prompt adaptation: %w", err)
+		}
+		*target = strings.TrimSpace(string(data))
+	}
+	return adaptation, nil
+}
+
+// newEmbedder creates the embeddings client for near-duplicate detection
+func newEmbedder(logger *slog.Logger, name, model string) (ai.Embedder, error) {
+	switch name {
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY environment variable is not set")
+		}
+		logger.Info("Using OpenAI embeddings", "model", model)
+		return ai.NewOpenAIEmbedder(apiKey, model), nil
+	case "mistral":
+		apiKey := os.Getenv("MISTRAL_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("MISTRAL_API_KEY environment variable is not set")
+		}
+		logger.Info("Using Mistral embeddings", "model", model)
+		return ai.NewMistralEmbedder(apiKey, model), nil
+	default:
+		return nil, fmt.Errorf("unsupported embeddings provider: %s", name)
+	}
+}
+
+func createProvider(logger *slog.Logger, config providerConfig) (ai.Provider, error) {
+	name, model, rateLimit := config.Name, config.Model, config.RateLimit
+	switch name {
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY environment variable is not set")
+		}
+		logger.Info("Using OpenAI API", "model", model)
+		return ai.NewOpenAIProvider(apiKey, model, rateLimit), nil
+	case "anthropic":
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable is not set")
+		}
+		logger.Info("Using Anthropic API", "model", model)
+		return ai.NewAnthropicProvider(apiKey, model, rateLimit), nil
+	case "mistral":
+		apiKey := os.Getenv("MISTRAL_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("MISTRAL_API_KEY environment variable is not set")
+		}
+		logger.Info("Using Mistral API", "model", model)
+		return ai.NewMistralProvider(apiKey, model, rateLimit), nil
+	case "gemini":
+		apiKey := os.Getenv("GEMINI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY environment variable is not set")
+		}
+
+		geminiProvider, err := ai.NewGeminiProvider(apiKey, model, rateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("error creating Gemini provider: %w", err)
+		}
+		logger.Info("Using Gemini API", "model", model)
+		return geminiProvider, nil
+	case "stub":
+		logger.Warn("Using the stub provider, messages aren't classified", "score", config.StubScore, "latency", config.StubLatency)
+		return ai.NewStubProvider(config.StubScore, config.StubLatency), nil
+	default:
+		return nil, fmt.Errorf("unsupported API provider: %s", name)
+	}
+}
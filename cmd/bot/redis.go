@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	redisConnectDelay    = time.Second // Pause after the first failed attempt, doubled after each one
+	maxRedisConnectDelay = 30 * time.Second
+)
+
+// connectRedis pings Redis until it answers, retrying with backoff so the bot
+// survives Redis starting a little later during deploys. Each attempt is bounded
+// by timeout; the last error is returned once retries are exhausted.
+func connectRedis(ctx context.Context, logger *slog.Logger, ping func(context.Context) error, retries int, timeout time.Duration) error {
+	delay := redisConnectDelay
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := ping(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt > retries {
+			return fmt.Errorf("redis unreachable after %d attempts: %w", attempt, err)
+		}
+		logger.Warn("Failed to connect to Redis, retrying", "error", err, "attempt", attempt, "retries", retries, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRedisConnectDelay)
+	}
+}
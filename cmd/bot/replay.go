@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/debugstore"
+)
+
+// runReplay re-sends a recorded prompt to the current provider and prints the score difference
+func runReplay(ctx context.Context, store *debugstore.Store, provider ai.Provider, id string) error {
+	if id == "" {
+		return fmt.Errorf("usage: replay <chatID:messageID>")
+	}
+
+	record, err := store.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	response, err := provider.ProcessMessage(ctx, record.Prompt)
+	if err != nil {
+		return fmt.Errorf("API error: %w", err)
+	}
+
+	result, err := ai.ParseResponse(response)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Record: %s (model %s, recorded %s)\n", record.ID, record.Model, record.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Recorded score: %.2f\n", record.SpamScore)
+	fmt.Printf("Replayed score: %.2f\n", result.SpamScore)
+	fmt.Printf("Difference: %+.2f\n", result.SpamScore-record.SpamScore)
+	fmt.Printf("Reasoning:\n%s\n", result.Reasoning)
+	return nil
+}
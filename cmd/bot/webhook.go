@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/bot"
+)
+
+// webhookSecretPattern is the alphabet and length Telegram accepts for secret tokens
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// webhookSecret resolves a secret token given as $ENV_VAR and checks Telegram accepts it
+func webhookSecret(value string) (string, error) {
+	if strings.HasPrefix(value, "$") {
+		value = os.Getenv(strings.TrimPrefix(value, "$"))
+	}
+	if value != "" && !webhookSecretPattern.MatchString(value) {
+		return "", fmt.Errorf("webhook-secret must be 1-256 letters, digits, underscores or hyphens")
+	}
+	return value, nil
+}
+
+// webhookServer receives the webhook updates of every bot, each on its own path
+type webhookServer struct {
+	logger *slog.Logger
+	server *http.Server
+}
+
+func newWebhookServer(logger *slog.Logger, addr string, bots []*bot.Bot) *webhookServer {
+	mux := http.NewServeMux()
+	for _, instance := range bots {
+		mux.Handle(instance.WebhookPath(), instance.WebhookHandler())
+	}
+	return &webhookServer{
+		logger: logger,
+		server: &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+	}
+}
+
+func (s *webhookServer) Start() {
+	s.logger.Info("Starting webhook server", "addr", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Error("Webhook server failed", "error", err)
+	}
+}
+
+func (s *webhookServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Error("Failed to stop webhook server", "error", err)
+	}
+}
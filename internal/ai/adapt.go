@@ -0,0 +1,38 @@
+package ai
+
+import "context"
+
+// PromptAdaptation tailors the shared prompt to a provider without maintaining a separate prompt file
+type PromptAdaptation struct {
+	Prefix string // Prepended to every rendered prompt
+	Suffix string // Appended to every rendered prompt
+	System string // Sent as the system prompt by providers supporting one
+}
+
+// Apply wraps a rendered prompt in the adaptation's prefix and suffix
+func (a PromptAdaptation) Apply(message string) string {
+	if a.Prefix != "" {
+		message = a.Prefix + "\n\n" + message
+	}
+	if a.Suffix != "" {
+		message = message + "\n\n" + a.Suffix
+	}
+	return message
+}
+
+// AdaptedProvider applies a prompt adaptation before passing prompts to the wrapped provider
+type AdaptedProvider struct {
+	provider   Provider
+	adaptation PromptAdaptation
+}
+
+func NewAdaptedProvider(provider Provider, adaptation PromptAdaptation) *AdaptedProvider {
+	return &AdaptedProvider{
+		provider:   provider,
+		adaptation: adaptation,
+	}
+}
+
+func (p *AdaptedProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	return p.provider.ProcessMessage(ctx, p.adaptation.Apply(message))
+}
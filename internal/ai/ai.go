@@ -11,35 +11,127 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ailabhub/giraffe-spam-crasher/internal/httpclient"
 	"github.com/google/generative-ai-go/genai"
 	"github.com/sashabaranov/go-openai"
 	"golang.org/x/time/rate"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 type Result struct {
-	Reasoning string  `json:"reasoning"`
-	SpamScore float64 `json:"spam_score"`
+	Reasoning string             `json:"reasoning"`
+	SpamScore float64            `json:"spam_score"`
+	Labels    map[string]float64 `json:"labels,omitempty"` // Additional labeled scores, e.g. "scam" or "nsfw"
 }
 
 type SpamClassification struct {
 	SpamScore float64 `json:"spam_score"`
 }
 
+const (
+	// MessagePlaceholder is replaced with the message text when rendering a prompt
+	MessagePlaceholder = "{{CHANNEL_CONTENT}}"
+	// ExamplesPlaceholder is replaced with few-shot spam examples, if present in the prompt
+	ExamplesPlaceholder = "{{SPAM_EXAMPLES}}"
+	// ContextPlaceholder is replaced with notes about the sender, if present in the prompt
+	ContextPlaceholder = "{{SENDER_CONTEXT}}"
+	// ChatPlaceholder is replaced with the messages preceding the classified one, if present in the prompt
+	ChatPlaceholder = "{{CHAT_CONTEXT}}"
+)
+
 // Global variables for prompt
 var (
 	reasoningRegex = regexp.MustCompile(`<reasoning>([\s\S]*?)</reasoning>`)
 	jsonRegex      = regexp.MustCompile(`<json>([\s\S]*?)</json>`)
+	// Tags of the examples block, stripped from example texts so they can't close it early
+	exampleTagRegex = regexp.MustCompile(`(?i)</?\s*(?:spam_examples|example)\s*>`)
 )
 
 type Provider interface {
 	ProcessMessage(ctx context.Context, message string) (string, error)
 }
 
+// LimitedProvider bounds the number of in-flight calls to the wrapped provider
+type LimitedProvider struct {
+	provider Provider
+	slots    chan struct{}
+}
+
+func NewLimitedProvider(provider Provider, concurrency int) *LimitedProvider {
+	return &LimitedProvider{
+		provider: provider,
+		slots:    make(chan struct{}, concurrency),
+	}
+}
+
+func (p *LimitedProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return "", fmt.Errorf("concurrency limit error: %w", ctx.Err())
+	}
+	defer func() { <-p.slots }()
+
+	return p.provider.ProcessMessage(ctx, message)
+}
+
+// jsonModeModels lists OpenAI model prefixes supporting response_format json_object
+var jsonModeModels = []string{"gpt-4o", "gpt-4-turbo", "gpt-4.1", "gpt-3.5-turbo", "gpt-5", "o1", "o3", "o4"}
+
+// supportsJSONMode reports whether an OpenAI model accepts JSON mode
+func supportsJSONMode(model string) bool {
+	for _, prefix := range jsonModeModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// chatMessages returns the messages of an OpenAI-compatible request, led by the system prompt if set
+func chatMessages(systemPrompt, message string) []openai.ChatCompletionMessage {
+	var messages []openai.ChatCompletionMessage
+	if systemPrompt != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: systemPrompt,
+		})
+	}
+	return append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: message,
+	})
+}
+
+// openAIConfig returns the configuration of an OpenAI-compatible client using the
+// configured proxy and headers, pointed at baseURL unless it is empty
+func openAIConfig(apiKey, baseURL string) openai.ClientConfig {
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	config.HTTPClient = httpclient.New(0)
+	return config
+}
+
+// responseFormat returns the JSON mode response format if enabled
+func responseFormat(jsonMode bool) *openai.ChatCompletionResponseFormat {
+	if !jsonMode {
+		return nil
+	}
+	return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+}
+
 type OpenAIProvider struct {
-	client      *openai.Client
-	model       string
-	rateLimiter *rate.Limiter
+	client       *openai.Client
+	model        string
+	rateLimiter  *rate.Limiter
+	jsonMode     bool
+	toolCalling  bool // Classification is returned as the arguments of a forced tool call
+	systemPrompt string
+	sampling     Sampling
 }
 
 func NewOpenAIProvider(apiKey, model string, rateLimit float64) *OpenAIProvider {
@@ -50,44 +142,137 @@ func NewOpenAIProvider(apiKey, model string, rateLimit float64) *OpenAIProvider
 		limiter = rate.NewLimiter(rate.Inf, 0) // No rate limit
 	}
 	return &OpenAIProvider{
-		client:      openai.NewClient(apiKey),
+		client:      openai.NewClientWithConfig(openAIConfig(apiKey, "")),
 		model:       model,
 		rateLimiter: limiter,
+		jsonMode:    supportsJSONMode(model),
+		toolCalling: supportsToolCalling(model),
 	}
 }
 
+// ForceJSON enables JSON mode even if the model isn't known to support it
+func (p *OpenAIProvider) ForceJSON() {
+	p.jsonMode = true
+}
+
+// SetSystemPrompt sends prompt as the system message of every request
+func (p *OpenAIProvider) SetSystemPrompt(prompt string) {
+	p.systemPrompt = prompt
+}
+
+// SetSampling sends the sampling parameters with every request, unless the model only accepts its defaults
+func (p *OpenAIProvider) SetSampling(sampling Sampling) {
+	p.sampling = sampling
+}
+
 func (p *OpenAIProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
 	err := p.rateLimiter.Wait(ctx) // Wait for rate limit
 	if err != nil {
 		return "", fmt.Errorf("rate limit error: %w", err)
 	}
 
+	request := openai.ChatCompletionRequest{
+		Model:    p.model,
+		Messages: chatMessages(p.systemPrompt, message),
+	}
+	if supportsSampling(p.model) {
+		request.Temperature = openAITemperature(p.sampling.Temperature)
+		request.TopP = float32(p.sampling.TopP)
+	}
+	if p.toolCalling {
+		request.Tools = classifyTools
+		request.ToolChoice = classifyToolChoice
+	} else {
+		request.ResponseFormat = responseFormat(p.jsonMode)
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", classifyError(err))
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("%w: OpenAI API returned no choices", ErrInvalid)
+	}
+
+	return toolCallResponse(resp.Choices[0].Message), nil
+}
+
+// mistralBaseURL is the OpenAI-compatible endpoint of the Mistral API
+const mistralBaseURL = "https://api.mistral.ai/v1"
+
+type MistralProvider struct {
+	client       *openai.Client
+	model        string
+	rateLimiter  *rate.Limiter
+	jsonMode     bool
+	systemPrompt string
+	sampling     Sampling
+}
+
+func NewMistralProvider(apiKey, model string, rateLimit float64) *MistralProvider {
+	var limiter *rate.Limiter
+	if rateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(rateLimit), 1)
+	} else {
+		limiter = rate.NewLimiter(rate.Inf, 0) // No rate limit
+	}
+	return &MistralProvider{
+		client:      openai.NewClientWithConfig(openAIConfig(apiKey, mistralBaseURL)),
+		model:       model,
+		rateLimiter: limiter,
+		jsonMode:    true, // Every Mistral chat model supports JSON mode
+	}
+}
+
+// ForceJSON enables JSON mode, which Mistral models support already
+func (p *MistralProvider) ForceJSON() {
+	p.jsonMode = true
+}
+
+// SetSystemPrompt sends prompt as the system message of every request
+func (p *MistralProvider) SetSystemPrompt(prompt string) {
+	p.systemPrompt = prompt
+}
+
+// SetSampling sends the sampling parameters with every request
+func (p *MistralProvider) SetSampling(sampling Sampling) {
+	p.sampling = sampling
+}
+
+func (p *MistralProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	err := p.rateLimiter.Wait(ctx) // Wait for rate limit
+	if err != nil {
+		return "", fmt.Errorf("rate limit error: %w", err)
+	}
+
 	resp, err := p.client.CreateChatCompletion(
 		ctx,
 		openai.ChatCompletionRequest{
-			Model: p.model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: message,
-				},
-			},
-			Temperature: 0,
+			Model:          p.model,
+			Messages:       chatMessages(p.systemPrompt, message),
+			Temperature:    openAITemperature(p.sampling.Temperature),
+			TopP:           float32(p.sampling.TopP),
+			ResponseFormat: responseFormat(p.jsonMode),
 		},
 	)
-
 	if err != nil {
-		return "", fmt.Errorf("OpenAI API error: %w", err)
+		return "", fmt.Errorf("mistral API error: %w", classifyError(err))
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("%w: mistral API returned no choices", ErrInvalid)
 	}
 
 	return resp.Choices[0].Message.Content, nil
 }
 
 type AnthropicProvider struct {
-	client      *http.Client
-	apiKey      string
-	model       string
-	rateLimiter *rate.Limiter
+	client       *http.Client
+	apiKey       string
+	model        string
+	rateLimiter  *rate.Limiter
+	systemPrompt string
+	sampling     Sampling
 }
 
 func NewAnthropicProvider(apiKey, model string, rateLimit float64) *AnthropicProvider {
@@ -98,7 +283,7 @@ func NewAnthropicProvider(apiKey, model string, rateLimit float64) *AnthropicPro
 		limiter = rate.NewLimiter(rate.Inf, 0) // No rate limit
 	}
 	return &AnthropicProvider{
-		client:      &http.Client{Timeout: 30 * time.Second},
+		client:      httpclient.New(30 * time.Second),
 		apiKey:      apiKey,
 		model:       model,
 		rateLimiter: limiter,
@@ -111,9 +296,22 @@ type AnthropicMessage struct {
 }
 
 type AnthropicRequest struct {
-	Model     string             `json:"model"`
-	Messages  []AnthropicMessage `json:"messages"`
-	MaxTokens int                `json:"max_tokens"`
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	TopP        *float64           `json:"top_p,omitempty"`
+}
+
+// SetSystemPrompt sends prompt as the system prompt of every request
+func (p *AnthropicProvider) SetSystemPrompt(prompt string) {
+	p.systemPrompt = prompt
+}
+
+// SetSampling sends the sampling parameters with every request, top_p instead of the temperature if set
+func (p *AnthropicProvider) SetSampling(sampling Sampling) {
+	p.sampling = sampling
 }
 
 type AnthropicResponse struct {
@@ -122,19 +320,32 @@ type AnthropicResponse struct {
 	} `json:"content"`
 }
 
+// request builds the Messages API request for a message. Newer models reject a temperature
+// together with a top_p, so a configured top_p replaces the temperature.
+func (p *AnthropicProvider) request(message string) AnthropicRequest {
+	request := AnthropicRequest{
+		Model:  p.model,
+		System: p.systemPrompt,
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: message},
+		},
+		MaxTokens: 1000,
+	}
+	if p.sampling.TopP > 0 {
+		request.TopP = &p.sampling.TopP
+	} else {
+		request.Temperature = &p.sampling.Temperature
+	}
+	return request
+}
+
 func (p *AnthropicProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
 	err := p.rateLimiter.Wait(ctx) // Wait for rate limit
 	if err != nil {
 		return "", fmt.Errorf("rate limit error: %w", err)
 	}
 
-	requestBody, err := json.Marshal(AnthropicRequest{
-		Model: p.model,
-		Messages: []AnthropicMessage{
-			{Role: "user", Content: message},
-		},
-		MaxTokens: 1000,
-	})
+	requestBody, err := json.Marshal(p.request(message))
 	if err != nil {
 		return "", fmt.Errorf("error marshaling request: %w", err)
 	}
@@ -150,13 +361,17 @@ func (p *AnthropicProvider) ProcessMessage(ctx context.Context, message string)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("error sending request: %w", err)
+		return "", fmt.Errorf("error sending request: %w", classifyError(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		if typed := statusError(resp.StatusCode); typed != nil {
+			err = fmt.Errorf("%w: %w", typed, err)
+		}
+		return "", err
 	}
 
 	var anthropicResp AnthropicResponse
@@ -172,12 +387,93 @@ func (p *AnthropicProvider) ProcessMessage(ctx context.Context, message string)
 }
 
 func ProcessRecord(message string, prompt string, provider Provider) (Result, error) {
-	prompt = strings.ReplaceAll(prompt, "{{CHANNEL_CONTENT}}", message)
-
-	response, err := provider.ProcessMessage(context.Background(), prompt)
+	response, err := provider.ProcessMessage(context.Background(), RenderPrompt(message, prompt))
 	if err != nil {
 		return Result{}, fmt.Errorf("API error: %w", err)
 	}
+	return ParseResponse(response)
+}
+
+// RenderPrompt substitutes the message into the prompt template
+func RenderPrompt(message string, prompt string) string {
+	return strings.ReplaceAll(prompt, MessagePlaceholder, message)
+}
+
+// InjectExamples adds known spam examples to the prompt template, either at
+// ExamplesPlaceholder or, when the prompt has none, in front of the prompt
+func InjectExamples(prompt string, examples []string) string {
+	if len(examples) == 0 {
+		return strings.ReplaceAll(prompt, ExamplesPlaceholder, "")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Here are examples of spam recently seen in this chat:\n<spam_examples>\n")
+	for _, example := range examples {
+		sb.WriteString("<example>\n")
+		sb.WriteString(exampleTagRegex.ReplaceAllString(example, ""))
+		sb.WriteString("\n</example>\n")
+	}
+	sb.WriteString("</spam_examples>")
+	return injectBlock(prompt, ExamplesPlaceholder, sb.String())
+}
+
+// InjectContext adds notes about the sender (e.g. "recently created account") to the prompt
+// template, either at ContextPlaceholder or, when the prompt has none, in front of the prompt
+func InjectContext(prompt string, notes []string) string {
+	if len(notes) == 0 {
+		return strings.ReplaceAll(prompt, ContextPlaceholder, "")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Context about the sender of the message:\n<sender_context>\n")
+	for _, note := range notes {
+		sb.WriteString("- ")
+		sb.WriteString(note)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</sender_context>")
+	return injectBlock(prompt, ContextPlaceholder, sb.String())
+}
+
+// InjectChatContext adds the preceding chat messages to the prompt template, either
+// at ChatPlaceholder or, when the prompt has none, in front of the prompt
+func InjectChatContext(prompt string, messages []string) string {
+	if len(messages) == 0 {
+		return strings.ReplaceAll(prompt, ChatPlaceholder, "")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("The messages preceding it in the chat, oldest first, for context only:\n<chat_context>\n")
+	for _, message := range messages {
+		sb.WriteString(message)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</chat_context>")
+	return injectBlock(prompt, ChatPlaceholder, sb.String())
+}
+
+func injectBlock(prompt, placeholder, block string) string {
+	if strings.Contains(prompt, placeholder) {
+		return strings.ReplaceAll(prompt, placeholder, block)
+	}
+	return block + "\n\n" + prompt
+}
+
+// ParseResponse extracts the reasoning and classification from a raw provider response.
+// Failures wrap ErrParse.
+func ParseResponse(response string) (Result, error) {
+	result, err := parseResponse(response)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %w", ErrParse, err)
+	}
+	return result, nil
+}
+
+func parseResponse(response string) (Result, error) {
+	// Responses in JSON mode are a bare object with the reasoning as a field
+	if trimmed := strings.TrimSpace(response); strings.HasPrefix(trimmed, "{") {
+		return parseJSONResponse(trimmed)
+	}
 
 	// Extract reasoning
 	reasoningMatch := reasoningRegex.FindStringSubmatch(response)
@@ -194,7 +490,7 @@ func ProcessRecord(message string, prompt string, provider Provider) (Result, er
 
 	var classification SpamClassification
 	if len(jsonMatch) > 1 {
-		err = json.Unmarshal([]byte(jsonMatch[1]), &classification)
+		err := json.Unmarshal([]byte(jsonMatch[1]), &classification)
 		if err != nil {
 			return Result{}, fmt.Errorf("failed to parse JSON classification: %w", err)
 		}
@@ -202,12 +498,88 @@ func ProcessRecord(message string, prompt string, provider Provider) (Result, er
 		return Result{}, fmt.Errorf("could not extract JSON classification from response")
 	}
 
+	labels, err := parseLabels(jsonMatch[1])
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse JSON labels: %w", err)
+	}
+
 	return Result{
 		Reasoning: reasoning,
 		SpamScore: classification.SpamScore,
+		Labels:    labels,
+	}, nil
+}
+
+// parseJSONResponse parses a JSON mode response, e.g. {"reasoning": "...", "spam_score": 0.9}
+func parseJSONResponse(response string) (Result, error) {
+	var classification struct {
+		Reasoning string   `json:"reasoning"`
+		SpamScore *float64 `json:"spam_score"`
+	}
+	if err := json.Unmarshal([]byte(response), &classification); err != nil {
+		return Result{}, fmt.Errorf("failed to parse JSON classification: %w", err)
+	}
+	if classification.SpamScore == nil {
+		return Result{}, fmt.Errorf("could not extract spam_score from JSON response")
+	}
+
+	labels, err := parseLabels(response)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse JSON labels: %w", err)
+	}
+
+	return Result{
+		Reasoning: classification.Reasoning,
+		SpamScore: *classification.SpamScore,
+		Labels:    labels,
 	}, nil
 }
 
+// parseLabels extracts every numeric field other than spam_score from the JSON classification
+func parseLabels(data string) (map[string]float64, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, err
+	}
+
+	var labels map[string]float64
+	for name, raw := range fields {
+		if name == "spam_score" {
+			continue
+		}
+		var score float64
+		if err := json.Unmarshal(raw, &score); err != nil {
+			continue // Not a score, e.g. a free-form comment
+		}
+		if labels == nil {
+			labels = make(map[string]float64)
+		}
+		labels[strings.TrimSuffix(name, "_score")] = score
+	}
+	return labels, nil
+}
+
+// geminiOptions returns the Gemini client options, sending the configured extra headers as gRPC metadata.
+// The gRPC transport reads the proxy from HTTPS_PROXY.
+func geminiOptions(apiKey string) []option.ClientOption {
+	options := []option.ClientOption{option.WithAPIKey(apiKey)}
+	headers := httpclient.Headers()
+	if len(headers) == 0 {
+		return options
+	}
+
+	var pairs []string
+	for name, values := range headers {
+		for _, value := range values {
+			pairs = append(pairs, strings.ToLower(name), value)
+		}
+	}
+	interceptor := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, opts...)
+	}
+	return append(options, option.WithGRPCDialOption(grpc.WithUnaryInterceptor(interceptor)))
+}
+
 type GeminiProvider struct {
 	client      *genai.Client
 	model       *genai.GenerativeModel
@@ -223,7 +595,7 @@ func NewGeminiProvider(apiKey, model string, rateLimit float64) (*GeminiProvider
 		limiter = rate.NewLimiter(rate.Inf, 0) // No rate limit
 	}
 
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	client, err := genai.NewClient(ctx, geminiOptions(apiKey)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
@@ -294,11 +666,28 @@ func NewGeminiProvider(apiKey, model string, rateLimit float64) (*GeminiProvider
 	}, nil
 }
 
+// SetSystemPrompt sends prompt as the system instruction of every request
+func (p *GeminiProvider) SetSystemPrompt(prompt string) {
+	p.model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(prompt)}}
+}
+
+// SetSampling sends the sampling parameters with every request, keeping the default top_p of 0.95 if TopP is 0
+func (p *GeminiProvider) SetSampling(sampling Sampling) {
+	p.model.SetTemperature(float32(sampling.Temperature))
+	if sampling.TopP > 0 {
+		p.model.SetTopP(float32(sampling.TopP))
+	}
+}
+
 func (p *GeminiProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
 	session := p.model.StartChat()
 	resp, err := session.SendMessage(ctx, genai.Text(message))
 	if err != nil {
-		return "", fmt.Errorf("error sending message: %w", err)
+		return "", fmt.Errorf("error sending message: %w", classifyError(err))
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", fmt.Errorf("empty response from Gemini API")
 	}
 
 	response := ""
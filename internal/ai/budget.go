@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by providers shedding calls beyond their request budget
+var ErrBudgetExceeded = errors.New("request budget exceeded")
+
+// BudgetUsage is the share of the current minute's and UTC day's budget already spent,
+// 0 for a window without a ceiling, and how many calls were shed so far
+type BudgetUsage struct {
+	Minute float64
+	Day    float64
+	Shed   uint64
+}
+
+// BudgetedProvider enforces per-minute and per-day request ceilings for API tiers
+// limited by request count rather than cost. Calls beyond the per-minute ceiling
+// either wait for the next minute or are shed; calls beyond the per-day ceiling are
+// always shed, as waiting for the next UTC day would hold up the bot for hours.
+// A ceiling of 0 means no limit.
+type BudgetedProvider struct {
+	provider  Provider
+	perMinute int
+	perDay    int
+	shed      bool
+	now       func() time.Time
+
+	mutex     sync.Mutex
+	minute    time.Time
+	day       time.Time
+	minuteUse int
+	dayUse    int
+	shedCount uint64
+}
+
+func NewBudgetedProvider(provider Provider, perMinute, perDay int, shed bool) *BudgetedProvider {
+	return &BudgetedProvider{
+		provider:  provider,
+		perMinute: perMinute,
+		perDay:    perDay,
+		shed:      shed,
+		now:       time.Now,
+	}
+}
+
+func (p *BudgetedProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	for {
+		wait, shed := p.reserve(ctx)
+		if shed {
+			return "", fmt.Errorf("%w: %w", ErrRateLimited, ErrBudgetExceeded)
+		}
+		if wait == 0 {
+			return p.provider.ProcessMessage(ctx, message)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", fmt.Errorf("request budget error: %w", ctx.Err())
+		}
+	}
+}
+
+// reserve counts a call against the budget, returning 0 if it may proceed, how long
+// to wait for the next minute, or whether the call is shed. Waits that would outlast
+// the context's deadline are shed right away.
+func (p *BudgetedProvider) reserve(ctx context.Context) (time.Duration, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := p.now()
+	p.roll(now)
+	switch {
+	case p.perDay > 0 && p.dayUse >= p.perDay:
+		p.shedCount++
+		return 0, true
+	case p.perMinute > 0 && p.minuteUse >= p.perMinute:
+		wait := p.minute.Add(time.Minute).Sub(now)
+		if deadline, ok := ctx.Deadline(); p.shed || ok && time.Until(deadline) < wait {
+			p.shedCount++
+			return 0, true
+		}
+		return wait, false
+	}
+	p.minuteUse++
+	p.dayUse++
+	return 0, false
+}
+
+// roll resets the counters of windows that ended before now
+func (p *BudgetedProvider) roll(now time.Time) {
+	if minute := now.Truncate(time.Minute); !minute.Equal(p.minute) {
+		p.minute, p.minuteUse = minute, 0
+	}
+	utc := now.UTC()
+	if day := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC); !day.Equal(p.day) {
+		p.day, p.dayUse = day, 0
+	}
+}
+
+// Usage returns how much of the current windows' budget is spent
+func (p *BudgetedProvider) Usage() BudgetUsage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.roll(p.now())
+	usage := BudgetUsage{Shed: p.shedCount}
+	if p.perMinute > 0 {
+		usage.Minute = float64(p.minuteUse) / float64(p.perMinute)
+	}
+	if p.perDay > 0 {
+		usage.Day = float64(p.dayUse) / float64(p.perDay)
+	}
+	return usage
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// callCounter answers every message and counts the calls that got through
+type callCounter struct {
+	calls atomic.Int32
+}
+
+func (p *callCounter) ProcessMessage(ctx context.Context, message string) (string, error) {
+	p.calls.Add(1)
+	return `{"reasoning": "", "spam_score": 0}`, nil
+}
+
+// budgetAt returns a budgeted provider whose clock starts at start and then runs in real time
+func budgetAt(start time.Time, perMinute, perDay int, shed bool) (*BudgetedProvider, *callCounter) {
+	inner := &callCounter{}
+	provider := NewBudgetedProvider(inner, perMinute, perDay, shed)
+	began := time.Now()
+	provider.now = func() time.Time { return start.Add(time.Since(began)) }
+	return provider, inner
+}
+
+// isShed reports whether err is a call shed for exceeding the budget
+func isShed(err error) bool {
+	return errors.Is(err, ErrBudgetExceeded) && errors.Is(err, ErrRateLimited) && !IsRetryable(err)
+}
+
+func TestBudgetShedsBeyondMinuteCeiling(t *testing.T) {
+	provider, inner := budgetAt(time.Date(2026, 10, 14, 12, 0, 10, 0, time.UTC), 2, 0, true)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.ProcessMessage(ctx, "hi"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	if _, err := provider.ProcessMessage(ctx, "hi"); !isShed(err) {
+		t.Errorf("third call error = %v, want it shed", err)
+	}
+
+	if inner.calls.Load() != 2 {
+		t.Errorf("provider calls = %d, want 2", inner.calls.Load())
+	}
+	if usage := provider.Usage(); usage.Minute != 1 || usage.Day != 0 || usage.Shed != 1 {
+		t.Errorf("usage = %+v, want the minute used up and one call shed", usage)
+	}
+}
+
+func TestBudgetQueuesForNextMinute(t *testing.T) {
+	provider, inner := budgetAt(time.Date(2026, 10, 14, 12, 0, 59, int(950*time.Millisecond), time.UTC), 1, 0, false)
+	ctx := context.Background()
+
+	if _, err := provider.ProcessMessage(ctx, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := provider.ProcessMessage(ctx, "hi"); err != nil {
+		t.Fatalf("queued call: %v", err)
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Errorf("queued call went through after %s, want it to wait for the next minute", waited)
+	}
+	if inner.calls.Load() != 2 {
+		t.Errorf("provider calls = %d, want 2", inner.calls.Load())
+	}
+}
+
+func TestBudgetShedsWaitsPastDeadline(t *testing.T) {
+	provider, inner := budgetAt(time.Date(2026, 10, 14, 12, 0, 10, 0, time.UTC), 1, 0, false)
+	if _, err := provider.ProcessMessage(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := provider.ProcessMessage(ctx, "hi"); !isShed(err) {
+		t.Errorf("error = %v, want a wait of 50s past the deadline shed", err)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("shedding took %s, want it right away", waited)
+	}
+	if inner.calls.Load() != 1 {
+		t.Errorf("provider calls = %d, want 1", inner.calls.Load())
+	}
+}
+
+func TestBudgetShedsUsedUpDayWhenQueueing(t *testing.T) {
+	day := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	provider, inner := budgetAt(day, 0, 1, false)
+	if _, err := provider.ProcessMessage(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a deadline, queueing would sleep until midnight
+	if _, err := provider.ProcessMessage(context.Background(), "hi"); !isShed(err) {
+		t.Errorf("error = %v, want the call beyond the day's ceiling shed", err)
+	}
+	if usage := provider.Usage(); usage.Day != 1 || usage.Shed != 1 {
+		t.Errorf("usage = %+v, want the day used up and one call shed", usage)
+	}
+
+	provider.now = func() time.Time { return day.Add(15 * time.Hour) }
+	if _, err := provider.ProcessMessage(context.Background(), "hi"); err != nil {
+		t.Errorf("call on the next UTC day: %v", err)
+	}
+	if inner.calls.Load() != 2 {
+		t.Errorf("provider calls = %d, want 2", inner.calls.Load())
+	}
+}
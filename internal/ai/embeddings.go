@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Embedder computes vector embeddings of message texts
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// OpenAIEmbedder computes embeddings with an OpenAI-compatible embeddings endpoint
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAIEmbedder(apiKey, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		client: openai.NewClientWithConfig(openAIConfig(apiKey, "")),
+		model:  model,
+	}
+}
+
+func NewMistralEmbedder(apiKey, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		client: openai.NewClientWithConfig(openAIConfig(apiKey, mistralBaseURL)),
+		model:  model,
+	}
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{text},
+		Model: openai.EmbeddingModel(e.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embeddings API error: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("embeddings API returned no data")
+	}
+	return resp.Data[0].Embedding, nil
+}
+
+// CosineSimilarity returns the cosine similarity of two embeddings, or zero if
+// their dimensions differ or either is empty
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
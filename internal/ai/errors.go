@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Provider failure causes, wrapped together with the underlying error so
+// callers can decide whether to retry or fall back
+var (
+	ErrRateLimited = errors.New("rate limited")
+	ErrAuth        = errors.New("authentication failed")
+	ErrServer      = errors.New("server error")
+	ErrParse       = errors.New("unparseable response")
+	ErrTimeout     = errors.New("timeout")
+	ErrInvalid     = errors.New("invalid request")
+)
+
+// IsRetryable reports whether a failed call may succeed when retried. Failures
+// with an unknown cause are assumed to be transient, while a spent request budget
+// stays spent until its window rolls over.
+func IsRetryable(err error) bool {
+	return !errors.Is(err, ErrAuth) && !errors.Is(err, ErrInvalid) && !errors.Is(err, ErrBudgetExceeded) && !errors.Is(err, context.Canceled)
+}
+
+// statusError returns the typed error for an HTTP status code, or nil if the status has none
+func statusError(statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrAuth
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case statusCode >= 500:
+		return ErrServer
+	case statusCode >= 400:
+		return ErrInvalid
+	default:
+		return nil
+	}
+}
+
+// classifyError wraps a provider error with its typed cause, if it can be determined
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if typed := errorCause(err); typed != nil {
+		return fmt.Errorf("%w: %w", typed, err)
+	}
+	return err
+}
+
+func errorCause(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrTimeout
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return statusError(apiErr.HTTPStatusCode)
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return statusError(requestErr.HTTPStatusCode)
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.ResourceExhausted:
+			return ErrRateLimited
+		case codes.Unauthenticated, codes.PermissionDenied:
+			return ErrAuth
+		case codes.Unavailable, codes.Internal:
+			return ErrServer
+		case codes.DeadlineExceeded:
+			return ErrTimeout
+		case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition:
+			return ErrInvalid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusOK, nil},
+		{http.StatusBadRequest, ErrInvalid},
+		{http.StatusNotFound, ErrInvalid},
+		{http.StatusUnauthorized, ErrAuth},
+		{http.StatusForbidden, ErrAuth},
+		{http.StatusRequestTimeout, ErrTimeout},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusBadGateway, ErrServer},
+		{http.StatusGatewayTimeout, ErrTimeout},
+		{529, ErrServer}, // Anthropic's overloaded status
+	}
+	for _, tt := range tests {
+		if got := statusError(tt.status); got != tt.want {
+			t.Errorf("statusError(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"OpenAI rate limit", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Message: "slow down"}, ErrRateLimited},
+		{"OpenAI bad key", &openai.APIError{HTTPStatusCode: http.StatusUnauthorized, Message: "invalid key"}, ErrAuth},
+		{"OpenAI request error", &openai.RequestError{HTTPStatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")}, ErrServer},
+		{"deadline", fmt.Errorf("calling: %w", context.DeadlineExceeded), ErrTimeout},
+		{"gRPC quota", status.Error(codes.ResourceExhausted, "quota"), ErrRateLimited},
+		{"gRPC unavailable", status.Error(codes.Unavailable, "down"), ErrServer},
+		{"gRPC bad argument", status.Error(codes.InvalidArgument, "bad"), ErrInvalid},
+		{"gRPC permission", status.Error(codes.PermissionDenied, "no"), ErrAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("classifyError() = %v, want it to wrap %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyError() = %v, lost the original error", got)
+			}
+		})
+	}
+
+	unknown := errors.New("something odd")
+	if got := classifyError(unknown); got != unknown {
+		t.Errorf("classifyError() = %v, want an unknown error unchanged", got)
+	}
+	if classifyError(nil) != nil {
+		t.Error("classifyError(nil) != nil")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", fmt.Errorf("%w: 429", ErrRateLimited), true},
+		{"server", fmt.Errorf("%w: 500", ErrServer), true},
+		{"timeout", fmt.Errorf("%w: slow", ErrTimeout), true},
+		{"parse", fmt.Errorf("%w: garbage", ErrParse), true},
+		{"unknown", errors.New("connection reset"), true},
+		{"auth", fmt.Errorf("%w: 401", ErrAuth), false},
+		{"invalid", fmt.Errorf("%w: 400", ErrInvalid), false},
+		{"budget", fmt.Errorf("%w: %w", ErrRateLimited, ErrBudgetExceeded), false},
+		{"canceled", fmt.Errorf("request: %w", context.Canceled), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// inFlightCounter holds every call until release is closed and records the most calls in flight at once
+type inFlightCounter struct {
+	started  chan struct{} // Receives a value when a call starts
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (p *inFlightCounter) ProcessMessage(ctx context.Context, message string) (string, error) {
+	current := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		seen := p.peak.Load()
+		if current <= seen || p.peak.CompareAndSwap(seen, current) {
+			break
+		}
+	}
+	p.started <- struct{}{}
+	<-p.release
+	return `{"reasoning": "", "spam_score": 0}`, nil
+}
+
+func TestLimitedProviderBoundsConcurrency(t *testing.T) {
+	const concurrency, calls = 3, 20
+	inner := &inFlightCounter{started: make(chan struct{}, calls), release: make(chan struct{})}
+	provider := NewLimitedProvider(inner, concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := provider.ProcessMessage(context.Background(), "message"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for i := 0; i < concurrency; i++ {
+		<-inner.started
+	}
+	if inFlight := inner.inFlight.Load(); inFlight != concurrency {
+		t.Errorf("%d calls in flight, want the limit of %d", inFlight, concurrency)
+	}
+	close(inner.release)
+	wg.Wait()
+
+	if peak := inner.peak.Load(); peak != concurrency {
+		t.Errorf("at most %d calls were in flight, want %d", peak, concurrency)
+	}
+	if len(inner.started) != calls-concurrency {
+		t.Errorf("%d calls got through after the release, want the remaining %d", len(inner.started), calls-concurrency)
+	}
+}
+
+func TestLimitedProviderGivesUpOnCancel(t *testing.T) {
+	inner := &inFlightCounter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	provider := NewLimitedProvider(inner, 1)
+	go provider.ProcessMessage(context.Background(), "message")
+	<-inner.started
+	defer close(inner.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.ProcessMessage(ctx, "message"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ProcessMessage() error = %v, want the cancellation while waiting for a slot", err)
+	}
+	if peak := inner.peak.Load(); peak != 1 {
+		t.Errorf("at most %d calls were in flight, want 1", peak)
+	}
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// placeholderRegex matches anything that looks like a template placeholder
+var placeholderRegex = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// knownPlaceholders are the placeholders the bot fills in when rendering a prompt
+var knownPlaceholders = map[string]bool{
+	MessagePlaceholder:  true,
+	ExamplesPlaceholder: true,
+	ContextPlaceholder:  true,
+	ChatPlaceholder:     true,
+}
+
+// LintPrompt returns the problems found in a prompt without calling a provider:
+// placeholders, rendering and whether it asks for a response the bot can parse
+func LintPrompt(prompt string, forceJSON bool) []string {
+	var problems []string
+	if !strings.Contains(prompt, MessagePlaceholder) {
+		problems = append(problems, fmt.Sprintf("missing the message placeholder %s", MessagePlaceholder))
+	}
+	for _, placeholder := range placeholderRegex.FindAllString(prompt, -1) {
+		if !knownPlaceholders[placeholder] {
+			problems = append(problems, fmt.Sprintf("unknown placeholder %s", placeholder))
+		}
+	}
+
+	// Render the way classify does, with the chat-specific blocks empty
+	rendered := InjectExamples(prompt, nil)
+	rendered = InjectChatContext(rendered, nil)
+	rendered = InjectContext(rendered, nil)
+	rendered = RenderPrompt("message", rendered)
+	if strings.TrimSpace(rendered) == "" {
+		problems = append(problems, "renders to an empty prompt")
+	}
+
+	if !strings.Contains(prompt, "spam_score") {
+		problems = append(problems, "does not ask for a spam_score")
+	}
+	// JSON mode and tool calling make the model answer with a bare object, otherwise
+	// the response is parsed from <reasoning> and <json> tags
+	if !forceJSON {
+		for _, tag := range []string{"<reasoning>", "<json>"} {
+			if !strings.Contains(prompt, tag) {
+				problems = append(problems, fmt.Sprintf("does not mention the %s tag the response is parsed from (or use -force-json)", tag))
+			}
+		}
+	}
+	return problems
+}
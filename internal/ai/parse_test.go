@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     Result
+	}{
+		{
+			name:     "tagged",
+			response: "<reasoning>Crypto giveaway</reasoning>\n<json>{\"spam_score\": 0.9}</json>",
+			want:     Result{Reasoning: "Crypto giveaway", SpamScore: 0.9},
+		},
+		{
+			name:     "tagged with labels",
+			response: "<reasoning>Fake exchange</reasoning><json>{\"spam_score\": 0.4, \"scam_score\": 0.95, \"nsfw\": 0.1, \"note\": \"text\"}</json>",
+			want:     Result{Reasoning: "Fake exchange", SpamScore: 0.4, Labels: map[string]float64{"scam": 0.95, "nsfw": 0.1}},
+		},
+		{
+			name:     "JSON mode",
+			response: `  {"reasoning": "Greeting", "spam_score": 0.05}`,
+			want:     Result{Reasoning: "Greeting", SpamScore: 0.05},
+		},
+		{
+			name:     "JSON mode with labels",
+			response: `{"reasoning": "Off-topic ad", "spam_score": 0.3, "offtopic_score": 0.8, "flags": ["ad"]}`,
+			want:     Result{Reasoning: "Off-topic ad", SpamScore: 0.3, Labels: map[string]float64{"offtopic": 0.8}},
+		},
+		{
+			name:     "zero score in JSON mode",
+			response: `{"reasoning": "Fine", "spam_score": 0}`,
+			want:     Result{Reasoning: "Fine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResponse(tt.response)
+			if err != nil {
+				t.Fatalf("ParseResponse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseResponse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResponseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"empty", ""},
+		{"no reasoning", `<json>{"spam_score": 0.9}</json>`},
+		{"no JSON", "<reasoning>Spam</reasoning>"},
+		{"malformed JSON", "<reasoning>Spam</reasoning><json>{spam_score: 0.9}</json>"},
+		{"JSON mode without a score", `{"reasoning": "Spam"}`},
+		{"truncated JSON mode", `{"reasoning": "Spam", "spam_sc`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseResponse(tt.response); !errors.Is(err, ErrParse) {
+				t.Errorf("ParseResponse() error = %v, want ErrParse", err)
+			}
+		})
+	}
+}
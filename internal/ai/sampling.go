@@ -0,0 +1,36 @@
+package ai
+
+import (
+	"math"
+	"strings"
+)
+
+// Sampling holds the sampling parameters sent with classification requests. A low
+// temperature keeps scores stable between calls; a TopP of 0 leaves the provider's default.
+type Sampling struct {
+	Temperature float64
+	TopP        float64
+}
+
+// fixedSamplingModels lists OpenAI model prefixes rejecting sampling parameters other than the defaults
+var fixedSamplingModels = []string{"gpt-5", "o1", "o3", "o4"}
+
+// supportsSampling reports whether an OpenAI model accepts a temperature and top_p
+func supportsSampling(model string) bool {
+	for _, prefix := range fixedSamplingModels {
+		if strings.HasPrefix(model, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// openAITemperature returns the temperature of an OpenAI-compatible request. The
+// client leaves out zero values, so a temperature of 0 is sent as the smallest
+// positive one rather than falling back to the provider's default of 1.
+func openAITemperature(temperature float64) float32 {
+	if temperature <= 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return float32(temperature)
+}
@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/time/rate"
+)
+
+// openAITestProvider returns an OpenAI provider for model sending its requests to server
+func openAITestProvider(server *httptest.Server, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		client:      openai.NewClientWithConfig(openAIConfig("test", server.URL)),
+		model:       model,
+		rateLimiter: rate.NewLimiter(rate.Inf, 0),
+		jsonMode:    supportsJSONMode(model),
+		toolCalling: supportsToolCalling(model),
+	}
+}
+
+// chatServer answers chat completions with response, keeping the decoded body of the last request
+func chatServer(t *testing.T, response string, request *map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request: %v", err)
+		}
+		if request != nil {
+			if err := json.Unmarshal(body, request); err != nil {
+				t.Errorf("decoding request: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+const textCompletion = `{"choices": [{"message": {"role": "assistant", "content": "{\"reasoning\": \"ok\", \"spam_score\": 0.1}"}}]}`
+
+func TestOpenAISendsSampling(t *testing.T) {
+	var request map[string]any
+	provider := openAITestProvider(chatServer(t, textCompletion, &request), "gpt-4o")
+	provider.SetSampling(Sampling{Temperature: 0.3, TopP: 0.9})
+
+	if _, err := provider.ProcessMessage(context.Background(), "hello"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if got, _ := request["temperature"].(float64); float32(got) != 0.3 {
+		t.Errorf("temperature = %v, want 0.3", request["temperature"])
+	}
+	if got, _ := request["top_p"].(float64); float32(got) != 0.9 {
+		t.Errorf("top_p = %v, want 0.9", request["top_p"])
+	}
+}
+
+func TestOpenAISendsZeroTemperature(t *testing.T) {
+	var request map[string]any
+	provider := openAITestProvider(chatServer(t, textCompletion, &request), "gpt-4o")
+	provider.SetSampling(Sampling{})
+
+	if _, err := provider.ProcessMessage(context.Background(), "hello"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if _, ok := request["temperature"]; !ok {
+		t.Error("temperature of 0 was left out, the provider would apply its default")
+	}
+	if _, ok := request["top_p"]; ok {
+		t.Errorf("top_p = %v, want it left out", request["top_p"])
+	}
+}
+
+func TestOpenAIReasoningModelSkipsSampling(t *testing.T) {
+	var request map[string]any
+	provider := openAITestProvider(chatServer(t, textCompletion, &request), "o3-mini")
+	provider.SetSampling(Sampling{Temperature: 0.3, TopP: 0.9})
+
+	if _, err := provider.ProcessMessage(context.Background(), "hello"); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	for _, field := range []string{"temperature", "top_p"} {
+		if _, ok := request[field]; ok {
+			t.Errorf("%s = %v, want it left out for a reasoning model", field, request[field])
+		}
+	}
+}
+
+func TestAnthropicRequestSampling(t *testing.T) {
+	tests := []struct {
+		name            string
+		sampling        Sampling
+		wantTemperature *float64
+		wantTopP        *float64
+	}{
+		{"temperature only", Sampling{Temperature: 0.2}, ptr(0.2), nil},
+		{"zero temperature", Sampling{}, ptr(0), nil},
+		{"top_p replaces temperature", Sampling{Temperature: 0.2, TopP: 0.9}, nil, ptr(0.9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &AnthropicProvider{model: "claude-sonnet-4-5"}
+			provider.SetSampling(tt.sampling)
+			request := provider.request("hello")
+			if !equalPtr(request.Temperature, tt.wantTemperature) {
+				t.Errorf("temperature = %v, want %v", deref(request.Temperature), deref(tt.wantTemperature))
+			}
+			if !equalPtr(request.TopP, tt.wantTopP) {
+				t.Errorf("top_p = %v, want %v", deref(request.TopP), deref(tt.wantTopP))
+			}
+		})
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func equalPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StubProvider returns the same spam score for every message after a simulated
+// latency, for end-to-end and load tests of the pipeline without calling an API
+type StubProvider struct {
+	score   float64
+	latency time.Duration
+}
+
+func NewStubProvider(score float64, latency time.Duration) *StubProvider {
+	return &StubProvider{
+		score:   score,
+		latency: latency,
+	}
+}
+
+func (p *StubProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	if p.latency > 0 {
+		timer := time.NewTimer(p.latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", fmt.Errorf("stub provider error: %w", ctx.Err())
+		}
+	}
+	response, err := json.Marshal(struct {
+		Reasoning string  `json:"reasoning"`
+		SpamScore float64 `json:"spam_score"`
+	}{
+		Reasoning: "Constant score of the stub provider",
+		SpamScore: p.score,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding stub response: %w", err)
+	}
+	return string(response), nil
+}
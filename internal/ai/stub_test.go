@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStubProviderScore(t *testing.T) {
+	for _, score := range []float64{0, 0.42, 1} {
+		response, err := NewStubProvider(score, 0).ProcessMessage(context.Background(), "anything")
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := ParseResponse(response)
+		if err != nil {
+			t.Fatalf("stub response %q doesn't parse: %v", response, err)
+		}
+		if result.SpamScore != score || result.Reasoning == "" {
+			t.Errorf("stub result = %+v, want score %v with a reasoning", result, score)
+		}
+	}
+}
+
+func TestStubProviderLatency(t *testing.T) {
+	provider := NewStubProvider(0.5, 50*time.Millisecond)
+
+	start := time.Now()
+	if _, err := provider.ProcessMessage(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("answered after %s, want at least the 50ms latency", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	slow := NewStubProvider(0.5, time.Minute)
+	if _, err := slow.ProcessMessage(ctx, "hi"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want the context's deadline", err)
+	}
+}
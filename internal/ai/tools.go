@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// classifyToolName is the function OpenAI models call to return their classification
+const classifyToolName = "classify_spam"
+
+// toolCallingModels lists OpenAI model prefixes supporting forced function calls
+var toolCallingModels = []string{"gpt-4o", "gpt-4-turbo", "gpt-4.1", "gpt-3.5-turbo", "gpt-5", "o3", "o4"}
+
+// supportsToolCalling reports whether an OpenAI model can be forced to call the classification tool
+func supportsToolCalling(model string) bool {
+	for _, prefix := range toolCallingModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifySchema describes the tool arguments, which parse like a JSON mode response.
+// Labels requested by the prompt are passed as additional numeric properties.
+var classifySchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"reasoning": {"type": "string", "description": "Short explanation of the classification"},
+		"spam_score": {"type": "number", "minimum": 0, "maximum": 1, "description": "Probability that the message is spam"}
+	},
+	"required": ["reasoning", "spam_score"],
+	"additionalProperties": {"type": "number"}
+}`)
+
+var classifyTools = []openai.Tool{{
+	Type: openai.ToolTypeFunction,
+	Function: &openai.FunctionDefinition{
+		Name:        classifyToolName,
+		Description: "Report the spam classification of the message",
+		Parameters:  classifySchema,
+	},
+}}
+
+var classifyToolChoice = openai.ToolChoice{
+	Type:     openai.ToolTypeFunction,
+	Function: openai.ToolFunction{Name: classifyToolName},
+}
+
+// toolCallResponse returns the arguments of the classification tool call, falling
+// back to the message content if the model answered in text instead
+func toolCallResponse(message openai.ChatCompletionMessage) string {
+	for _, call := range message.ToolCalls {
+		if call.Function.Name == classifyToolName && call.Function.Arguments != "" {
+			return call.Function.Arguments
+		}
+	}
+	return message.Content
+}
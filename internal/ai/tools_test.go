@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const toolCallCompletion = `{"choices": [{"message": {"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function",
+	"function": {"name": "classify_spam", "arguments": "{\"reasoning\": \"crypto giveaway\", \"spam_score\": 0.95, \"scam\": 0.8}"}}]}}]}`
+
+func TestOpenAIToolCallClassification(t *testing.T) {
+	var request map[string]any
+	provider := openAITestProvider(chatServer(t, toolCallCompletion, &request), "gpt-4o")
+
+	response, err := provider.ProcessMessage(context.Background(), "free coins")
+	if err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if _, ok := request["tools"]; !ok {
+		t.Error("request has no tools")
+	}
+	if _, ok := request["response_format"]; ok {
+		t.Error("request sets a response format together with the tool")
+	}
+
+	result, err := ParseResponse(response)
+	if err != nil {
+		t.Fatalf("ParseResponse(%q): %v", response, err)
+	}
+	if result.SpamScore != 0.95 || result.Reasoning != "crypto giveaway" || result.Labels["scam"] != 0.8 {
+		t.Errorf("result = %+v, want spam score 0.95 and scam 0.8 with the reasoning", result)
+	}
+}
+
+func TestOpenAIToolCallFallsBackToText(t *testing.T) {
+	provider := openAITestProvider(chatServer(t, textCompletion, nil), "gpt-4o")
+
+	response, err := provider.ProcessMessage(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	result, err := ParseResponse(response)
+	if err != nil {
+		t.Fatalf("ParseResponse(%q): %v", response, err)
+	}
+	if result.SpamScore != 0.1 {
+		t.Errorf("spam score = %v, want 0.1", result.SpamScore)
+	}
+}
+
+func TestOpenAINoChoices(t *testing.T) {
+	provider := openAITestProvider(chatServer(t, `{"choices": []}`, nil), "gpt-4o")
+
+	_, err := provider.ProcessMessage(context.Background(), "hello")
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("error = %v, want ErrInvalid", err)
+	}
+	if IsRetryable(err) {
+		t.Error("an empty response is retried")
+	}
+}
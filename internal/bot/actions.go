@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/locale"
+)
+
+// Action is the enforcement applied to a flagged message
+type Action string
+
+const (
+	ActionNotify Action = "notify" // Only report to the log channel
+	ActionDelete Action = "delete" // Delete the message
+	ActionMute   Action = "mute"   // Delete the message and restrict the user for MuteDuration
+	ActionBan    Action = "ban"    // Delete the message and restrict the user
+)
+
+// severity orders actions so the strictest one wins when several apply
+func (a Action) severity() int {
+	switch a {
+	case ActionNotify:
+		return 1
+	case ActionDelete:
+		return 2
+	case ActionMute:
+		return 3
+	case ActionBan:
+		return 4
+	default:
+		return 0
+	}
+}
+
+func ParseAction(value string) (Action, error) {
+	action := Action(value)
+	if action.severity() == 0 {
+		return "", fmt.Errorf("unknown action: %s", value)
+	}
+	return action, nil
+}
+
+// enforce applies the action to a message flagged as label and reports it to the log channel
+func (b *Bot) enforce(message *tgbotapi.Message, channelID, userID int64, adminRights AdminRights, action Action, label string, score, threshold float64) {
+	ctx := context.Background()
+	warmup := action != ActionNotify && b.warmingUp(ctx, channelID)
+	stale := action.severity() >= ActionDelete.severity() && tooOldToDelete(message)
+	if stale {
+		action = b.staleFallback(action)
+	}
+	action, adminRights = b.effectiveEnforcement(ctx, channelID, action, adminRights)
+	b.recordDecision(ctx, message, userID, action, label, score, threshold)
+	_, span := tracer.Start(ctx, "enforce", trace.WithAttributes(
+		attribute.Int64("chat_id", channelID),
+		attribute.String("action", string(action)),
+		attribute.String("label", label),
+		attribute.Float64("spam_score", score),
+	))
+	defer span.End()
+	b.recordEvidence(ctx, message, userID, evidence{
+		MessageID: message.MessageID,
+		Text:      messageText(message),
+		Label:     label,
+		Score:     score,
+		Action:    action,
+		Time:      message.Time(),
+	})
+
+	notify, suppressed := b.notifications.allow(channelID, userID, time.Now(), action == ActionNotify)
+	if !notify {
+		b.logger.Debug("Suppressed admin notification", "userID", userID, "channelID", channelID, "label", label)
+	}
+
+	// Forward the message to the log channel
+	if logChannelID, exists := b.logChannel(channelID); exists && notify {
+		if err := b.messenger.Forward(logChannelID, channelID, message.MessageID); err != nil {
+			b.logger.Error("Failed to forward spam message to log channel", "error", err, "messageID", message.MessageID, "logChannelID", logChannelID)
+		} else {
+			b.logger.Info("Forwarded spam message to log channel", "messageID", message.MessageID, "userID", userID, "channelID", channelID, "logChannelID", logChannelID, "label", label)
+		}
+	}
+
+	lang := b.chatLocale(ctx, channelID)
+	var deleted, muted, banned bool
+	summary := locale.T(lang, "notify.logged", label)
+	if stale && action.severity() >= ActionDelete.severity() {
+		summary = locale.T(lang, "notify.too_old", label)
+		b.logger.Info("Spam message too old to delete", "messageID", message.MessageID, "userID", userID, "channelID", channelID, "age", time.Since(message.Time()).Round(time.Minute), "action", action)
+	} else if action.severity() >= ActionDelete.severity() && adminRights.CanDeleteMessages {
+		summary = locale.T(lang, "notify.deleted", label)
+		if err := b.deleteMessage(channelID, message.MessageID); err != nil {
+			b.logger.Error("Failed to delete spam message", "error", err, "messageID", message.MessageID)
+		} else {
+			b.logger.Info("Deleted spam message", "messageID", message.MessageID, "userID", userID, "channelID", channelID, "label", label)
+			b.storeContent(ctx, message)
+			deleted = true
+		}
+	}
+
+	if action == ActionMute && adminRights.CanRestrictMembers {
+		summary += "\n" + locale.T(lang, "notify.muted", b.config.MuteDuration)
+		if err := b.muteUser(channelID, userID, time.Now().Add(b.config.MuteDuration)); err != nil {
+			b.logger.Error("Failed to mute user", "error", err, "userID", userID, "channelID", channelID)
+		} else {
+			b.logger.Info("Muted user", "userID", userID, "channelID", channelID, "duration", b.config.MuteDuration)
+			muted = true
+		}
+	}
+
+	if action.severity() >= ActionBan.severity() && adminRights.CanRestrictMembers {
+		summary += "\n" + locale.T(lang, "notify.banned")
+		if err := b.restrictUser(channelID, userID); err != nil {
+			b.logger.Error("Failed to restrict user", "error", err, "userID", userID, "channelID", channelID)
+		} else {
+			b.logger.Info("Restricted user", "userID", userID, "channelID", channelID)
+			banned = true
+			if b.config.FloodDeleteWindow > 0 && adminRights.CanDeleteMessages {
+				if flood := b.deleteFlood(ctx, channelID, userID, message.MessageID); flood > 0 {
+					summary += "\n" + locale.T(lang, "notify.flood_deleted", flood)
+				}
+			}
+			b.escalateInviter(ctx, channelID, userID, adminRights)
+			if b.config.ReportOnBan {
+				if err := b.report(ctx, message, userID, "banned"); err != nil {
+					b.logger.Error("Failed to report spammer", "error", err, "userID", userID)
+				}
+			}
+		}
+	}
+
+	// Every action an admin can undo counts, so false positives from undo never outnumber detections
+	if (deleted || muted || banned) && label != reportedSpamLabel {
+		b.recordFeedback(ctx, channelID, feedbackDetections)
+	}
+
+	b.postNotice(ctx, message, label, lang, noticeAction(deleted, muted, banned))
+
+	if warmup {
+		detections := b.countWarmupDetection(ctx, channelID)
+		summary += "\n" + locale.T(lang, "notify.warmup")
+		if b.config.WarmupDetections > 0 {
+			summary += fmt.Sprintf(" (%d/%d)", detections, b.config.WarmupDetections)
+		}
+	}
+
+	if logChannelID, exists := b.logChannel(channelID); exists && notify {
+		// Send additional information to the log channel
+		logMessage := summary + "\n" + locale.T(lang, "notify.details", userID, channelID, label, score, threshold)
+		if suppressed > 0 {
+			logMessage += "\n" + locale.T(lang, "notify.collapsed", suppressed)
+		}
+		var logMessageID int
+		if b.hasDetails(ctx, channelID, message.MessageID) {
+			logMessageID = b.sendLogWithDetails(logChannelID, logMessage, channelID, message.MessageID)
+		} else {
+			logMessageID = b.sendLog(logChannelID, logMessage)
+		}
+		if logMessageID != 0 {
+			b.recordDecisionNotice(ctx, logChannelID, logMessageID, channelID, message.MessageID)
+		}
+		if logMessageID != 0 && (deleted || muted || banned) {
+			b.rememberUndo(ctx, logChannelID, logMessageID, undoRecord{
+				ChatID:     channelID,
+				UserID:     userID,
+				MessageID:  message.MessageID,
+				Name:       senderName(message),
+				Hash:       b.hashMessage(messageText(message)),
+				Restricted: muted || banned,
+			})
+		}
+	}
+}
+
+// sendLog sends a message to a log channel and returns its ID, or 0 if it couldn't be sent
+func (b *Bot) sendLog(logChannelID int64, text string) int {
+	logMessageID, err := b.messenger.Send(OutgoingMessage{ChatID: logChannelID, Text: text})
+	if err != nil {
+		b.logger.Error("Failed to send log message to log channel", "error", err, "logChannelID", logChannelID)
+		return 0
+	}
+	return logMessageID
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// allowedUpdates are the update types the bot subscribes to; chat member
+// updates aren't sent unless requested and keep the cached admin lists fresh
+var allowedUpdates = []string{"message", "channel_post", "callback_query", "chat_join_request", "chat_member", "my_chat_member"}
+
+func (b *Bot) adminsKey(chatID int64) string {
+	return b.key("admins:%d", chatID)
+}
+
+// adminNamesKey holds the display names and usernames of a chat's administrators, cached along with their IDs
+func (b *Bot) adminNamesKey(chatID int64) string {
+	return b.key("admin_names:%d", chatID)
+}
+
+// chatAdmins returns the IDs of the chat's administrators, cached in Redis for AdminCacheTTL
+func (b *Bot) chatAdmins(chatID int64) map[int64]bool {
+	ctx := context.Background()
+	key := b.adminsKey(chatID)
+
+	cached, err := b.redis.SMembers(ctx, key).Result()
+	if err != nil {
+		b.logger.Error("Failed to get cached chat administrators", "error", err, "chatID", chatID)
+	}
+	if len(cached) > 0 {
+		admins := make(map[int64]bool, len(cached))
+		for _, member := range cached {
+			if id, err := strconv.ParseInt(member, 10, 64); err == nil {
+				admins[id] = true
+			}
+		}
+		return admins
+	}
+
+	members := b.fetchAdmins(ctx, chatID)
+	admins := make(map[int64]bool, len(members))
+	for _, member := range members {
+		admins[member.ID] = true
+	}
+	return admins
+}
+
+// adminNames returns the cached names and usernames of the chat's administrators by their IDs
+func (b *Bot) adminNames(chatID int64) map[int64][]string {
+	ctx := context.Background()
+	cached, err := b.redis.HGetAll(ctx, b.adminNamesKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get cached chat administrator names", "error", err, "chatID", chatID)
+	}
+	names := make(map[int64][]string)
+	if len(cached) > 0 {
+		for member, value := range cached {
+			if id, err := strconv.ParseInt(member, 10, 64); err == nil {
+				names[id] = strings.Split(value, "\n")
+			}
+		}
+		return names
+	}
+	for _, member := range b.fetchAdmins(ctx, chatID) {
+		names[member.ID] = adminAliases(member)
+	}
+	return names
+}
+
+// adminAliases lists the names an administrator is recognized by
+func adminAliases(admin Administrator) []string {
+	aliases := []string{admin.Name}
+	if admin.Username != "" {
+		aliases = append(aliases, admin.Username)
+	}
+	return aliases
+}
+
+// fetchAdmins gets the chat's administrators from Telegram, caching their IDs and names for AdminCacheTTL
+func (b *Bot) fetchAdmins(ctx context.Context, chatID int64) []Administrator {
+	members, err := b.messenger.Administrators(chatID)
+	if err != nil {
+		b.logger.Error("Error getting chat administrators", "error", err, "chatID", chatID)
+		return nil
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	ids := make([]any, 0, len(members))
+	names := make(map[string]any, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+		names[strconv.FormatInt(member.ID, 10)] = strings.Join(adminAliases(member), "\n")
+	}
+	key, namesKey := b.adminsKey(chatID), b.adminNamesKey(chatID)
+	pipe := b.redis.TxPipeline()
+	pipe.Del(ctx, key, namesKey)
+	pipe.SAdd(ctx, key, ids...)
+	pipe.HSet(ctx, namesKey, names)
+	pipe.Expire(ctx, key, b.config.AdminCacheTTL)
+	pipe.Expire(ctx, namesKey, b.config.AdminCacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to cache chat administrators", "error", err, "chatID", chatID)
+	}
+	return members
+}
+
+// handleChatMemberUpdate tracks joining and leaving users and drops the
+// cached admin list of a chat when someone is promoted or demoted
+func (b *Bot) handleChatMemberUpdate(update *tgbotapi.ChatMemberUpdated) {
+	b.rememberJoinInviter(update)
+	b.trackTenure(update)
+	if memberJoined(update) && update.NewChatMember.User != nil {
+		member := *update.NewChatMember.User
+		b.goSafe(func() { b.screenProfile(update.Chat.ID, member) })
+		b.goSafe(func() { b.screenImpersonation(update.Chat.ID, member) })
+	}
+	if update.OldChatMember.IsAdministrator() == update.NewChatMember.IsAdministrator() &&
+		update.OldChatMember.IsCreator() == update.NewChatMember.IsCreator() {
+		return
+	}
+	if err := b.redis.Del(context.Background(), b.adminsKey(update.Chat.ID), b.adminNamesKey(update.Chat.ID)).Err(); err != nil {
+		b.logger.Error("Failed to invalidate cached chat administrators", "error", err, "chatID", update.Chat.ID)
+		return
+	}
+	b.logger.Debug("Invalidated cached chat administrators", "chatID", update.Chat.ID, "userID", update.NewChatMember.User.ID)
+}
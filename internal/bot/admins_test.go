@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// statusChange returns an update of user's status in testChatID from old to new
+func statusChange(userID int64, old, new string) *tgbotapi.ChatMemberUpdated {
+	user := &tgbotapi.User{ID: userID, FirstName: "Member"}
+	return &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: testChatID, Type: "supergroup"},
+		From:          tgbotapi.User{ID: testAdminID},
+		Date:          int(time.Now().Unix()),
+		OldChatMember: tgbotapi.ChatMember{User: user, Status: old},
+		NewChatMember: tgbotapi.ChatMember{User: user, Status: new},
+	}
+}
+
+func TestChatAdminsCache(t *testing.T) {
+	config := testConfig()
+	config.AdminCacheTTL = time.Hour
+	b, messenger, server := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin", Username: "admin"}}
+
+	// A miss fetches the admins from Telegram and caches them
+	if admins := b.chatAdmins(testChatID); !admins[testAdminID] || len(admins) != 1 {
+		t.Fatalf("chatAdmins() = %v, want the chat's admin", admins)
+	}
+	if ttl := server.TTL(b.adminsKey(testChatID)); ttl != time.Hour {
+		t.Errorf("admin cache TTL = %v, want AdminCacheTTL", ttl)
+	}
+
+	// A hit answers from the cache without asking Telegram
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}, {ID: 43, Name: "Promoted"}}
+	if admins := b.chatAdmins(testChatID); admins[43] {
+		t.Errorf("chatAdmins() = %v, want the cached admins", admins)
+	}
+	if names := b.adminNames(testChatID); len(names[testAdminID]) != 2 || names[testAdminID][1] != "admin" {
+		t.Errorf("adminNames() = %v, want the cached name and username", names)
+	}
+
+	// Other status changes keep the cache
+	b.handleChatMemberUpdate(statusChange(44, "member", "kicked"))
+	if admins := b.chatAdmins(testChatID); admins[43] {
+		t.Errorf("chatAdmins() = %v, a ban dropped the cache", admins)
+	}
+
+	// Promotions drop the cache, so the new admin is fetched
+	b.handleChatMemberUpdate(statusChange(43, "member", "administrator"))
+	if admins := b.chatAdmins(testChatID); !admins[43] {
+		t.Errorf("chatAdmins() = %v, want the promoted admin after a promotion", admins)
+	}
+
+	// And so do demotions
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	b.handleChatMemberUpdate(statusChange(43, "administrator", "member"))
+	if admins := b.chatAdmins(testChatID); admins[43] {
+		t.Errorf("chatAdmins() = %v, want the demoted admin gone", admins)
+	}
+}
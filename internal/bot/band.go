@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// reviewLabel marks messages scored too close to the threshold to act on automatically
+const reviewLabel = "Possible spam"
+
+const maxUncertaintyBand = 0.5
+
+func (b *Bot) uncertaintyBandKey(chatID int64) string {
+	return b.key("uncertainty_band:%d", chatID)
+}
+
+// uncertaintyBand returns how far from the threshold scores are left to admins in a chat,
+// which may be overridden per chat
+func (b *Bot) uncertaintyBand(ctx context.Context, chatID int64) float64 {
+	band, err := b.redis.Get(ctx, b.uncertaintyBandKey(chatID)).Float64()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get chat uncertainty band", "error", err, "chatID", chatID)
+		}
+		return b.config.UncertaintyBand
+	}
+	return band
+}
+
+// uncertain reports whether a score falls within band of the threshold, on either side
+func uncertain(score, threshold, band float64) bool {
+	return band > 0 && score >= threshold-band && score <= threshold+band
+}
+
+// handleBandCommand shows, sets, disables or resets the chat's uncertainty band: /band [width|off|reset]
+func (b *Bot) handleBandCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	arg := strings.TrimSpace(message.CommandArguments())
+
+	switch arg {
+	case "":
+		b.reply(message, b.t(chatID, "band.show", b.uncertaintyBand(ctx, chatID), b.threshold(ctx, chatID), b.config.UncertaintyBand))
+	case "reset":
+		if err := b.redis.Del(ctx, b.uncertaintyBandKey(chatID)).Err(); err != nil {
+			b.logger.Error("Failed to reset chat uncertainty band", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "band.reset_failed"))
+			return
+		}
+		b.logger.Info("Reset chat uncertainty band", "chatID", chatID, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "band.reset", b.config.UncertaintyBand))
+	default:
+		band := 0.0
+		if arg != "off" {
+			var err error
+			band, err = strconv.ParseFloat(arg, 64)
+			if err != nil || band <= 0 || band > maxUncertaintyBand {
+				b.reply(message, b.t(chatID, "band.usage"))
+				return
+			}
+		}
+		if err := b.redis.Set(ctx, b.uncertaintyBandKey(chatID), band, 0).Err(); err != nil {
+			b.logger.Error("Failed to set chat uncertainty band", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "band.set_failed"))
+			return
+		}
+		b.logger.Info("Set chat uncertainty band", "chatID", chatID, "band", band, "admin", commandSender(message))
+		if band == 0 {
+			b.reply(message, b.t(chatID, "band.off"))
+			return
+		}
+		b.reply(message, b.t(chatID, "band.set", band))
+	}
+}
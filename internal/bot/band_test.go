@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+func TestUncertain(t *testing.T) {
+	tests := []struct {
+		score, threshold, band float64
+		want                   bool
+	}{
+		{0.5, 0.5, 0.1, true},
+		{0.4, 0.5, 0.1, true},
+		{0.6, 0.5, 0.1, true},
+		{0.39, 0.5, 0.1, false},
+		{0.61, 0.5, 0.1, false},
+		{0.5, 0.5, 0, false},
+		{0.95, 0.9, 0.2, true},
+	}
+	for _, tt := range tests {
+		if got := uncertain(tt.score, tt.threshold, tt.band); got != tt.want {
+			t.Errorf("uncertain(%v, %v, %v) = %t, want %t", tt.score, tt.threshold, tt.band, got, tt.want)
+		}
+	}
+}
+
+func TestDecideWithBand(t *testing.T) {
+	config := testConfig()
+	config.Labels = []LabelRule{{Name: "scam", Threshold: 0.5, Action: ActionDelete}}
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	tests := []struct {
+		name   string
+		result ai.Result
+		raid   bool
+		want   Action
+		label  string
+	}{
+		{"clear spam", ai.Result{SpamScore: 0.9}, false, ActionBan, spamLabel},
+		{"just above the threshold", ai.Result{SpamScore: 0.55}, false, ActionNotify, reviewLabel},
+		{"just below the threshold", ai.Result{SpamScore: 0.45}, false, ActionNotify, reviewLabel},
+		{"clean", ai.Result{SpamScore: 0.2}, false, "", ""},
+		{"label wins over the band", ai.Result{SpamScore: 0.55, Labels: map[string]float64{"scam": 0.8}}, false, ActionDelete, "scam"},
+		{"no band during a raid", ai.Result{SpamScore: 0.55}, true, ActionBan, spamLabel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.decide(&tt.result, 0.5, 0.1, tt.raid)
+			if got.Action != tt.want || got.Label != tt.label {
+				t.Errorf("decide() = %+v, want %q labeled %q", got, tt.want, tt.label)
+			}
+		})
+	}
+}
+
+func TestBandIsSetPerChat(t *testing.T) {
+	config := testConfig()
+	config.UncertaintyBand = 0.1
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	ctx := context.Background()
+
+	b.handleCommand(commandMessage(testAdminID, "/band 0.25"))
+	if band := b.uncertaintyBand(ctx, testChatID); band != 0.25 {
+		t.Errorf("band = %v after /band 0.25", band)
+	}
+	if band := b.uncertaintyBand(ctx, -1009); band != 0.1 {
+		t.Errorf("band of another chat = %v, want the default 0.1", band)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/band 0.9"))
+	if band := b.uncertaintyBand(ctx, testChatID); band != 0.25 {
+		t.Errorf("band = %v after an out-of-range /band, want 0.25 kept", band)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/band off"))
+	if band := b.uncertaintyBand(ctx, testChatID); band != 0 {
+		t.Errorf("band = %v after /band off", band)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/band reset"))
+	if band := b.uncertaintyBand(ctx, testChatID); band != 0.1 {
+		t.Errorf("band = %v after /band reset, want the default", band)
+	}
+}
+
+func TestBandedMessageIsReportedNotActedOn(t *testing.T) {
+	config := testConfig()
+	config.UncertaintyBand = 0.1
+	b, messenger, _ := newTestBot(t, config, &countingProvider{response: `{"reasoning": "unsure", "spam_score": 0.55}`})
+
+	handle(b, textMessage(10, "maybe spam"))
+
+	if len(messenger.deleted)+len(messenger.restricted) != 0 {
+		t.Errorf("deleted = %v, restricted = %v, want a banded score left to admins", messenger.deleted, messenger.restricted)
+	}
+	if len(messenger.sentTexts()) == 0 {
+		t.Error("the banded message wasn't reported for review")
+	}
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// bareLinkWords is the number of words besides its links up to which a message is a bare link
+const bareLinkWords = 3
+
+// isLinkEntity reports whether an entity is a URL or a text link
+func isLinkEntity(entity tgbotapi.MessageEntity) bool {
+	return entity.Type == "url" || entity.Type == "text_link"
+}
+
+// isBareLink reports whether a message is little more than a link, such as "hi t.me/promo".
+// Such messages give the classifier hardly anything to go on, so new users posting them
+// are boosted and optionally acted on without classification.
+func isBareLink(message *tgbotapi.Message) bool {
+	entities := messageEntities(message)
+	linked := false
+	for _, entity := range entities {
+		if isLinkEntity(entity) && !insideCode(entity, entities) {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		return false
+	}
+
+	words := 0
+	for _, field := range strings.Fields(rewriteEntities(messageText(message), entities, isLinkEntity, " ")) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	return words <= bareLinkWords
+}
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// linkMessage returns a message from testUserID whose text contains link as a URL entity
+func linkMessage(messageID int, text, link string) *tgbotapi.Message {
+	message := textMessage(messageID, text)
+	if offset := strings.Index(text, link); offset >= 0 {
+		message.Entities = []tgbotapi.MessageEntity{{Type: "url", Offset: offset, Length: len(link)}}
+	}
+	return message
+}
+
+func TestIsBareLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *tgbotapi.Message
+		want    bool
+	}{
+		{"only a link", linkMessage(1, "https://t.me/promo", "https://t.me/promo"), true},
+		{"word and link", linkMessage(1, "hi https://t.me/promo", "https://t.me/promo"), true},
+		{"three words and link", linkMessage(1, "look at this https://t.me/promo !!", "https://t.me/promo"), true},
+		{"sentence with link", linkMessage(1, "the meetup slides are at https://example.com/slides", "https://example.com/slides"), false},
+		{"no link", textMessage(1, "hi"), false},
+		{"text link", &tgbotapi.Message{
+			Text:     "click here",
+			Entities: []tgbotapi.MessageEntity{{Type: "text_link", Offset: 0, Length: 10, URL: "https://t.me/promo"}},
+		}, true},
+		{"link in code", &tgbotapi.Message{
+			Text: "https://t.me/promo",
+			Entities: []tgbotapi.MessageEntity{
+				{Type: "code", Offset: 0, Length: 18},
+				{Type: "url", Offset: 0, Length: 18},
+			},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBareLink(tt.message); got != tt.want {
+				t.Errorf("isBareLink(%q) = %t, want %t", tt.message.Text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBareLinkFromNewUserIsClassified(t *testing.T) {
+	provider := &countingProvider{response: `{"reasoning": "promo link", "spam_score": 0.9}`}
+	b, messenger, _ := newTestBot(t, testConfig(), provider)
+
+	handle(b, linkMessage(10, "hi t.me/promo", "t.me/promo"))
+
+	if provider.callCount() != 1 {
+		t.Errorf("provider calls = %d, want the short message classified", provider.callCount())
+	}
+	if len(messenger.restricted) != 1 {
+		t.Errorf("restricted = %v, want the sender banned", messenger.restricted)
+	}
+}
+
+func TestBareLinkActionSkipsClassification(t *testing.T) {
+	config := testConfig()
+	config.BareLinkAction = ActionBan
+	provider := &countingProvider{response: `{"reasoning": "looks fine", "spam_score": 0}`}
+	b, messenger, _ := newTestBot(t, config, provider)
+
+	handle(b, linkMessage(10, "t.me/promo", "t.me/promo"))
+
+	if provider.callCount() != 0 {
+		t.Errorf("provider calls = %d, want none", provider.callCount())
+	}
+	if len(messenger.deleted) != 1 || len(messenger.restricted) != 1 {
+		t.Errorf("deleted = %v, restricted = %v, want the bare link deleted and its sender banned", messenger.deleted, messenger.restricted)
+	}
+}
+
+func TestBareLinkActionSparesEstablishedUsers(t *testing.T) {
+	config := testConfig()
+	config.BareLinkAction = ActionBan
+	provider := &countingProvider{response: `{"reasoning": "looks fine", "spam_score": 0}`}
+	b, messenger, server := newTestBot(t, config, provider)
+	server.Set(b.key("%d:%d", testUserID, testChatID), "5")
+	b.config.ScanWindow = 10
+
+	handle(b, linkMessage(10, "t.me/docs", "t.me/docs"))
+
+	if len(messenger.deleted)+len(messenger.restricted) != 0 {
+		t.Errorf("deleted = %v, restricted = %v, want an established user's link left alone", messenger.deleted, messenger.restricted)
+	}
+	if provider.callCount() != 1 {
+		t.Errorf("provider calls = %d, want the link classified as usual", provider.callCount())
+	}
+}
+
+func TestBareLinkBoostWhileAIPaused(t *testing.T) {
+	config := testConfig()
+	config.BareLinkBoost = 0.6
+	provider := &countingProvider{}
+	b, messenger, _ := newTestBot(t, config, provider)
+	b.setAIPaused(true)
+
+	handle(b, linkMessage(10, "t.me/promo", "t.me/promo"))
+
+	if provider.callCount() != 0 {
+		t.Errorf("provider calls = %d while AI classification is paused", provider.callCount())
+	}
+	if len(messenger.restricted) != 1 {
+		t.Errorf("restricted = %v, want the boosted bare link acted on by heuristics", messenger.restricted)
+	}
+}
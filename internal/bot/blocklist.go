@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	blocklistMaxEntries = 200 // Entries kept per chat, so every message isn't matched against an unbounded list
+	blocklistMaxLength  = 200 // Maximum length of an entry
+)
+
+func (b *Bot) blocklistKey(chatID int64) string {
+	return b.key("blocklist:%d", chatID)
+}
+
+// blockPattern compiles a blocklist entry: /expression/ is a case-insensitive
+// regular expression, anything else a case-insensitive phrase
+func blockPattern(entry string) (*regexp.Regexp, error) {
+	if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+		return regexp.Compile("(?i)" + entry[1:len(entry)-1])
+	}
+	return regexp.Compile("(?i)" + regexp.QuoteMeta(entry))
+}
+
+// compiledBlockPattern returns the pattern of an entry, compiling it once
+func (b *Bot) compiledBlockPattern(entry string) (*regexp.Regexp, error) {
+	if pattern, ok := b.blockPatterns.Load(entry); ok {
+		return pattern.(*regexp.Regexp), nil
+	}
+	pattern, err := blockPattern(entry)
+	if err != nil {
+		return nil, err
+	}
+	b.blockPatterns.Store(entry, pattern)
+	return pattern, nil
+}
+
+// blocklistMatch returns the first entry of the chat's blocklist found in the text
+func (b *Bot) blocklistMatch(ctx context.Context, chatID int64, text string) (string, bool) {
+	entries, err := b.redis.SMembers(ctx, b.blocklistKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get blocklist", "error", err, "chatID", chatID)
+		return "", false
+	}
+	for _, entry := range entries {
+		pattern, err := b.compiledBlockPattern(entry)
+		if err != nil {
+			b.logger.Warn("Skipping invalid blocklist entry", "error", err, "chatID", chatID, "entry", entry)
+			continue
+		}
+		if pattern.MatchString(text) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// handleBlockCommand adds an entry to the chat's blocklist: /block <phrase|/regexp/>
+func (b *Bot) handleBlockCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	entry := strings.TrimSpace(message.CommandArguments())
+	if entry == "" {
+		b.reply(message, "Usage: /block <phrase> or /block /regexp/")
+		return
+	}
+	if len(entry) > blocklistMaxLength {
+		b.reply(message, fmt.Sprintf("Blocklist entries can be at most %d characters long", blocklistMaxLength))
+		return
+	}
+	if _, err := blockPattern(entry); err != nil {
+		b.reply(message, fmt.Sprintf("Invalid regular expression: %v", err))
+		return
+	}
+
+	count, err := b.redis.SCard(ctx, b.blocklistKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get blocklist size", "error", err, "chatID", chatID)
+		b.reply(message, "Failed to update the blocklist")
+		return
+	}
+	if count >= blocklistMaxEntries {
+		b.reply(message, fmt.Sprintf("The blocklist is full (%d entries), remove some with /unblock first", blocklistMaxEntries))
+		return
+	}
+	if err := b.redis.SAdd(ctx, b.blocklistKey(chatID), entry).Err(); err != nil {
+		b.logger.Error("Failed to add blocklist entry", "error", err, "chatID", chatID)
+		b.reply(message, "Failed to update the blocklist")
+		return
+	}
+	b.logger.Info("Added blocklist entry", "chatID", chatID, "entry", entry, "admin", commandSender(message))
+	b.reply(message, fmt.Sprintf("Blocked %q", entry))
+}
+
+// handleUnblockCommand removes an entry from the chat's blocklist: /unblock <entry>
+func (b *Bot) handleUnblockCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	entry := strings.TrimSpace(message.CommandArguments())
+	if entry == "" {
+		b.reply(message, "Usage: /unblock <entry>, see /blocklist for the entries")
+		return
+	}
+	removed, err := b.redis.SRem(ctx, b.blocklistKey(chatID), entry).Result()
+	if err != nil {
+		b.logger.Error("Failed to remove blocklist entry", "error", err, "chatID", chatID)
+		b.reply(message, "Failed to update the blocklist")
+		return
+	}
+	if removed == 0 {
+		b.reply(message, fmt.Sprintf("%q is not on the blocklist", entry))
+		return
+	}
+	b.logger.Info("Removed blocklist entry", "chatID", chatID, "entry", entry, "admin", commandSender(message))
+	b.reply(message, fmt.Sprintf("Unblocked %q", entry))
+}
+
+// handleBlocklistCommand lists the chat's blocklist: /blocklist
+func (b *Bot) handleBlocklistCommand(message *tgbotapi.Message) {
+	entries, err := b.redis.SMembers(context.Background(), b.blocklistKey(message.Chat.ID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get blocklist", "error", err, "chatID", message.Chat.ID)
+		b.reply(message, "Failed to get the blocklist")
+		return
+	}
+	if len(entries) == 0 {
+		b.reply(message, "The blocklist is empty, add entries with /block")
+		return
+	}
+	sort.Strings(entries)
+	b.reply(message, fmt.Sprintf("Blocklist (%d):\n%s", len(entries), strings.Join(entries, "\n")))
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// blocklistBot returns a bot whose chat admin is testAdminID
+func blocklistBot(t *testing.T, provider *countingProvider) (*Bot, *fakeMessenger) {
+	t.Helper()
+	b, messenger, _ := newTestBot(t, testConfig(), provider)
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	return b, messenger
+}
+
+// lastReply returns the text of the last message the bot sent
+func lastReply(messenger *fakeMessenger) string {
+	texts := messenger.sentTexts()
+	if len(texts) == 0 {
+		return ""
+	}
+	return texts[len(texts)-1]
+}
+
+func TestBlocklistCommands(t *testing.T) {
+	b, messenger := blocklistBot(t, &countingProvider{})
+	ctx := context.Background()
+
+	b.handleCommand(commandMessage(testAdminID, "/block free crypto"))
+	b.handleCommand(commandMessage(testAdminID, "/block /t\\.me/\\w+bot/"))
+	b.handleCommand(commandMessage(testAdminID, "/block /(unclosed/"))
+	if reply := lastReply(messenger); !strings.HasPrefix(reply, "Invalid regular expression") {
+		t.Errorf("reply = %q, want an invalid regular expression refused", reply)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/blocklist"))
+	if reply := lastReply(messenger); !strings.Contains(reply, "Blocklist (2)") || !strings.Contains(reply, "free crypto") || !strings.Contains(reply, `/t\.me/\w+bot/`) {
+		t.Errorf("/blocklist = %q, want both entries listed", reply)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/unblock free crypto"))
+	b.handleCommand(commandMessage(testAdminID, "/unblock free crypto"))
+	if reply := lastReply(messenger); !strings.Contains(reply, "is not on the blocklist") {
+		t.Errorf("reply = %q, want the removed entry reported missing", reply)
+	}
+	entries, err := b.redis.SMembers(ctx, b.blocklistKey(testChatID)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(entries, []string{`/t\.me/\w+bot/`}) {
+		t.Errorf("blocklist = %q, want only the regular expression left", entries)
+	}
+}
+
+func TestBlocklistMatch(t *testing.T) {
+	b, _ := blocklistBot(t, &countingProvider{})
+	ctx := context.Background()
+	if err := b.redis.SAdd(ctx, b.blocklistKey(testChatID), "free crypto", `/t\.me/\w+bot/`, "a.b").Err(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text  string
+		entry string
+	}{
+		{"Get FREE Crypto today", "free crypto"},
+		{"write to t.me/earn_money_bot", `/t\.me/\w+bot/`},
+		{"plain entries match literally: a.b", "a.b"},
+		{"axb only", ""},
+		{"free  crypto with two spaces", ""},
+		{"t.me/channel", ""},
+	}
+	for _, tt := range tests {
+		entry, ok := b.blocklistMatch(ctx, testChatID, tt.text)
+		if ok != (tt.entry != "") || entry != tt.entry {
+			t.Errorf("blocklistMatch(%q) = %q, %t, want %q", tt.text, entry, ok, tt.entry)
+		}
+	}
+	if _, ok := b.blocklistMatch(ctx, -1002, "free crypto"); ok {
+		t.Error("another chat's blocklist matched")
+	}
+}
+
+func TestBlocklistedMessagesSkipClassification(t *testing.T) {
+	provider := &countingProvider{response: `{"reasoning": "fine", "spam_score": 0}`}
+	b, messenger := blocklistBot(t, provider)
+	b.handleCommand(commandMessage(testAdminID, "/block free crypto"))
+
+	handle(b, textMessage(10, "get free crypto now"))
+	handle(b, textMessage(11, "hello everyone"))
+
+	if !slices.Equal(messenger.deleted, []int{10}) {
+		t.Errorf("deleted = %v, want only the blocklisted message", messenger.deleted)
+	}
+	if !slices.Equal(messenger.restricted, []int64{testUserID}) {
+		t.Errorf("restricted = %v, want the new user banned", messenger.restricted)
+	}
+	if provider.callCount() != 1 {
+		t.Errorf("classified %d messages, want only the one not blocklisted", provider.callCount())
+	}
+}
@@ -7,18 +7,26 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/cas"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/debugstore"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/errreport"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/evalstore"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Bot struct {
-	api               *tgbotapi.BotAPI
+	api               *tgbotapi.BotAPI // Receives updates, actions go through the messenger
+	messenger         Messenger
 	redis             *redis.Client
 	logger            *slog.Logger
 	aiprovider        ai.Provider
@@ -26,253 +34,512 @@ type Bot struct {
 	adminCache        map[int64]AdminRights
 	cacheMutex        sync.RWMutex
 	stopChan          chan struct{}
+	webhookUpdates    chan receivedUpdate
 	whitelistChannels map[int64]bool
+	debugStore        *debugstore.Store
+	evalStore         *evalstore.Store
+	shadowProvider    ai.Provider
+	reporter          errreport.Reporter
+	shadowStats       shadowStats
+	shadowSlots       chan struct{} // Held by shadow classifications in flight
+	chatLabels        *metrics.ChatLabels
+	cas               *cas.Client
+	notifications     *notifyThrottle
+	cacheStats        cacheStats
+	spamEmbeddings    spamEmbeddings
+	moderation        moderationState
+	costCapped        atomic.Bool
+	aiPaused          atomic.Bool
+	noBulkDelete      atomic.Bool // Set once the Bot API server rejects deleteMessages
+	blockPatterns     sync.Map    // Compiled blocklist entries by entry
 }
 
 type Config struct {
-	Prompt            string
-	Threshold         float64
-	NewUserThreshold  int
-	WhitelistChannels []int64
-	LogChannels       map[int64]int64
+	Token                   string // Telegram bot token, read from TELEGRAM_BOT_TOKEN if empty
+	Prompt                  string
+	ForceJSON               bool // Whether the provider is forced into JSON mode, so prompts need no response tags
+	Threshold               float64
+	NewUserThreshold        int
+	WhitelistChannels       []int64
+	LogChannels             map[int64]int64
+	DefaultLogChannel       int64 // Log channel of chats without their own in LogChannels, disabled if zero
+	FirstMessageOnly        bool  // Classify only the first message of each user, checking later ones with heuristics
+	ScanWindow              int   // Number of clean messages after which AI scanning stops, defaults to NewUserThreshold
+	BuildInfo               buildinfo.Info
+	Labels                  []LabelRule
+	LengthThresholds        []LengthThreshold // Threshold offsets by message length, the first matching bucket applies
+	DebugStoreTTL           time.Duration     // Retention of recorded prompts and responses, disabled if zero
+	EvalSampleRate          float64           // Fraction of classifications kept in the eval store, disabled if zero
+	EvalStoreSize           int               // Number of most recent eval samples kept
+	EvalKeepContent         bool              // Keep message text in eval samples instead of only its hash
+	OnError                 ErrorPolicy
+	SpamExamples            int // Number of recent spam examples from the chat injected into the prompt, disabled if zero
+	SpamExamplesChars       int // Maximum total length of the injected examples
+	RegisterCommands        bool
+	SenderChatPolicy        SenderChatPolicy
+	SampleRate              float64     // Fraction of established users' messages that are still scanned
+	ShadowProvider          ai.Provider // Candidate provider classifying alongside the primary one without affecting decisions
+	MetricsMinChatMessages  int         // Scanned messages after which a chat gets its own metrics label
+	MetricsMaxChats         int         // Maximum number of chats with their own metrics label
+	JoinRequestPolicy       JoinRequestPolicy
+	UseCAS                  bool          // Check joining users against the CAS ban list
+	NewAccountID            int64         // User IDs at or above this are treated as recently created accounts, disabled if zero
+	NewAccountBoost         float64       // Added to the spam score of recently created accounts
+	PurgeWindow             time.Duration // How far back /purge deletes a user's messages
+	FloodDeleteWindow       time.Duration // How far back the other messages of banned users are deleted, disabled if zero
+	RedisPrefix             string        // Prepended to every Redis key the bot uses
+	NotifyDedupeWindow      time.Duration // Window in which repeated notifications about a user are collapsed
+	NotifyRateLimit         int           // Maximum admin notifications per minute, unlimited if zero
+	ScanFilter              ScanFilter
+	ScanCommands            bool          // Scan commands not handled by the bot, otherwise commands for other bots and bare commands are skipped
+	LearningInterval        time.Duration // How often per-chat thresholds are adjusted from admin feedback, disabled if zero
+	LearningTargetPrecision float64
+	LearningStep            float64         // Maximum threshold change per adjustment
+	WebhookURL              string          // Public base URL updates are received on by webhook instead of polling, e.g. https://bot.example.com
+	WebhookSecret           string          // Secret token Telegram sends with webhook updates, requests without it are rejected
+	PollTimeout             time.Duration   // Long-poll timeout of getUpdates
+	PollLimit               int             // Maximum number of updates fetched per getUpdates call, 0 for Telegram's default of 100
+	PollJitter              time.Duration   // Upper bound of the random pause after empty or failed polls
+	ExitOnConflict          int             // Exit after this many consecutive getUpdates conflicts, disabled if zero
+	TenureGrace             time.Duration   // Members who joined longer ago are treated as established regardless of message count, disabled if zero
+	Workers                 int             // Number of updates processed concurrently
+	PerUserOrder            bool            // Act on each user's messages in arrival order when Workers > 1
+	ContextMessages         int             // Number of preceding chat messages injected into the prompt, disabled if zero
+	ContextMessagesChars    int             // Maximum total length of the injected chat messages
+	ActionPolicy            ActionPolicy    // Strictest action applied, overridable per chat
+	MuteDuration            time.Duration   // How long users are restricted by the mute action
+	StaleAction             Action          // Applied instead of deleting flagged messages too old to delete, if stricter
+	DeleteVerifyAttempts    int             // Times a deleted message is checked and deleted again if still present, disabled if zero
+	DeleteVerifyDelay       time.Duration   // Pause before each deletion check
+	InviterThreshold        int             // Number of banned spammers invited by the same user that triggers InviterAction, disabled if zero
+	InviterAction           Action          // Applied to inviters of repeated spammers
+	EmojiRatio              float64         // Share of emoji and symbols above which new users' messages are boosted, disabled if zero
+	EmojiRatioBoost         float64         // Added to the spam score of emoji-flooded messages
+	CustomEmoji             CustomEmojiMode // How custom emoji are presented to the classifier
+	CustomEmojiRatio        float64         // Share of custom emoji above which new users' messages are boosted, disabled if zero
+	CustomEmojiBoost        float64         // Added to the spam score of messages dense with custom emoji
+	LanguageBoost           float64         // Added to the spam score of new users' messages outside the chat's languages
+	PhoneBoost              float64         // Added to the spam score of new users' messages with a phone number
+	WalletBoost             float64         // Added to the spam score of new users' messages with a crypto wallet address
+	CardBoost               float64         // Added to the spam score of new users' messages with a card number
+	EmailBoost              float64         // Added to the spam score of new users' messages with an email address, tunable per chat
+	MentionBoost            float64         // Added to the spam score of new users' messages with MentionCount mentions, tunable per chat
+	MentionCount            int             // Number of @mentions that makes a message dense with them
+	PaymentAction           Action          // Applied to new users' messages with payment details without classification, disabled if empty
+	BareLinkBoost           float64         // Added to the spam score of new users' messages that are little more than a link
+	BareLinkAction          Action          // Applied to new users' bare links without classification, disabled if empty
+	RepostWindow            time.Duration   // How long new users' messages are remembered to spot reposts of them, disabled if zero
+	RepostBoost             float64         // Added to the spam score of new users' reposts
+	RepostAction            Action          // Applied to new users' reposts without classification, disabled if empty
+	BioPrompt               string          // Prompt classifying the name and bio of users joining a chat, disabled if empty
+	BioThreshold            float64         // Profile score from which new members are reported and boosted
+	BioBoost                float64         // Added to the spam score of new users' messages if their profile was suspicious
+	ImpersonationSimilarity float64         // Name similarity to an admin from which new members are reported, disabled if zero
+	Embedder                ai.Embedder     // Computes message embeddings for near-duplicate detection, disabled if nil
+	NearDuplicateThreshold  float64         // Cosine similarity to known spam above which a message is a near-duplicate
+	NearDuplicateMax        int             // Number of recent spam embeddings compared against
+	ReportChannel           int64           // Channel receiving spammer reports
+	ReportDir               string          // Directory where spammer reports are saved as JSON files
+	ReportOnBan             bool            // Report every banned user automatically
+	ReplyPolicy             ReplyPolicy
+	ReplyToAdminWeight      float64       // Factor applied to the spam score of replies to admins
+	ThreadCleanMessages     int           // Clean replies in a thread after which an established user's replies there are weighted down, disabled if zero
+	ThreadWeight            float64       // Factor applied to the spam score of replies from thread regulars, who aren't scanned if zero
+	MaxMessageAge           time.Duration // Messages older than this when processed aren't classified, disabled if zero
+	MaxTextLength           int           // Message texts are cut to this many bytes before processing, not cut if zero
+	SuperAdmins             []int64       // Users notified about operational problems such as lost admin rights, who can also pause AI classification
+	PauseAI                 bool          // Start with AI classification paused
+	AdminCacheTTL           time.Duration // How long chat administrator lists are cached in Redis
+	IgnoreCodeBlocks        bool          // Exclude code from link and emoji heuristics and tell the classifier about it
+	VoicePolicy             VoicePolicy   // Handling of voice messages and video notes from new users
+	CleanServiceMessages    bool          // Delete join and leave notifications
+	CleanPinnedMessages     bool          // Also delete pin notifications if CleanServiceMessages is set
+	RaidThreshold           float64       // Spam threshold applied while /raid is on, if stricter than the chat's
+	UncertaintyBand         float64       // Scores this close to the threshold are reported for review instead of acted on, disabled if zero
+	RaidDuration            time.Duration // How long /raid on lasts unless a duration is given
+	RaidDetectRate          int           // New users' messages per RaidDetectWindow that start raid mode, disabled if zero
+	RaidDetectWindow        time.Duration
+	AuditKeepContent        bool          // Keep the content of deleted messages behind the details button
+	AuditContentTTL         time.Duration // How long deleted message content is kept
+	NotificationDetailsTTL  time.Duration // How long classification details behind the notification button are kept, disabled if zero
+	DecisionTTL             time.Duration // How long the decisions on messages are kept for /why, disabled if zero
+	DailyCostCap            float64       // Estimated daily spend on classification after which CostCapFallback applies, disabled if zero
+	CostPer1KTokens         float64       // Price used to estimate the cost of a classification
+	CostCapFallback         CostCapFallback
+	WarmupDetections        int                // Detections in a newly added chat that are only reported before enforcing
+	WarmupDuration          time.Duration      // How long after being added to a chat detections are only reported
+	Notice                  *template.Template // Notice posted in the chat when the bot acts, disabled if nil
+	NoticeInterval          time.Duration      // Minimum time between notices in a chat
+	NoticeTTL               time.Duration      // How long notices stay before they are deleted, kept if zero
+	UndoWindow              time.Duration      // How long admins can undo an action by reacting to its notification, disabled if zero
+	UndoReaction            string             // Emoji reaction undoing an action
+	Locale                  string             // Locale of chats without one of their own
+	Reporter                errreport.Reporter // Receives panics and unexpected errors, disabled if nil
 }
 
 func New(logger *slog.Logger, rdb *redis.Client, aiprovider ai.Provider, config *Config) (*Bot, error) {
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	token := config.Token
+	if token == "" {
+		token = os.Getenv("TELEGRAM_BOT_TOKEN")
+	}
 	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, err
 	}
+	return newBot(logger, rdb, aiprovider, config, api, &telegramMessenger{api: api}), nil
+}
 
+// newBot builds a bot receiving updates from api and acting through messenger
+func newBot(logger *slog.Logger, rdb *redis.Client, aiprovider ai.Provider, config *Config, api *tgbotapi.BotAPI, messenger Messenger) *Bot {
 	// Convert WhitelistChannels slice to map for efficient lookup
 	whitelistMap := make(map[int64]bool)
 	for _, channelID := range config.WhitelistChannels {
 		whitelistMap[channelID] = true
 	}
 
+	var casClient *cas.Client
+	if config.UseCAS {
+		casClient = cas.New()
+	}
+
+	var store *debugstore.Store
+	if config.DebugStoreTTL > 0 {
+		store = debugstore.New(rdb, config.RedisPrefix, config.DebugStoreTTL)
+	}
+
+	var evalStore *evalstore.Store
+	if config.EvalSampleRate > 0 && config.EvalStoreSize > 0 {
+		evalStore = evalstore.New(rdb, config.RedisPrefix, config.EvalStoreSize)
+	}
+
+	var reporter errreport.Reporter = errreport.Nop{}
+	if config.Reporter != nil {
+		reporter = config.Reporter
+	}
+
 	return &Bot{
 		api:               api,
+		messenger:         messenger,
 		redis:             rdb,
 		logger:            logger,
 		aiprovider:        aiprovider,
 		config:            config,
 		adminCache:        make(map[int64]AdminRights),
 		stopChan:          make(chan struct{}),
+		webhookUpdates:    make(chan receivedUpdate, api.Buffer),
 		whitelistChannels: whitelistMap,
-	}, nil
+		debugStore:        store,
+		evalStore:         evalStore,
+		shadowProvider:    config.ShadowProvider,
+		shadowSlots:       make(chan struct{}, shadowConcurrency),
+		reporter:          reporter,
+		chatLabels:        metrics.NewChatLabels(config.MetricsMinChatMessages, config.MetricsMaxChats),
+		cas:               casClient,
+		notifications:     newNotifyThrottle(config.NotifyDedupeWindow, config.NotifyRateLimit),
+		moderation: moderationState{
+			failures: make(map[int64]int),
+			paused:   make(map[int64]bool),
+		},
+	}
 }
 
-func (b *Bot) Start() { //nolint:gocyclo,gocognit
+func (b *Bot) Start() {
 	b.logger.Info("Authorized on account", "username", b.api.Self.UserName)
-	b.logger.Info("Config", "threshold", b.config.Threshold, "newUserThreshold", b.config.NewUserThreshold, "whitelistChannels", b.config.WhitelistChannels)
+	b.logger.Info("Config", "threshold", b.config.Threshold, "newUserThreshold", b.config.NewUserThreshold, "scanWindow", b.scanWindow(b.config.NewUserThreshold), "whitelistChannels", b.config.WhitelistChannels)
 	b.logger.Info("Starting bot")
+	b.setAIPaused(b.config.PauseAI)
+
+	if b.config.RegisterCommands {
+		if err := b.registerCommands(); err != nil {
+			b.logger.Error("Failed to register bot commands", "error", err)
+		} else {
+			b.logger.Info("Registered bot commands", "count", len(adminCommands))
+		}
+	}
+
+	b.checkUndoLogChats()
+
+	if retried, failed := b.sweepDeadLetters(context.Background(), 0); retried+failed > 0 {
+		b.logger.Info("Swept dead-letter actions", "retried", retried, "failed", failed)
+	}
 
 	// Start the cache clearing goroutine
 	go b.clearAdminCacheRoutine()
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	if b.config.LearningInterval > 0 {
+		go b.learningRoutine()
+	}
 
-	updates := b.api.GetUpdatesChan(u)
+	var updates <-chan receivedUpdate
+	if b.config.WebhookURL != "" {
+		if err := b.setWebhook(); err != nil {
+			b.logger.Error("Failed to set webhook", "error", err)
+			return
+		}
+		b.logger.Info("Receiving updates by webhook", "url", b.config.WebhookURL+b.WebhookPath(), "secret", b.config.WebhookSecret != "")
+		updates = b.receiveWebhook()
+	} else {
+		updates = b.poll(telegramFetcher{api: b.api}, b.pollConfig(), b.api.Buffer)
+	}
 	me, err := b.api.GetMe()
 	if err != nil {
 		b.logger.Error("Failed to get bot info", "error", err)
 		return
 	}
 
-	for update := range updates {
-		if update.Message == nil {
-			continue
-		}
-		if update.Message.ReplyToMessage != nil { // Ignore replies
-			continue
-		}
-		if update.Message.From.ID == me.ID { // Ignore self
-			continue
+	b.handleUpdates(updates, me)
+}
+
+// handleUpdates processes updates until the channel is closed, on Workers concurrent workers
+func (b *Bot) handleUpdates(updates <-chan receivedUpdate, me tgbotapi.User) {
+	if b.config.Workers <= 1 {
+		for update := range updates {
+			b.handleUpdate(update.incomingUpdate, me, update.received, nil)
 		}
+		return
+	}
 
-		ctx := context.Background()
-		userID := fmt.Sprintf("user%d", update.Message.From.ID)
-		channelID := update.Message.Chat.ID
-
-		// Check admin rights for this chat
-		adminRights := b.checkAdminRights(channelID, me.ID)
-		b.logger.Debug("Bot admin status for chat", "chatID", channelID, "isAdmin", adminRights)
-
-		// Only process messages of type "message"
-		if update.Message.Text != "" {
-			uid, _ := strconv.Atoi(strings.TrimPrefix(userID, "user"))
-			if int64(uid) == channelID && !b.whitelistChannels[channelID] {
-				b.logger.Debug("Skipping self message", "userID", uid, "channelID", channelID)
-				replyMsg := tgbotapi.NewMessage(channelID, "Sorry, it doesn't work this way. Add me to your channel as an admin.")
-				replyMsg.ReplyToMessageID = update.Message.MessageID
-				_, err := b.api.Send(replyMsg)
-				if err != nil {
-					b.logger.Error("Failed to send reply message", "error", err)
+	var order *userOrder
+	if b.config.PerUserOrder {
+		order = newUserOrder()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < b.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				update, turn, ok := order.next(updates)
+				if !ok {
+					return
 				}
-				continue
+				b.handleUpdate(update.incomingUpdate, me, update.received, turn)
 			}
+		}()
+	}
+	wg.Wait()
+}
 
-			// Check if the channel is whitelisted
-			if len(b.whitelistChannels) > 0 && !b.whitelistChannels[channelID] {
-				b.logger.Debug("Skipping non-whitelisted channel", "channelID", channelID)
-				continue
-			}
+// handleUpdate processes an update; with a turn, actions on the message wait for the sender's earlier messages
+func (b *Bot) handleUpdate(update incomingUpdate, me tgbotapi.User, received time.Time, turn *userTurn) { //nolint:gocyclo,gocognit
+	defer b.recoverUpdate(update.Update)
+	defer turn.finish()
+	b.sanitizeMessage(update.Message)
+	b.sanitizeMessage(update.ChannelPost)
+	if update.MessageReaction != nil {
+		b.handleReaction(update.MessageReaction)
+		return
+	}
+	if update.ChatJoinRequest != nil {
+		b.handleJoinRequest(update.ChatJoinRequest)
+		return
+	}
+	if update.CallbackQuery != nil {
+		b.handleCallbackQuery(update.CallbackQuery)
+		return
+	}
+	if update.ChatMember != nil {
+		b.handleChatMemberUpdate(update.ChatMember)
+		return
+	}
+	if update.MyChatMember != nil {
+		b.clearAdminCacheEntry(update.MyChatMember.Chat.ID) // The bot's own rights changed
+		if memberJoined(update.MyChatMember) {
+			b.startWarmup(context.Background(), update.MyChatMember.Chat.ID)
+		}
+		return
+	}
+	if update.ChannelPost != nil && update.ChannelPost.IsCommand() {
+		b.handleCommand(update.ChannelPost)
+		return
+	}
+	if update.Message == nil {
+		return
+	}
+	if update.Message.IsCommand() && b.handleCommand(update.Message) {
+		return
+	}
+	if b.skipCommand(update.Message) {
+		b.logger.Debug("Skipping command for another bot", "command", update.Message.CommandWithAt(), "channelID", update.Message.Chat.ID)
+		return
+	}
+	if b.ignoredReply(update.Message) {
+		return
+	}
+	if update.Message.From.ID == me.ID { // Ignore self
+		return
+	}
 
-			key := fmt.Sprintf("%s:%d", strings.TrimPrefix(userID, "user"), channelID)
-			count, err := b.redis.Get(ctx, key).Int()
-			if err != nil && err != redis.Nil {
-				b.logger.Error("Error retrieving count from Redis", "error", err)
-				continue
-			}
-			// b.logger.Debug("User message count", "userID", uid, "channelID", channelID, "count", count)
+	ctx := context.Background()
+	channelID := update.Message.Chat.ID
 
-			if count >= b.config.NewUserThreshold {
-				// b.logger.Debug("Skipping old user", "userID", uid, "channelID", channelID, "count", count)
-				continue
-			}
+	// Check admin rights for this chat
+	adminRights := b.checkAdminRights(channelID, me.ID)
+	b.logger.Debug("Bot admin status for chat", "chatID", channelID, "isAdmin", adminRights)
 
-			// Hash the message
-			messageHash := b.hashMessage(update.Message.Text)
-			b.logger.Debug("Message hash", "userID", uid, "channelID", channelID, "hash", messageHash)
+	if isServiceMessage(update.Message) {
+		if len(update.Message.NewChatMembers) > 0 {
+			b.handleNewMembers(ctx, update.Message, adminRights)
+		}
+		b.cleanServiceMessage(update.Message, adminRights)
+		return
+	}
+	if isVoiceMessage(update.Message) && b.config.VoicePolicy != VoicePolicyIgnore {
+		b.handleVoiceMessage(ctx, update.Message, adminRights)
+		return
+	}
 
-			// Check if the message hash is in the Redis cache
-			isSpam, err := b.isSpamMessage(ctx, messageHash)
-			if err != nil {
-				b.logger.Error("Error checking spam cache", "error", err)
-				continue
-			}
+	// Only process messages of type "message"
+	text := messageText(update.Message)
+	if text == "" {
+		return
+	}
+	uid := update.Message.From.ID
+	if update.Message.SenderChat != nil {
+		// Posted on behalf of a chat (linked channel or anonymous admin), there is no user to ban
+		uid = update.Message.SenderChat.ID
+		adminRights.CanRestrictMembers = false
+	} else if uid == channelID && !b.whitelistChannels[channelID] {
+		b.logger.Debug("Skipping self message", "userID", uid, "channelID", channelID)
+		b.reply(update.Message, "Sorry, it doesn't work this way. Add me to your channel as an admin.")
+		return
+	}
 
-			if isSpam {
-				// Immediately delete the message if it's in the spam cache
-				if adminRights.CanDeleteMessages {
-					deleteMsg := tgbotapi.NewDeleteMessage(channelID, update.Message.MessageID)
-					_, err := b.api.Request(deleteMsg)
-					if err != nil {
-						b.logger.Error("Failed to delete cached spam message", "error", err, "messageID", update.Message.MessageID)
-					} else {
-						b.logger.Info("Deleted cached spam message", "messageID", update.Message.MessageID, "userID", uid, "channelID", channelID)
-					}
-				}
-				continue
+	s, err := b.screen(ctx, update.Message, text, uid, false)
+	if err != nil {
+		b.logger.Error("Failed to screen message", "error", err, "userID", uid, "channelID", channelID)
+		return
+	}
+	if s.Skip != "" {
+		b.logger.Debug("Skipping message", "reason", s.Skip, "userID", uid, "channelID", channelID, "messageID", update.Message.MessageID)
+		return
+	}
+	if s.CostCapped {
+		metrics.CostCapSkipped.WithLabelValues(string(b.config.CostCapFallback)).Inc()
+	}
+	if s.Verdict != nil {
+		turn.wait()
+		b.logger.Info("Acting without classification", "label", s.Verdict.Label, "detail", s.Detail, "userID", uid, "channelID", channelID, "messageID", update.Message.MessageID, "score", s.Verdict.Score)
+		if s.CacheSpam {
+			if err := b.addSpamMessage(ctx, channelID, s.messageHash); err != nil {
+				b.logger.Error("Failed to add spam message to cache", "error", err)
 			}
-
-			// Check for spam
-			processed, err := b.checkForSpamWithRetry(update.Message.Text, 3, 100*time.Millisecond)
-			if err != nil {
-				b.logger.Error("Error checking for spam after retries", "error", err)
-				continue
+		}
+		b.enforce(update.Message, channelID, uid, adminRights, s.Verdict.Action, s.Verdict.Label, s.Verdict.Score, s.Verdict.Threshold)
+		observeDecision(received, true)
+		return
+	}
+	if s.Heuristics != "" {
+		turn.wait()
+		if _, verdict := b.heuristicVerdict(ctx, update.Message, uid, text, s); verdict.Action != "" {
+			b.enforce(update.Message, channelID, uid, adminRights, verdict.Action, verdict.Label, verdict.Score, verdict.Threshold)
+		} else if s.CountClean {
+			if err := b.redis.Incr(ctx, s.countKey).Err(); err != nil {
+				b.logger.Error("Error incrementing count in Redis", "error", err)
 			}
+		}
+		return
+	}
 
-			b.logger.Debug("Spam check result",
-				"userID", uid,
-				"channelID", channelID,
-				"spamScore", processed.SpamScore,
-				"reasoning", processed.Reasoning)
-
-			if processed.SpamScore <= b.config.Threshold {
-				// Increment the count for the user
-				_, err = b.redis.Incr(ctx, key).Result()
-				if err != nil {
-					b.logger.Error("Error incrementing count in Redis", "error", err)
-				}
-				if logChannelID, exists := b.config.LogChannels[channelID]; exists {
-					forwardMsg := tgbotapi.NewForward(logChannelID, channelID, update.Message.MessageID)
-					_, err := b.api.Send(forwardMsg)
-					if err != nil {
-						b.logger.Error("Failed to forward spam message to log channel", "error", err, "messageID", update.Message.MessageID, "logChannelID", logChannelID)
-					} else {
-						b.logger.Info("Forwarded non-spam message to log channel", "messageID", update.Message.MessageID, "userID", uid, "channelID", channelID, "logChannelID", logChannelID, "spamScore", processed.SpamScore)
-					}
-
-					// Send additional information to the log channel
-					logMessage := fmt.Sprintf("✅ New user check:\nUser ID: %d\nChannel ID: %d\nSpam Score: %.2f / %.2f \nReasoning: %s", uid, channelID, processed.SpamScore, b.config.Threshold, processed.Reasoning)
-					logMsg := tgbotapi.NewMessage(logChannelID, logMessage)
-					_, err = b.api.Send(logMsg)
-					if err != nil {
-						b.logger.Error("Failed to send log message to log channel", "error", err, "logChannelID", logChannelID)
-					}
-				}
-				continue
-			}
+	// Check for spam
+	prompt := ai.RenderPrompt(b.classificationText(update.Message), b.promptFor(ctx, update.Message, uid))
+	processed, response, err := b.checkForSpamWithRetry(prompt, 3, 100*time.Millisecond)
+	if err != nil {
+		b.logger.Error("Error checking for spam after retries", "error", err)
+		turn.wait()
+		b.handleClassificationError(update.Message, channelID, uid, adminRights, err)
+		return
+	}
+	b.recordInteraction(ctx, update.Message, prompt, response, processed)
+	b.sampleForEval(ctx, update.Message, text, processed)
+	primary, messageID, threshold := *processed, update.Message.MessageID, s.threshold
+	b.startShadowClassify(channelID, messageID, prompt, primary, threshold)
+	metrics.SpamScore.WithLabelValues(b.chatLabels.Label(channelID)).Observe(processed.SpamScore)
+	verdict := b.judge(ctx, update.Message, uid, text, s, processed)
+	b.storeDetails(ctx, update.Message, processed, s.threshold)
+
+	b.logger.Debug("Spam check result",
+		"userID", uid,
+		"channelID", channelID,
+		"spamScore", processed.SpamScore,
+		"reasoning", processed.Reasoning)
+
+	turn.wait() // Classified concurrently, but acted on in order
+	if verdict.Label != spamLabel {
+		if verdict.Action != "" {
+			b.recordClassification(ctx, update.Message, processed.Reasoning)
+			b.enforce(update.Message, channelID, uid, adminRights, verdict.Action, verdict.Label, verdict.Score, verdict.Threshold)
+			observeDecision(received, false)
+			return
+		}
 
-			// Add the message hash to the Redis spam cache
-			if err := b.addSpamMessage(ctx, messageHash); err != nil {
-				b.logger.Error("Failed to add spam message to cache", "error", err)
+		// Increment the count for the user
+		if err := b.redis.Incr(ctx, s.countKey).Err(); err != nil {
+			b.logger.Error("Error incrementing count in Redis", "error", err)
+		}
+		b.countThreadClean(ctx, channelID, s.thread, uid)
+		if logChannelID, exists := b.logChannel(channelID); exists {
+			if err := b.messenger.Forward(logChannelID, channelID, update.Message.MessageID); err != nil {
+				b.logger.Error("Failed to forward spam message to log channel", "error", err, "messageID", update.Message.MessageID, "logChannelID", logChannelID)
+			} else {
+				b.logger.Info("Forwarded non-spam message to log channel", "messageID", update.Message.MessageID, "userID", uid, "channelID", channelID, "logChannelID", logChannelID, "spamScore", processed.SpamScore)
 			}
 
-			b.handleSpamMessage(update.Message, channelID, int64(uid), adminRights, processed.SpamScore)
+			// Send additional information to the log channel, the reasoning is behind the details button if enabled
+			if b.config.NotificationDetailsTTL > 0 {
+				logMessage := fmt.Sprintf("✅ New user check:\nUser ID: %d\nChannel ID: %d\nSpam Score: %.2f / %.2f", uid, channelID, processed.SpamScore, s.threshold)
+				b.sendLogWithDetails(logChannelID, logMessage, channelID, update.Message.MessageID)
+			} else {
+				logMessage := fmt.Sprintf("✅ New user check:\nUser ID: %d\nChannel ID: %d\nSpam Score: %.2f / %.2f \nReasoning: %s", uid, channelID, processed.SpamScore, s.threshold, processed.Reasoning)
+				b.sendLog(logChannelID, logMessage)
+			}
 		}
+		observeDecision(received, false)
+		return
 	}
-}
-
-func (b *Bot) hashMessage(message string) string {
-	hash := sha256.Sum256([]byte(message))
-	return hex.EncodeToString(hash[:])
-}
 
-func (b *Bot) isSpamMessage(ctx context.Context, hash string) (bool, error) {
-	exists, err := b.redis.Exists(ctx, "spam:"+hash).Result()
-	if err != nil {
-		return false, err
+	// Add the message hash to the Redis spam cache
+	if err := b.addSpamMessage(ctx, channelID, s.messageHash); err != nil {
+		b.logger.Error("Failed to add spam message to cache", "error", err)
 	}
-	return exists == 1, nil
-}
+	b.storeSpamExample(ctx, channelID, s.messageHash, text, false)
+	b.addSpamEmbedding(ctx, s.messageHash, s.embedding)
 
-func (b *Bot) addSpamMessage(ctx context.Context, hash string) error {
-	// Store the hash with an expiration time (e.g., 24 hours)
-	return b.redis.Set(ctx, "spam:"+hash, 1, 24*7*time.Hour).Err()
+	b.recordClassification(ctx, update.Message, processed.Reasoning)
+	b.handleSpamMessage(update.Message, channelID, uid, adminRights, processed.SpamScore, s.threshold)
+	observeDecision(received, false)
 }
 
-func (b *Bot) handleSpamMessage(message *tgbotapi.Message, channelID, userID int64, adminRights AdminRights, spamScore float64) {
-	// Forward the message to the log channel
-	if logChannelID, exists := b.config.LogChannels[channelID]; exists {
-		forwardMsg := tgbotapi.NewForward(logChannelID, channelID, message.MessageID)
-		_, err := b.api.Send(forwardMsg)
-		if err != nil {
-			b.logger.Error("Failed to forward spam message to log channel", "error", err, "messageID", message.MessageID, "logChannelID", logChannelID)
-		} else {
-			b.logger.Info("Forwarded spam message to log channel", "messageID", message.MessageID, "userID", userID, "channelID", channelID, "logChannelID", logChannelID)
-		}
+// observeDecision records the time from receiving a message to the decision made on it
+func observeDecision(received time.Time, cacheHit bool) {
+	cache := "miss"
+	if cacheHit {
+		cache = "hit"
 	}
+	metrics.DecisionLatency.WithLabelValues(cache).Observe(time.Since(received).Seconds())
+}
 
-	action := "👻 Spam detected and logged"
-	if adminRights.CanDeleteMessages {
-		action = "🤡 Spam detected and deleted"
-		deleteMsg := tgbotapi.NewDeleteMessage(channelID, message.MessageID)
-		_, err := b.api.Request(deleteMsg)
-		if err != nil {
-			b.logger.Error("Failed to delete spam message", "error", err, "messageID", message.MessageID)
-		} else {
-			b.logger.Info("Deleted spam message", "messageID", message.MessageID, "userID", userID, "channelID", channelID)
-		}
+// scanWindow returns the message count below which users are scanned by the AI,
+// defaulting to the chat's new user threshold
+func (b *Bot) scanWindow(newUserThreshold int) int {
+	if b.config.ScanWindow > 0 {
+		return b.config.ScanWindow
 	}
+	return newUserThreshold
+}
 
-	if adminRights.CanRestrictMembers {
-		action += "\n👩‍⚖️User banned"
-		restrictConfig := tgbotapi.RestrictChatMemberConfig{
-			ChatMemberConfig: tgbotapi.ChatMemberConfig{
-				ChatID: channelID,
-				UserID: userID,
-			},
-		}
-		_, err := b.api.Request(restrictConfig)
-		if err != nil {
-			b.logger.Error("Failed to restrict user", "error", err, "userID", userID, "channelID", channelID)
-		} else {
-			b.logger.Info("Restricted user", "userID", userID, "channelID", channelID)
-		}
-	}
+// key builds a Redis key in the bot's namespace
+func (b *Bot) key(format string, args ...any) string {
+	return b.config.RedisPrefix + fmt.Sprintf(format, args...)
+}
 
-	if logChannelID, exists := b.config.LogChannels[channelID]; exists {
-		// Send additional information to the log channel
-		logMessage := fmt.Sprintf(action+"\nUser ID: %d\nChannel ID: %d\nSpam Score: %.2f/%.2f", userID, channelID, spamScore, b.config.Threshold)
-		logMsg := tgbotapi.NewMessage(logChannelID, logMessage)
-		_, err := b.api.Send(logMsg)
-		if err != nil {
-			b.logger.Error("Failed to send log message to log channel", "error", err, "logChannelID", logChannelID)
-		}
-	}
+func (b *Bot) hashMessage(message string) string {
+	hash := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(hash[:])
+}
+
+func (b *Bot) handleSpamMessage(message *tgbotapi.Message, channelID, userID int64, adminRights AdminRights, spamScore, threshold float64) {
+	b.enforce(message, channelID, userID, adminRights, ActionBan, spamLabel, spamScore, threshold)
 }
 
 type AdminRights struct {
@@ -292,13 +559,7 @@ func (b *Bot) checkAdminRights(chatID int64, botID int64) AdminRights {
 	// If not in cache, fetch the admin rights
 	adminRights := AdminRights{}
 
-	me, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
-		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
-			ChatID: chatID,
-			UserID: botID,
-		},
-	})
-
+	me, err := b.messenger.Member(chatID, botID)
 	if err != nil {
 		b.logger.Error("Error getting chat member", "error", err, "chatID", chatID, "botID", botID)
 		return adminRights
@@ -309,6 +570,7 @@ func (b *Bot) checkAdminRights(chatID int64, botID int64) AdminRights {
 
 	// Update the cache
 	b.adminCache[chatID] = adminRights
+	b.checkModerationRestored(chatID, adminRights)
 
 	return adminRights
 }
@@ -327,27 +589,72 @@ func (b *Bot) clearAdminCacheRoutine() {
 	}
 }
 
+func (b *Bot) clearAdminCacheEntry(chatID int64) {
+	b.cacheMutex.Lock()
+	defer b.cacheMutex.Unlock()
+	delete(b.adminCache, chatID)
+}
+
 func (b *Bot) clearAdminCache() {
 	b.cacheMutex.Lock()
 	defer b.cacheMutex.Unlock()
 	b.adminCache = make(map[int64]AdminRights)
 }
 
+// Stop stops receiving updates once the current poll returns; the shared Redis client is closed by the caller
 func (b *Bot) Stop() {
 	close(b.stopChan)
-	b.redis.Close()
 }
 
-func (b *Bot) checkForSpamWithRetry(text string, maxRetries int, retryDelay time.Duration) (*ai.Result, error) {
+func (b *Bot) checkForSpamWithRetry(prompt string, maxRetries int, retryDelay time.Duration) (result *ai.Result, response string, err error) {
+	ctx, span := tracer.Start(context.Background(), "classify")
+	defer func() {
+		if result != nil {
+			span.SetAttributes(attribute.Float64("spam_score", result.SpamScore))
+		}
+		endSpan(span, err)
+	}()
+
 	var lastErr error
 	for i := 0; i < maxRetries; i++ {
-		processed, err := ai.ProcessRecord(text, b.config.Prompt, b.aiprovider)
+		span.SetAttributes(attribute.Int("attempts", i+1))
+		response, err := b.aiprovider.ProcessMessage(ctx, prompt)
 		if err == nil {
-			return &processed, nil
+			b.recordCost(ctx, prompt, response)
+			var processed ai.Result
+			processed, err = ai.ParseResponse(response)
+			if err == nil {
+				return &processed, response, nil
+			}
 		}
 		lastErr = err
+		if !ai.IsRetryable(err) {
+			b.logger.Warn("Spam check failed, not retrying", "attempt", i+1, "error", err)
+			break
+		}
 		b.logger.Warn("Spam check failed, retrying", "attempt", i+1, "error", err)
 		time.Sleep(retryDelay)
 	}
-	return nil, fmt.Errorf("failed to check for spam after %d attempts: %w", maxRetries, lastErr)
+	return nil, "", fmt.Errorf("failed to check for spam: %w", lastErr)
+}
+
+// recordInteraction stores the rendered prompt and raw response when the debug store is enabled
+func (b *Bot) recordInteraction(ctx context.Context, message *tgbotapi.Message, prompt, response string, processed *ai.Result) {
+	if b.debugStore == nil {
+		return
+	}
+	record := debugstore.Record{
+		ID:        debugstore.RecordID(message.Chat.ID, message.MessageID),
+		ChatID:    message.Chat.ID,
+		UserID:    message.From.ID,
+		MessageID: message.MessageID,
+		Model:     b.config.BuildInfo.Model,
+		Prompt:    prompt,
+		Response:  response,
+		SpamScore: processed.SpamScore,
+		CreatedAt: time.Now(),
+	}
+	if err := b.debugStore.Save(ctx, record); err != nil {
+		b.logger.Error("Failed to save debug record", "error", err, "id", record.ID)
+	}
 }
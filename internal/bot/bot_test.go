@@ -0,0 +1,280 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+const (
+	testBotID     = 1000
+	testChatID    = -1001
+	testLogChatID = -2001
+	testUserID    = 42
+)
+
+// fakeMessenger records the bot's actions instead of performing them
+type fakeMessenger struct {
+	mutex       sync.Mutex
+	member      ChatMember // Returned for every Member lookup
+	admins      []Administrator
+	bios        map[int64]string // Returned by Bio if set, which fails for users missing from it
+	deleteErr   error            // Returned by Delete if set
+	bulkErr     error            // Returned by DeleteMany if set
+	restrictErr error            // Returned by Restrict if set
+	failures    int              // Calls failing with deleteErr or restrictErr before they succeed, every call if zero
+	lingering   map[int]int      // Deletes of each listed message succeeding without removing it; the next removes it, later ones report it gone
+	sent        []OutgoingMessage
+	forwarded   []int
+	deleted     []int
+	deleteCalls int // Delete calls, including failed ones
+	bulk        [][]int
+	restricted  []int64
+	lifted      []int64
+	nextID      int
+}
+
+func (m *fakeMessenger) Send(message OutgoingMessage) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sent = append(m.sent, message)
+	m.nextID++
+	return 5000 + m.nextID, nil
+}
+
+func (m *fakeMessenger) Forward(toChatID, fromChatID int64, messageID int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.forwarded = append(m.forwarded, messageID)
+	return nil
+}
+
+func (m *fakeMessenger) Edit(chatID int64, messageID int, text string) error { return nil }
+
+func (m *fakeMessenger) Delete(chatID int64, messageID int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.deleteCalls++
+	if err := m.fail(m.deleteErr); err != nil {
+		return err
+	}
+	if left, ok := m.lingering[messageID]; ok {
+		if left < 0 {
+			return errors.New("Bad Request: message to delete not found")
+		}
+		m.lingering[messageID] = left - 1 // Removed once it drops below zero
+	}
+	m.deleted = append(m.deleted, messageID)
+	return nil
+}
+
+func (m *fakeMessenger) DeleteMany(chatID int64, messageIDs []int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.bulkErr != nil {
+		return m.bulkErr
+	}
+	m.bulk = append(m.bulk, append([]int(nil), messageIDs...))
+	return nil
+}
+
+func (m *fakeMessenger) Restrict(chatID, userID int64, until time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.fail(m.restrictErr); err != nil {
+		return err
+	}
+	m.restricted = append(m.restricted, userID)
+	return nil
+}
+
+// fail returns err for calls that should fail, counting them down if failures is set
+func (m *fakeMessenger) fail(err error) error {
+	if err == nil {
+		return nil
+	}
+	if m.failures == 0 {
+		return err
+	}
+	m.failures--
+	if m.failures == 0 {
+		m.deleteErr, m.restrictErr = nil, nil
+	}
+	return err
+}
+
+func (m *fakeMessenger) Unrestrict(chatID, userID int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.lifted = append(m.lifted, userID)
+	return nil
+}
+
+func (m *fakeMessenger) DeclineJoinRequest(chatID, userID int64) error { return nil }
+func (m *fakeMessenger) AnswerCallback(callbackID, text string) error  { return nil }
+func (m *fakeMessenger) Bio(userID int64) (string, error) {
+	if m.bios == nil {
+		return "", nil
+	}
+	bio, ok := m.bios[userID]
+	if !ok {
+		return "", errors.New("Bad Request: chat not found")
+	}
+	return bio, nil
+}
+
+func (m *fakeMessenger) SetAdminCommands(commands []Command) error { return nil }
+
+func (m *fakeMessenger) Member(chatID, userID int64) (ChatMember, error) {
+	return m.member, nil
+}
+
+func (m *fakeMessenger) Administrators(chatID int64) ([]Administrator, error) {
+	return m.admins, nil
+}
+
+// sentTexts returns the texts of the messages sent so far
+func (m *fakeMessenger) sentTexts() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	texts := make([]string, len(m.sent))
+	for i, message := range m.sent {
+		texts[i] = message.Text
+	}
+	return texts
+}
+
+// countingProvider returns a fixed classification and counts its calls
+type countingProvider struct {
+	mutex    sync.Mutex
+	response string
+	calls    int
+}
+
+func (p *countingProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.calls++
+	return p.response, nil
+}
+
+func (p *countingProvider) callCount() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.calls
+}
+
+// testConfig returns the configuration of a bot moderating testChatID and logging to testLogChatID
+func testConfig() *Config {
+	return &Config{
+		Threshold:        0.5,
+		NewUserThreshold: 1,
+		LogChannels:      map[int64]int64{testChatID: testLogChatID},
+		ActionPolicy:     ActionPolicyBan,
+		ReplyPolicy:      ReplyPolicySkipAdmin,
+		SenderChatPolicy: SenderChatPolicyIgnore,
+		ScanFilter:       ScanFilterAll,
+		OnError:          ErrorPolicyIgnore,
+		CostCapFallback:  CostCapFallbackOff,
+		MuteDuration:     time.Hour,
+		Locale:           "en",
+	}
+}
+
+// newTestBot returns a bot backed by an in-memory Redis, acting through a fake
+// messenger with full admin rights and classifying with provider
+func newTestBot(t *testing.T, config *Config, provider ai.Provider) (*Bot, *fakeMessenger, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	messenger := &fakeMessenger{member: ChatMember{IsAdmin: true, CanDeleteMessages: true, CanRestrictMembers: true}}
+	api := &tgbotapi.BotAPI{Self: tgbotapi.User{ID: testBotID, IsBot: true, UserName: "giraffe_bot"}, Buffer: 10}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return newBot(logger, rdb, provider, config, api, messenger), messenger, server
+}
+
+// textMessage returns a message sent to testChatID by testUserID
+func textMessage(messageID int, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: messageID,
+		From:      &tgbotapi.User{ID: testUserID, FirstName: "Test"},
+		Chat:      &tgbotapi.Chat{ID: testChatID, Type: "supergroup"},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+}
+
+// handle processes a message update the way the update workers do
+func handle(b *Bot, message *tgbotapi.Message) {
+	b.handleUpdate(incomingUpdate{Update: tgbotapi.Update{Message: message}}, b.api.Self, time.Now(), nil)
+}
+
+func TestKnownSpamIsDeleted(t *testing.T) {
+	b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+	message := textMessage(10, "buy followers now")
+	if err := b.addSpamMessage(context.Background(), testChatID, b.hashMessage(message.Text)); err != nil {
+		t.Fatal(err)
+	}
+
+	handle(b, message)
+
+	if len(messenger.deleted) != 1 || messenger.deleted[0] != 10 {
+		t.Errorf("deleted = %v, want the cached spam message", messenger.deleted)
+	}
+	if len(messenger.restricted) != 0 {
+		t.Errorf("restricted = %v, cache hits only delete", messenger.restricted)
+	}
+	if len(messenger.sentTexts()) == 0 {
+		t.Error("the cache hit wasn't reported to the log channel")
+	}
+}
+
+func TestKnownSpamRespectsPausedModeration(t *testing.T) {
+	b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+	message := textMessage(10, "buy followers now")
+	if err := b.addSpamMessage(context.Background(), testChatID, b.hashMessage(message.Text)); err != nil {
+		t.Fatal(err)
+	}
+	// Rights still cached from before they were lost, fetching them again would resume moderation
+	b.adminCache[testChatID] = AdminRights{CanDeleteMessages: true, CanRestrictMembers: true}
+	b.moderation.paused[testChatID] = true
+
+	handle(b, message)
+
+	if len(messenger.deleted) != 0 {
+		t.Errorf("deleted = %v while moderation is paused", messenger.deleted)
+	}
+	if len(messenger.sentTexts()) == 0 {
+		t.Error("the cache hit wasn't reported while moderation is paused")
+	}
+}
+
+func TestKnownSpamDuringWarmup(t *testing.T) {
+	config := testConfig()
+	config.WarmupDuration = time.Hour
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+	b.startWarmup(ctx, testChatID)
+	message := textMessage(10, "buy followers now")
+	if err := b.addSpamMessage(ctx, testChatID, b.hashMessage(message.Text)); err != nil {
+		t.Fatal(err)
+	}
+
+	handle(b, message)
+
+	if len(messenger.deleted) != 0 {
+		t.Errorf("deleted = %v during warm-up", messenger.deleted)
+	}
+}
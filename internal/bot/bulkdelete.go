@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxBulkDelete is the most message IDs Telegram's deleteMessages accepts per request
+const maxBulkDelete = 100
+
+// isUnknownMethod reports whether the Bot API server doesn't implement a method, as
+// older self-hosted servers don't implement deleteMessages
+func isUnknownMethod(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// deleteMessages deletes several messages of a chat, in bulk requests of up to
+// maxBulkDelete messages where the Bot API server supports them, and returns how many
+// were deleted. A bulk request skips messages that are already gone and counts them
+// as deleted, and isn't verified with DeleteVerifyAttempts. Messages of failed bulk
+// requests are deleted one by one, with retries and dead-lettering.
+func (b *Bot) deleteMessages(chatID int64, messageIDs []int) int {
+	deleted := 0
+	for start := 0; start < len(messageIDs); start += maxBulkDelete {
+		chunk := messageIDs[start:min(start+maxBulkDelete, len(messageIDs))]
+		if len(chunk) > 1 && !b.noBulkDelete.Load() {
+			err := b.messenger.DeleteMany(chatID, chunk)
+			b.recordActionResult(chatID, err)
+			if err == nil {
+				deleted += len(chunk)
+				continue
+			}
+			if isUnknownMethod(err) {
+				b.noBulkDelete.Store(true)
+				b.logger.Warn("Bulk deletes unsupported by the Bot API server, deleting messages one by one", "error", err)
+			} else {
+				b.logger.Warn("Failed to delete messages in bulk, deleting them one by one", "error", err, "chatID", chatID, "count", len(chunk))
+			}
+		}
+		for _, messageID := range chunk {
+			if err := b.deleteMessage(chatID, messageID); err != nil {
+				b.logger.Error("Failed to delete message", "error", err, "messageID", messageID, "chatID", chatID)
+				continue
+			}
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// deleteFlood deletes a banned user's other messages posted within FloodDeleteWindow,
+// returning how many were deleted
+func (b *Bot) deleteFlood(ctx context.Context, chatID, userID int64, messageID int) int {
+	ids, err := b.recentMessages(ctx, chatID, userID, b.config.FloodDeleteWindow)
+	if err != nil {
+		b.logger.Error("Failed to load recent messages", "error", err, "chatID", chatID, "userID", userID)
+		return 0
+	}
+	ids = slices.DeleteFunc(ids, func(id int) bool { return id == messageID })
+	if len(ids) == 0 {
+		return 0
+	}
+	deleted := b.deleteMessages(chatID, ids)
+	b.logger.Info("Deleted flood of banned user", "chatID", chatID, "userID", userID, "deleted", deleted, "messages", len(ids))
+	return deleted
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// messageIDs returns the IDs from first to first+count-1
+func messageIDs(first, count int) []int {
+	ids := make([]int, count)
+	for i := range ids {
+		ids[i] = first + i
+	}
+	return ids
+}
+
+func TestDeleteMessagesInBulk(t *testing.T) {
+	b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+
+	if deleted := b.deleteMessages(testChatID, messageIDs(1, 250)); deleted != 250 {
+		t.Errorf("deleted = %d, want 250", deleted)
+	}
+
+	if len(messenger.bulk) != 3 || len(messenger.bulk[0]) != 100 || len(messenger.bulk[1]) != 100 || len(messenger.bulk[2]) != 50 {
+		t.Errorf("bulk requests of %d messages, want 100, 100 and 50", bulkSizes(messenger))
+	}
+	if messenger.bulk[2][49] != 250 {
+		t.Errorf("last bulk request ends with %d, want 250", messenger.bulk[2][49])
+	}
+	if len(messenger.deleted) != 0 {
+		t.Errorf("deleted %v one by one", messenger.deleted)
+	}
+}
+
+func TestDeleteSingleMessageWithoutBulk(t *testing.T) {
+	b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+
+	if deleted := b.deleteMessages(testChatID, []int{7}); deleted != 1 {
+		t.Errorf("deleted = %d, want 1", deleted)
+	}
+	if len(messenger.bulk) != 0 || len(messenger.deleted) != 1 {
+		t.Errorf("bulk = %v, deleted = %v, want a single delete", messenger.bulk, messenger.deleted)
+	}
+}
+
+func TestDeleteMessagesFallsBackOnBulkFailure(t *testing.T) {
+	b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+	messenger.bulkErr = &tgbotapi.Error{Code: 500, Message: "Internal Server Error"}
+
+	if deleted := b.deleteMessages(testChatID, messageIDs(1, 120)); deleted != 120 {
+		t.Errorf("deleted = %d, want 120", deleted)
+	}
+	if len(messenger.deleted) != 120 {
+		t.Errorf("deleted %d messages one by one, want all 120", len(messenger.deleted))
+	}
+
+	// Bulk deletes are tried again after a transient failure
+	messenger.bulkErr = nil
+	b.deleteMessages(testChatID, messageIDs(200, 5))
+	if len(messenger.bulk) != 1 {
+		t.Errorf("bulk requests = %v, want bulk deletes used again", messenger.bulk)
+	}
+}
+
+func TestDeleteMessagesRemembersUnsupportedBulk(t *testing.T) {
+	b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+	messenger.bulkErr = &tgbotapi.Error{Code: 404, Message: "Not Found: method not found"}
+
+	if deleted := b.deleteMessages(testChatID, messageIDs(1, 3)); deleted != 3 {
+		t.Errorf("deleted = %d, want 3", deleted)
+	}
+
+	messenger.bulkErr = nil
+	b.deleteMessages(testChatID, messageIDs(10, 3))
+	if len(messenger.bulk) != 0 || len(messenger.deleted) != 6 {
+		t.Errorf("bulk = %v, deleted = %v, want every message deleted one by one once bulk deletes are unsupported", messenger.bulk, messenger.deleted)
+	}
+}
+
+func TestDeleteFloodOfBannedUser(t *testing.T) {
+	config := testConfig()
+	config.FloodDeleteWindow = 10 * time.Minute
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+	for id := 1; id <= 4; id++ {
+		b.trackMessage(ctx, textMessage(id, "promo"), testUserID)
+	}
+	old := textMessage(5, "promo")
+	old.Date = int(time.Now().Add(-time.Hour).Unix())
+	b.trackMessage(ctx, old, testUserID)
+
+	if deleted := b.deleteFlood(ctx, testChatID, testUserID, 4); deleted != 3 {
+		t.Errorf("deleted = %d, want the 3 other messages within the window", deleted)
+	}
+	if len(messenger.bulk) != 1 || len(messenger.bulk[0]) != 3 {
+		t.Errorf("bulk = %v, want messages 1 to 3 in one request", messenger.bulk)
+	}
+}
+
+func bulkSizes(messenger *fakeMessenger) []int {
+	sizes := make([]int, len(messenger.bulk))
+	for i, ids := range messenger.bulk {
+		sizes[i] = len(ids)
+	}
+	return sizes
+}
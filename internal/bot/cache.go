@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// spamCacheTTL is how long a known spam message hash is remembered
+const spamCacheTTL = 24 * 7 * time.Hour
+
+// cacheStats counts spam cache lookups since startup
+type cacheStats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func (b *Bot) spamCacheKey(hash string) string {
+	return b.key("spam:%s", hash)
+}
+
+// spamCacheChatKey is the set of cached hashes that were detected in a chat,
+// so the cache can be cleared for a single chat
+func (b *Bot) spamCacheChatKey(chatID int64) string {
+	return b.key("spam_hashes:%d", chatID)
+}
+
+func (b *Bot) isSpamMessage(ctx context.Context, hash string) (bool, error) {
+	exists, err := b.redis.Exists(ctx, b.spamCacheKey(hash)).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists == 1 {
+		b.cacheStats.hits.Add(1)
+	} else {
+		b.cacheStats.misses.Add(1)
+	}
+	return exists == 1, nil
+}
+
+func (b *Bot) addSpamMessage(ctx context.Context, chatID int64, hash string) error {
+	pipe := b.redis.TxPipeline()
+	pipe.Set(ctx, b.spamCacheKey(hash), 1, spamCacheTTL)
+	pipe.SAdd(ctx, b.spamCacheChatKey(chatID), hash)
+	pipe.Expire(ctx, b.spamCacheChatKey(chatID), spamCacheTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// removeSpamMessage forgets a message wrongly taken for spam, along with its embedding and examples
+func (b *Bot) removeSpamMessage(ctx context.Context, chatID int64, hash string) error {
+	pipe := b.redis.TxPipeline()
+	pipe.Del(ctx, b.spamCacheKey(hash))
+	pipe.SRem(ctx, b.spamCacheChatKey(chatID), hash)
+	_, err := pipe.Exec(ctx)
+	b.removeSpamEmbeddings(ctx, hash)
+	b.removeSpamExample(ctx, chatID, hash)
+	return err
+}
+
+// spamCacheSize counts the cached spam hashes in the bot's namespace
+func (b *Bot) spamCacheSize(ctx context.Context) (int, error) {
+	count := 0
+	iter := b.redis.Scan(ctx, 0, b.spamCacheKey("*"), 1000).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	return count, iter.Err()
+}
+
+// clearChatSpamCache drops the hashes detected in a chat from the spam cache,
+// keeping those that other chats detected as well
+func (b *Bot) clearChatSpamCache(ctx context.Context, chatID int64) (int, error) {
+	chatKey := b.spamCacheChatKey(chatID)
+	hashes, err := b.redis.SMembers(ctx, chatKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	shared := make(map[string]bool)
+	if len(hashes) > 0 {
+		members := make([]any, len(hashes))
+		for i, hash := range hashes {
+			members[i] = hash
+		}
+		iter := b.redis.Scan(ctx, 0, b.key("spam_hashes:*"), 1000).Iterator()
+		for iter.Next(ctx) {
+			if iter.Val() == chatKey {
+				continue
+			}
+			found, err := b.redis.SMIsMember(ctx, iter.Val(), members...).Result()
+			if err != nil {
+				return 0, err
+			}
+			for i, member := range found {
+				if member {
+					shared[hashes[i]] = true
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	keys := make([]string, 0, len(hashes)+1)
+	var removed []string
+	for _, hash := range hashes {
+		if !shared[hash] {
+			keys = append(keys, b.spamCacheKey(hash))
+			removed = append(removed, hash)
+		}
+	}
+	keys = append(keys, chatKey)
+	if err := b.redis.Del(ctx, keys...).Err(); err != nil {
+		return 0, err
+	}
+	b.removeSpamEmbeddings(ctx, removed...)
+	return len(removed), nil
+}
+
+// clearSpamCache drops every cached spam hash and spam embedding in the bot's namespace
+func (b *Bot) clearSpamCache(ctx context.Context) (int, error) {
+	if err := b.clearSpamEmbeddings(ctx); err != nil {
+		return 0, err
+	}
+	cleared := 0
+	for _, pattern := range []string{b.spamCacheKey("*"), b.key("spam_hashes:*")} {
+		iter := b.redis.Scan(ctx, 0, pattern, 1000).Iterator()
+		for iter.Next(ctx) {
+			if err := b.redis.Del(ctx, iter.Val()).Err(); err != nil {
+				return cleared, err
+			}
+			if strings.HasPrefix(iter.Val(), b.key("spam:")) {
+				cleared++
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return cleared, err
+		}
+	}
+	return cleared, nil
+}
+
+// handleCacheCommand shows or clears the spam cache: /cache stats|clear [all]
+func (b *Bot) handleCacheCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		args = []string{"stats"}
+	}
+
+	switch {
+	case args[0] == "stats":
+		size, err := b.spamCacheSize(ctx)
+		if err != nil {
+			b.logger.Error("Failed to count spam cache", "error", err)
+			b.reply(message, "Failed to read the spam cache")
+			return
+		}
+		b.reply(message, fmt.Sprintf("Spam cache: %d entries\nHits: %d\nMisses: %d",
+			size, b.cacheStats.hits.Load(), b.cacheStats.misses.Load()))
+	case args[0] == "clear" && len(args) == 1:
+		cleared, err := b.clearChatSpamCache(ctx, message.Chat.ID)
+		if err != nil {
+			b.logger.Error("Failed to clear chat spam cache", "error", err, "chatID", message.Chat.ID)
+			b.reply(message, "Failed to clear the spam cache")
+			return
+		}
+		b.logger.Info("Cleared chat spam cache", "chatID", message.Chat.ID, "entries", cleared, "admin", commandSender(message))
+		b.reply(message, fmt.Sprintf("Cleared %d cached spam messages from this chat", cleared))
+	case args[0] == "clear" && len(args) == 2 && args[1] == "all":
+		// The cache is shared by every chat, so only super-admins may drop all of it
+		if !b.hasRole(message, roleSuperAdmin) {
+			b.reply(message, "Only super-admins can clear the whole spam cache")
+			return
+		}
+		cleared, err := b.clearSpamCache(ctx)
+		if err != nil {
+			b.logger.Error("Failed to clear spam cache", "error", err)
+			b.reply(message, "Failed to clear the spam cache")
+			return
+		}
+		b.logger.Info("Cleared spam cache", "entries", cleared, "admin", commandSender(message))
+		b.reply(message, fmt.Sprintf("Cleared %d cached spam messages", cleared))
+	default:
+		b.reply(message, "Usage: /cache stats|clear [all]")
+	}
+}
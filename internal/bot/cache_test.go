@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClearChatSpamCacheKeepsSharedHashes(t *testing.T) {
+	b, _, server := newTestBot(t, testConfig(), &countingProvider{})
+	ctx := context.Background()
+	const otherChatID = -1002
+	for _, entry := range []struct {
+		chatID int64
+		hash   string
+	}{{testChatID, "own"}, {testChatID, "shared"}, {otherChatID, "shared"}, {otherChatID, "other"}} {
+		if err := b.addSpamMessage(ctx, entry.chatID, entry.hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleared, err := b.clearChatSpamCache(ctx, testChatID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cleared != 1 {
+		t.Errorf("clearChatSpamCache() = %d, want 1", cleared)
+	}
+	for key, want := range map[string]bool{
+		b.spamCacheKey("own"):           false,
+		b.spamCacheChatKey(testChatID):  false,
+		b.spamCacheKey("shared"):        true,
+		b.spamCacheKey("other"):         true,
+		b.spamCacheChatKey(otherChatID): true,
+	} {
+		if exists := server.Exists(key); exists != want {
+			t.Errorf("%s exists = %t, want %t", key, exists, want)
+		}
+	}
+	if members, _ := server.Members(b.spamCacheChatKey(otherChatID)); len(members) != 2 {
+		t.Errorf("other chat's hashes = %v, want them untouched", members)
+	}
+}
+
+func TestClearAllSpamCacheNeedsSuperAdmin(t *testing.T) {
+	config := testConfig()
+	config.SuperAdmins = []int64{testSuperAdminID}
+	b, messenger, server := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}, {ID: testSuperAdminID, Name: "Owner"}}
+	if err := b.addSpamMessage(context.Background(), -1002, "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/cache clear all"))
+	if !server.Exists(b.spamCacheKey("other")) {
+		t.Error("a chat admin cleared the whole spam cache")
+	}
+
+	b.handleCommand(commandMessage(testSuperAdminID, "/cache clear all"))
+	if server.Exists(b.spamCacheKey("other")) || server.Exists(b.spamCacheChatKey(-1002)) {
+		t.Error("a super-admin couldn't clear the whole spam cache")
+	}
+}
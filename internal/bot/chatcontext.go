@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	chatContextTTL          = 24 * time.Hour // Context older than this no longer describes the conversation
+	chatContextExtra        = 5              // Extra entries kept so concurrently processed messages don't crowd out context
+	chatContextMessageChars = 500            // Maximum length of a single context message
+)
+
+// contextEntry is a chat message kept for the conversation context of later messages
+type contextEntry struct {
+	MessageID int    `json:"message_id"`
+	SenderID  int64  `json:"sender_id"`
+	Text      string `json:"text"`
+}
+
+func (b *Bot) chatContextKey(chatID int64) string {
+	return b.key("chat_context:%d", chatID)
+}
+
+// pushChatContext appends a message to the chat's short ring buffer of recent messages
+func (b *Bot) pushChatContext(ctx context.Context, message *tgbotapi.Message, senderID int64, text string) {
+	if b.config.ContextMessages <= 0 {
+		return
+	}
+	data, err := json.Marshal(contextEntry{MessageID: message.MessageID, SenderID: senderID, Text: truncate(text, chatContextMessageChars)})
+	if err != nil {
+		b.logger.Error("Failed to encode chat context", "error", err)
+		return
+	}
+
+	key := b.chatContextKey(message.Chat.ID)
+	pipe := b.redis.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, int64(b.config.ContextMessages+chatContextExtra)-1)
+	pipe.Expire(ctx, key, chatContextTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to store chat context", "error", err, "chatID", message.Chat.ID)
+	}
+}
+
+// chatContext returns the messages preceding messageID in the chat, oldest
+// first, bounded by count and total length
+func (b *Bot) chatContext(ctx context.Context, chatID int64, messageID int) []string {
+	stored, err := b.redis.LRange(ctx, b.chatContextKey(chatID), 0, -1).Result()
+	if err != nil {
+		b.logger.Error("Failed to load chat context", "error", err, "chatID", chatID)
+		return nil
+	}
+
+	var messages []string
+	total := 0
+	for _, data := range stored {
+		var entry contextEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.MessageID >= messageID {
+			continue
+		}
+		line := fmt.Sprintf("User %d: %s", entry.SenderID, entry.Text)
+		if b.config.ContextMessagesChars > 0 && total+len(line) > b.config.ContextMessagesChars {
+			break
+		}
+		total += len(line)
+		messages = append(messages, line)
+		if len(messages) == b.config.ContextMessages {
+			break
+		}
+	}
+
+	// Stored newest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages
+}
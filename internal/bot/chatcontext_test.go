@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestChatContextRingBuffer(t *testing.T) {
+	config := testConfig()
+	config.ContextMessages = 3
+	b, _, server := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	for id := 1; id <= 12; id++ {
+		b.pushChatContext(ctx, textMessage(id, fmt.Sprintf("message %d", id)), testUserID, fmt.Sprintf("message %d", id))
+	}
+	key := b.chatContextKey(testChatID)
+	stored, err := server.List(key)
+	if err != nil {
+		t.Fatalf("List(%s): %v", key, err)
+	}
+	if len(stored) != config.ContextMessages+chatContextExtra {
+		t.Errorf("%d entries kept, want %d", len(stored), config.ContextMessages+chatContextExtra)
+	}
+	if ttl := server.TTL(key); ttl <= 0 || ttl > chatContextTTL {
+		t.Errorf("TTL = %v, want up to %v", ttl, chatContextTTL)
+	}
+
+	want := []string{"User 42: message 10", "User 42: message 11", "User 42: message 12"}
+	if got := b.chatContext(ctx, testChatID, 13); !reflect.DeepEqual(got, want) {
+		t.Errorf("chatContext() = %q, want the last %d messages oldest first %q", got, config.ContextMessages, want)
+	}
+	// A message processed late only sees what preceded it, of what is still kept
+	want = []string{"User 42: message 5", "User 42: message 6"}
+	if got := b.chatContext(ctx, testChatID, 7); !reflect.DeepEqual(got, want) {
+		t.Errorf("chatContext() before message 7 = %q, want %q", got, want)
+	}
+}
+
+func TestChatContextTruncatesMessages(t *testing.T) {
+	config := testConfig()
+	config.ContextMessages = 3
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	long := strings.Repeat("a", 2*chatContextMessageChars)
+	b.pushChatContext(ctx, textMessage(1, long), testUserID, long)
+	got := b.chatContext(ctx, testChatID, 2)
+	if len(got) != 1 || got[0] != "User 42: "+truncate(long, chatContextMessageChars) {
+		t.Errorf("chatContext() = %q, want the message truncated to %d characters", got, chatContextMessageChars)
+	}
+}
+
+func TestChatContextLengthBound(t *testing.T) {
+	config := testConfig()
+	config.ContextMessages = 10
+	config.ContextMessagesChars = 50 // Room for two lines of 20 characters
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	for id := 1; id <= 5; id++ {
+		b.pushChatContext(ctx, textMessage(id, "eleven char"), testUserID, "eleven char")
+	}
+	got := b.chatContext(ctx, testChatID, 6)
+	total := 0
+	for _, line := range got {
+		total += len(line)
+	}
+	if len(got) != 2 || total > config.ContextMessagesChars {
+		t.Errorf("chatContext() = %q, %d characters, want the 2 newest lines within %d", got, total, config.ContextMessagesChars)
+	}
+}
+
+func TestChatContextInjected(t *testing.T) {
+	config := testConfig()
+	config.ContextMessages = 2
+	config.Prompt = "{{CHAT_CONTEXT}}\nClassify {{CHANNEL_CONTENT}}"
+	provider := &promptRecorder{}
+	b, _, _ := newTestBot(t, config, provider)
+
+	for i, text := range []string{"first message", "second message", "third message", "is this a scam?"} {
+		message := textMessage(10+i, text)
+		message.From = &tgbotapi.User{ID: int64(43 + i), FirstName: "Member"} // Every sender is new and scanned
+		handle(b, message)
+	}
+	prompt := provider.last()
+	if !strings.Contains(prompt, "<chat_context>\nUser 44: second message\nUser 45: third message\n</chat_context>") {
+		t.Errorf("prompt = %q, want the 2 preceding messages in the chat context", prompt)
+	}
+	if strings.Contains(prompt, "first message") || strings.Count(prompt, "is this a scam?") != 1 {
+		t.Errorf("prompt = %q, want neither older messages nor the message itself in the context", prompt)
+	}
+	if strings.Contains(prompt, "{{CHAT_CONTEXT}}") {
+		t.Errorf("prompt = %q, placeholder left in", prompt)
+	}
+}
+
+func TestChatContextDisabled(t *testing.T) {
+	config := testConfig()
+	config.Prompt = "{{CHAT_CONTEXT}}\nClassify {{CHANNEL_CONTENT}}"
+	provider := &promptRecorder{}
+	b, _, server := newTestBot(t, config, provider)
+
+	handle(b, textMessage(10, "first message"))
+	second := textMessage(11, "second message")
+	second.From = &tgbotapi.User{ID: 43, FirstName: "Other"}
+	handle(b, second)
+	if prompt := provider.last(); strings.Contains(prompt, "chat_context") || strings.Contains(prompt, "{{CHAT_CONTEXT}}") {
+		t.Errorf("prompt = %q, want no chat context and the placeholder stripped", prompt)
+	}
+	if server.Exists(b.chatContextKey(testChatID)) {
+		t.Error("chat context stored while disabled")
+	}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// maxChatPromptChars bounds a chat's prompt override, keeping classification costs predictable
+const maxChatPromptChars = 4000
+
+func (b *Bot) chatPromptKey(chatID int64) string {
+	return b.key("prompt:%d", chatID)
+}
+
+// chatPrompt returns the prompt template of a chat: its override set with /setprompt, else the global prompt
+func (b *Bot) chatPrompt(ctx context.Context, chatID int64) string {
+	prompt, err := b.redis.Get(ctx, b.chatPromptKey(chatID)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get chat prompt", "error", err, "chatID", chatID)
+		}
+		return b.config.Prompt
+	}
+	return prompt
+}
+
+// handleSetPromptCommand overrides the chat's prompt with the text after the command
+// or of the replied message once it passes the prompt checks: /setprompt <prompt>
+func (b *Bot) handleSetPromptCommand(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	prompt := strings.TrimSpace(message.CommandArguments())
+	if prompt == "" && message.ReplyToMessage != nil {
+		prompt = strings.TrimSpace(message.ReplyToMessage.Text)
+	}
+	if prompt == "" {
+		b.reply(message, "Usage: /setprompt <prompt>, or reply /setprompt to a message with the prompt. The prompt must contain "+ai.MessagePlaceholder+"; /resetprompt goes back to the global prompt")
+		return
+	}
+	if length := utf8.RuneCountInString(prompt); length > maxChatPromptChars {
+		b.reply(message, fmt.Sprintf("The prompt is too long: %d characters, at most %d", length, maxChatPromptChars))
+		return
+	}
+	if problems := ai.LintPrompt(prompt, b.config.ForceJSON); len(problems) > 0 {
+		b.reply(message, "The prompt was not saved:\n- "+strings.Join(problems, "\n- "))
+		return
+	}
+
+	if err := b.redis.Set(context.Background(), b.chatPromptKey(chatID), prompt, 0).Err(); err != nil {
+		b.logger.Error("Failed to set chat prompt", "error", err, "chatID", chatID)
+		b.reply(message, "Failed to save the prompt")
+		return
+	}
+	b.logger.Info("Set chat prompt", "chatID", chatID, "chars", utf8.RuneCountInString(prompt), "admin", commandSender(message))
+	b.reply(message, "📝 The chat's prompt is set, messages here are classified with it from now on")
+}
+
+// handleResetPromptCommand drops the chat's prompt override: /resetprompt
+func (b *Bot) handleResetPromptCommand(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	if err := b.redis.Del(context.Background(), b.chatPromptKey(chatID)).Err(); err != nil {
+		b.logger.Error("Failed to reset chat prompt", "error", err, "chatID", chatID)
+		b.reply(message, "Failed to reset the prompt")
+		return
+	}
+	b.logger.Info("Reset chat prompt", "chatID", chatID, "admin", commandSender(message))
+	b.reply(message, "The chat uses the global prompt again")
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const chatPromptOverride = "Chat rules: no crypto. Answer with <reasoning> and <json> holding a spam_score for {{CHANNEL_CONTENT}}"
+
+// chatPromptBot returns a bot with testSuperAdminID as super-admin and admin of the chat
+func chatPromptBot(t *testing.T, config *Config, provider *promptRecorder) (*Bot, *fakeMessenger, *miniredis.Miniredis) {
+	t.Helper()
+	config.SuperAdmins = []int64{testSuperAdminID}
+	b, messenger, server := newTestBot(t, config, provider)
+	messenger.admins = []Administrator{{ID: testSuperAdminID, Name: "Owner"}}
+	return b, messenger, server
+}
+
+// promptRecorder flags nothing and keeps the prompts it was called with
+type promptRecorder struct {
+	mutex   sync.Mutex
+	prompts []string
+}
+
+func (p *promptRecorder) ProcessMessage(ctx context.Context, message string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.prompts = append(p.prompts, message)
+	return `{"reasoning": "fine", "spam_score": 0}`, nil
+}
+
+func (p *promptRecorder) last() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.prompts) == 0 {
+		return ""
+	}
+	return p.prompts[len(p.prompts)-1]
+}
+
+func TestChatPromptOverride(t *testing.T) {
+	config := testConfig()
+	config.Prompt = "Global prompt for {{CHANNEL_CONTENT}}"
+	provider := &promptRecorder{}
+	b, _, _ := chatPromptBot(t, config, provider)
+
+	b.handleCommand(commandMessage(testSuperAdminID, "/setprompt "+chatPromptOverride))
+	handle(b, textMessage(10, "hello everyone"))
+	if prompt := provider.last(); !strings.HasPrefix(prompt, "Chat rules: no crypto.") || !strings.Contains(prompt, "hello everyone") {
+		t.Errorf("prompt = %q, want the chat's override with the message", prompt)
+	}
+
+	b.handleCommand(commandMessage(testSuperAdminID, "/resetprompt"))
+	second := textMessage(11, "hello again")
+	second.From = &tgbotapi.User{ID: 43, FirstName: "Other"} // The first user isn't new any more
+	handle(b, second)
+	if prompt := provider.last(); prompt != "Global prompt for hello again" {
+		t.Errorf("prompt = %q, want the global prompt after /resetprompt", prompt)
+	}
+}
+
+func TestSetPromptValidates(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		reply  string
+	}{
+		{"no message placeholder", "Answer with <reasoning> and <json> holding a spam_score", "The prompt was not saved"},
+		{"no spam score", "Classify {{CHANNEL_CONTENT}} in <reasoning> and <json>", "does not ask for a spam_score"},
+		{"too long", chatPromptOverride + strings.Repeat(" padding", maxChatPromptChars/8), "The prompt is too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, messenger, server := chatPromptBot(t, testConfig(), &promptRecorder{})
+
+			b.handleCommand(commandMessage(testSuperAdminID, "/setprompt "+tt.prompt))
+
+			if server.Exists(b.chatPromptKey(testChatID)) {
+				t.Error("an invalid prompt was saved")
+			}
+			if reply := lastReply(messenger); !strings.Contains(reply, tt.reply) {
+				t.Errorf("reply = %q, want it to contain %q", reply, tt.reply)
+			}
+		})
+	}
+}
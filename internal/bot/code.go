@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isCodeEntity reports whether an entity marks inline code or a code block
+func isCodeEntity(entity tgbotapi.MessageEntity) bool {
+	return entity.Type == "pre" || entity.Type == "code"
+}
+
+// messageEntities returns the entities matching the text returned by messageText
+func messageEntities(message *tgbotapi.Message) []tgbotapi.MessageEntity {
+	if message.Poll != nil {
+		return nil
+	}
+	if message.Text == "" {
+		return message.CaptionEntities
+	}
+	return message.Entities
+}
+
+// hasCode reports whether a message contains inline code or code blocks
+func hasCode(message *tgbotapi.Message) bool {
+	for _, entity := range messageEntities(message) {
+		if isCodeEntity(entity) {
+			return true
+		}
+	}
+	return false
+}
+
+// insideCode reports whether an entity lies within one of the code entities
+func insideCode(entity tgbotapi.MessageEntity, entities []tgbotapi.MessageEntity) bool {
+	for _, code := range entities {
+		if isCodeEntity(code) && entity.Offset >= code.Offset && entity.Offset+entity.Length <= code.Offset+code.Length {
+			return true
+		}
+	}
+	return false
+}
+
+// textWithoutCode returns the message text with the content of code entities removed
+func textWithoutCode(message *tgbotapi.Message) string {
+	text := messageText(message)
+	if !hasCode(message) {
+		return text
+	}
+	return rewriteEntities(text, messageEntities(message), isCodeEntity, "")
+}
+
+// rewriteEntities replaces the content of matching entities with replacement, once
+// per run of adjacent entities; an empty replacement removes them.
+// Entity offsets count UTF-16 code units, so the text is cut in that encoding.
+func rewriteEntities(text string, entities []tgbotapi.MessageEntity, match func(tgbotapi.MessageEntity) bool, replacement string) string {
+	units := utf16.Encode([]rune(text))
+	covered := make([]bool, len(units))
+	matched := false
+	for _, entity := range entities {
+		if !match(entity) {
+			continue
+		}
+		matched = true
+		for i := max(entity.Offset, 0); i < entity.Offset+entity.Length && i < len(units); i++ {
+			covered[i] = true
+		}
+	}
+	if !matched {
+		return text
+	}
+
+	replacementUnits := utf16.Encode([]rune(replacement))
+	kept := make([]uint16, 0, len(units))
+	for i, unit := range units {
+		switch {
+		case !covered[i]:
+			kept = append(kept, unit)
+		case i == 0 || !covered[i-1]:
+			kept = append(kept, replacementUnits...)
+		}
+	}
+	return string(utf16.Decode(kept))
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// adminCommands lists the commands shown to chat administrators in the Telegram menu
+var adminCommands = []Command{
+	{Name: "version", Description: "Show the running build and its configuration"},
+	{Name: "retryfailed", Description: "Retry deletes and bans that failed in this chat"},
+	{Name: "purge", Description: "Reply to a message to delete the user's recent messages and ban them"},
+	{Name: "cache", Description: "Show spam cache stats or clear it: /cache stats|clear [all]"},
+	{Name: "policy", Description: "Show or set the chat's action policy: delete-only, mute or ban"},
+	{Name: "report", Description: "Reply to a message to report its sender with the collected evidence"},
+	{Name: "threshold", Description: "Show, set or undo the chat's spam threshold"},
+	{Name: "band", Description: "Show or set how close to the threshold scores are left for review: /band 0.1|off|reset"},
+	{Name: "newuser", Description: "Show or set how many clean messages make a user established: /newuser 5|reset"},
+	{Name: "spam", Description: "Reply to a missed spam message to report it"},
+	{Name: "notspam", Description: "Reply to a wrongly flagged message to report it"},
+	{Name: "simulate", Description: "Reply to a message to see what the bot would do with it"},
+	{Name: "why", Description: "Reply to a notification, or pass a message ID or link, to see why the bot acted on it"},
+	{Name: "trust", Description: "Reply to a user's message to stop scanning them and lift their restrictions"},
+	{Name: "untrust", Description: "Reply to a trusted user's message to scan them again"},
+	{Name: "forget", Description: "Reply to a user's message to reset what the bot tracks about them"},
+	{Name: "block", Description: "Block a phrase or /regexp/ in this chat"},
+	{Name: "unblock", Description: "Remove an entry from the chat's blocklist"},
+	{Name: "blocklist", Description: "List the chat's blocked phrases"},
+	{Name: "languages", Description: "Show or set the languages expected in this chat: /languages en ru|off"},
+	{Name: "signals", Description: "Show or tune the email and mention signals of this chat: /signals email|mentions <boost|off|default>"},
+	{Name: "locale", Description: "Show or set the language of the bot's replies and notifications: /locale en|ru|reset"},
+	{Name: "raid", Description: "Tighten scoring during a spam raid: /raid on [duration]|off"},
+}
+
+// registerCommands publishes the command menu, scoped to chat administrators
+func (b *Bot) registerCommands() error {
+	return b.messenger.SetAdminCommands(adminCommands)
+}
+
+// commandRole is who may use a command
+type commandRole int
+
+const (
+	roleAdmin      commandRole = iota // Admins of the chat the command is sent in
+	roleSuperAdmin                    // The configured super-admins, in any chat
+)
+
+// commandHandler handles a command and names the role required to use it
+type commandHandler struct {
+	role   commandRole
+	handle func(b *Bot, message *tgbotapi.Message)
+}
+
+// commandHandlers maps command names to their handlers.
+// Commands from senders without the required role are consumed without a reply.
+var commandHandlers = map[string]commandHandler{
+	"version":     {roleAdmin, (*Bot).handleVersionCommand},
+	"retryfailed": {roleAdmin, (*Bot).handleRetryFailedCommand},
+	"purge":       {roleAdmin, (*Bot).handlePurge},
+	"cache":       {roleAdmin, (*Bot).handleCacheCommand},
+	"policy":      {roleAdmin, (*Bot).handlePolicyCommand},
+	"report":      {roleAdmin, (*Bot).handleReportCommand},
+	"threshold":   {roleAdmin, (*Bot).handleThresholdCommand},
+	"band":        {roleAdmin, (*Bot).handleBandCommand},
+	"newuser":     {roleAdmin, (*Bot).handleNewUserCommand},
+	"spam":        {roleAdmin, func(b *Bot, message *tgbotapi.Message) { b.handleFeedbackCommand(message, true) }},
+	"notspam":     {roleAdmin, func(b *Bot, message *tgbotapi.Message) { b.handleFeedbackCommand(message, false) }},
+	"simulate":    {roleAdmin, (*Bot).handleSimulateCommand},
+	"why":         {roleAdmin, (*Bot).handleWhyCommand},
+	"trust":       {roleAdmin, func(b *Bot, message *tgbotapi.Message) { b.handleTrustCommand(message, true) }},
+	"untrust":     {roleAdmin, func(b *Bot, message *tgbotapi.Message) { b.handleTrustCommand(message, false) }},
+	"forget":      {roleAdmin, (*Bot).handleForgetCommand},
+	"block":       {roleAdmin, (*Bot).handleBlockCommand},
+	"unblock":     {roleAdmin, (*Bot).handleUnblockCommand},
+	"blocklist":   {roleAdmin, (*Bot).handleBlocklistCommand},
+	"languages":   {roleAdmin, (*Bot).handleLanguagesCommand},
+	"signals":     {roleAdmin, (*Bot).handleSignalsCommand},
+	"locale":      {roleAdmin, (*Bot).handleLocaleCommand},
+	"raid":        {roleAdmin, (*Bot).handleRaidCommand},
+	"setprompt":   {roleSuperAdmin, (*Bot).handleSetPromptCommand},
+	"resetprompt": {roleSuperAdmin, (*Bot).handleResetPromptCommand},
+	"pause_ai":    {roleSuperAdmin, func(b *Bot, message *tgbotapi.Message) { b.handlePauseAICommand(message, true) }},
+	"resume_ai":   {roleSuperAdmin, func(b *Bot, message *tgbotapi.Message) { b.handlePauseAICommand(message, false) }},
+}
+
+// handleCommand processes admin commands addressed to the bot.
+// It reports whether the message was consumed as a command.
+func (b *Bot) handleCommand(message *tgbotapi.Message) bool {
+	if addressedToOtherBot(message, b.api.Self.UserName) {
+		return false
+	}
+	handler, ok := commandHandlers[message.Command()]
+	if !ok {
+		return false
+	}
+	if b.hasRole(message, handler.role) {
+		handler.handle(b, message)
+	}
+	return true
+}
+
+// hasRole reports whether the sender of a command has the role
+func (b *Bot) hasRole(message *tgbotapi.Message, role commandRole) bool {
+	if role == roleSuperAdmin {
+		return b.isSuperAdmin(message)
+	}
+	return b.isAdminMessage(message)
+}
+
+func (b *Bot) handleVersionCommand(message *tgbotapi.Message) {
+	b.reply(message, b.config.BuildInfo.String())
+}
+
+func (b *Bot) handleRetryFailedCommand(message *tgbotapi.Message) {
+	retried, failed := b.sweepDeadLetters(context.Background(), message.Chat.ID)
+	b.reply(message, fmt.Sprintf("Retried failed actions: %d succeeded, %d failed", retried, failed))
+}
+
+// addressedToOtherBot reports whether a command names another bot, as in /start@other_bot
+func addressedToOtherBot(message *tgbotapi.Message, username string) bool {
+	_, target, addressed := strings.Cut(message.CommandWithAt(), "@")
+	return addressed && !strings.EqualFold(target, username)
+}
+
+// skipCommand reports whether a command not handled by the bot is left unscanned:
+// commands addressed to other bots and bare commands without arguments
+func (b *Bot) skipCommand(message *tgbotapi.Message) bool {
+	if b.config.ScanCommands || !message.IsCommand() {
+		return false
+	}
+	return addressedToOtherBot(message, b.api.Self.UserName) || message.CommandArguments() == ""
+}
+
+// isAdminMessage reports whether a command was sent by an admin of the chat.
+// Channel posts can only be made by admins.
+func (b *Bot) isAdminMessage(message *tgbotapi.Message) bool {
+	if message.Chat.IsChannel() {
+		return true
+	}
+	return message.From != nil && b.isChatAdmin(message.Chat.ID, message.From.ID)
+}
+
+// commandSender returns the ID of whoever sent a command, for logging
+func commandSender(message *tgbotapi.Message) int64 {
+	if message.From != nil {
+		return message.From.ID
+	}
+	if message.SenderChat != nil {
+		return message.SenderChat.ID
+	}
+	return 0
+}
+
+func (b *Bot) isChatAdmin(chatID, userID int64) bool {
+	return b.chatAdmins(chatID)[userID]
+}
+
+func (b *Bot) reply(message *tgbotapi.Message, text string) {
+	if _, err := b.messenger.Send(OutgoingMessage{ChatID: message.Chat.ID, Text: text, ReplyTo: message.MessageID}); err != nil {
+		b.logger.Error("Failed to send reply message", "error", err, "chatID", message.Chat.ID)
+	}
+}
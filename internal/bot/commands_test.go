@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testSuperAdminID = 8
+
+// commandMessage returns a command sent to testChatID by userID
+func commandMessage(userID int64, text string) *tgbotapi.Message {
+	command, _, _ := strings.Cut(text, " ")
+	return &tgbotapi.Message{
+		MessageID: 50,
+		From:      &tgbotapi.User{ID: userID, FirstName: "Sender"},
+		Chat:      &tgbotapi.Chat{ID: testChatID, Type: "supergroup"},
+		Text:      text,
+		Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}},
+	}
+}
+
+func TestHandleCommandRoles(t *testing.T) {
+	tests := []struct {
+		name     string
+		sender   int64
+		text     string
+		consumed bool
+		replied  bool
+	}{
+		{"admin command from an admin", testAdminID, "/version", true, true},
+		{"admin command from a member", testUserID, "/version", true, false},
+		{"super-admin command from a super-admin", testSuperAdminID, "/pause_ai", true, true},
+		{"super-admin command from a chat admin", testAdminID, "/pause_ai", true, false},
+		{"addressed to the bot", testAdminID, "/version@giraffe_bot", true, true},
+		{"addressed to another bot", testAdminID, "/version@other_bot", false, false},
+		{"unknown command", testAdminID, "/start", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.SuperAdmins = []int64{testSuperAdminID}
+			b, messenger, _ := newTestBot(t, config, &countingProvider{})
+			messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+
+			if consumed := b.handleCommand(commandMessage(tt.sender, tt.text)); consumed != tt.consumed {
+				t.Errorf("handleCommand() = %t, want %t", consumed, tt.consumed)
+			}
+			if replied := len(messenger.sentTexts()) > 0; replied != tt.replied {
+				t.Errorf("replied = %t, want %t (sent %q)", replied, tt.replied, messenger.sentTexts())
+			}
+		})
+	}
+}
+
+func TestEveryMenuCommandIsHandled(t *testing.T) {
+	for _, command := range adminCommands {
+		handler, ok := commandHandlers[command.Name]
+		if !ok {
+			t.Errorf("/%s is in the menu but not handled", command.Name)
+			continue
+		}
+		if handler.role != roleAdmin {
+			t.Errorf("/%s is in the admin menu but needs a super-admin", command.Name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Contact signals whose boost chats can tune with /signals
+const (
+	contactEmail    = "email"
+	contactMentions = "mentions"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// Telegram usernames have 5 to 32 characters and start with a letter
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@[A-Za-z]\w{4,31}\b`)
+)
+
+func (b *Bot) contactSignalsKey(chatID int64) string {
+	return b.key("contact_signals:%d", chatID)
+}
+
+// hasEmail reports whether text contains an email address
+func hasEmail(text string) bool {
+	return emailPattern.MatchString(text)
+}
+
+// countMentions returns the number of @username mentions in text, leaving out email addresses
+func countMentions(text string) int {
+	return len(mentionPattern.FindAllString(emailPattern.ReplaceAllString(text, " "), -1))
+}
+
+// contactBoosts returns the email and mention boosts of a chat, which may override the global ones
+func (b *Bot) contactBoosts(ctx context.Context, chatID int64) (email, mentions float64) {
+	email, mentions = b.config.EmailBoost, b.config.MentionBoost
+	overrides, err := b.redis.HGetAll(ctx, b.contactSignalsKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get chat contact signals", "error", err, "chatID", chatID)
+		return email, mentions
+	}
+	if value, err := strconv.ParseFloat(overrides[contactEmail], 64); err == nil {
+		email = value
+	}
+	if value, err := strconv.ParseFloat(overrides[contactMentions], 64); err == nil {
+		mentions = value
+	}
+	return email, mentions
+}
+
+// contactBoost returns the boost for the email addresses and mentions in text
+func (b *Bot) contactBoost(ctx context.Context, chatID int64, text string) float64 {
+	email, mentions := b.contactBoosts(ctx, chatID)
+	var boost float64
+	if email > 0 && hasEmail(text) {
+		boost += email
+	}
+	if mentions > 0 && b.config.MentionCount > 0 && countMentions(text) >= b.config.MentionCount {
+		boost += mentions
+	}
+	return boost
+}
+
+// formatBoost describes a boost for /signals
+func formatBoost(boost float64) string {
+	if boost <= 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%.2f", boost)
+}
+
+// handleSignalsCommand shows or tunes the chat's contact signals: /signals [email|mentions <boost|off|default>]
+func (b *Bot) handleSignalsCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	args := strings.Fields(strings.ToLower(message.CommandArguments()))
+
+	if len(args) == 0 {
+		email, mentions := b.contactBoosts(ctx, chatID)
+		b.reply(message, fmt.Sprintf("Email addresses: %s (default %s)\nAt least %d mentions: %s (default %s)\nTune them with /signals email|mentions <boost|off|default>",
+			formatBoost(email), formatBoost(b.config.EmailBoost), b.config.MentionCount, formatBoost(mentions), formatBoost(b.config.MentionBoost)))
+		return
+	}
+	if len(args) != 2 || (args[0] != contactEmail && args[0] != contactMentions) {
+		b.reply(message, "Usage: /signals [email|mentions <boost|off|default>], where the boost is added to new users' spam scores, from 0 to 1")
+		return
+	}
+
+	signal, value := args[0], args[1]
+	switch value {
+	case "default":
+		if err := b.redis.HDel(ctx, b.contactSignalsKey(chatID), signal).Err(); err != nil {
+			b.logger.Error("Failed to reset chat contact signal", "error", err, "chatID", chatID, "signal", signal)
+			b.reply(message, "Failed to reset the signal")
+			return
+		}
+		b.logger.Info("Reset chat contact signal", "chatID", chatID, "signal", signal, "admin", commandSender(message))
+		b.reply(message, fmt.Sprintf("The %s signal uses the default again", signal))
+		return
+	case "off":
+		value = "0"
+	}
+	boost, err := strconv.ParseFloat(value, 64)
+	if err != nil || boost < 0 || boost > 1 {
+		b.reply(message, "The boost must be a number from 0 to 1, off or default")
+		return
+	}
+	if err := b.redis.HSet(ctx, b.contactSignalsKey(chatID), signal, boost).Err(); err != nil {
+		b.logger.Error("Failed to set chat contact signal", "error", err, "chatID", chatID, "signal", signal)
+		b.reply(message, "Failed to set the signal")
+		return
+	}
+	b.logger.Info("Set chat contact signal", "chatID", chatID, "signal", signal, "boost", boost, "admin", commandSender(message))
+	b.reply(message, fmt.Sprintf("The %s signal is %s in this chat", signal, formatBoost(boost)))
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	charsPerToken = 4              // Rough average, providers don't report token usage
+	costKeyTTL    = 48 * time.Hour // Daily totals outlive their day to survive clock skew between instances
+)
+
+// CostCapFallback controls how messages are handled once the daily cost cap is reached
+type CostCapFallback string
+
+const (
+	CostCapFallbackOff        CostCapFallback = "off"             // Let messages through unclassified
+	CostCapFallbackHeuristics CostCapFallback = "heuristics-only" // Score messages with the heuristic signals only
+	CostCapFallbackNotify     CostCapFallback = "notify-only"     // Report new users' messages and links or media for review
+)
+
+func ParseCostCapFallback(value string) (CostCapFallback, error) {
+	switch fallback := CostCapFallback(value); fallback {
+	case CostCapFallbackOff, CostCapFallbackHeuristics, CostCapFallbackNotify:
+		return fallback, nil
+	default:
+		return "", fmt.Errorf("unknown cost cap fallback: %s (expected off, heuristics-only or notify-only)", value)
+	}
+}
+
+// costKey returns the key of the estimated spend on a UTC day, shared by every instance
+func (b *Bot) costKey(day time.Time) string {
+	return b.key("cost:%s", day.UTC().Format(time.DateOnly))
+}
+
+// estimateCost approximates the price of a classification from the length of its prompt and response
+func estimateCost(prompt, response string, costPer1KTokens float64) float64 {
+	tokens := float64(len(prompt)+len(response)) / charsPerToken
+	return tokens / 1000 * costPer1KTokens
+}
+
+// recordCost adds the estimated price of a classification to today's spend
+func (b *Bot) recordCost(ctx context.Context, prompt, response string) {
+	if b.config.DailyCostCap <= 0 {
+		return
+	}
+	key := b.costKey(time.Now())
+	pipe := b.redis.TxPipeline()
+	pipe.IncrByFloat(ctx, key, estimateCost(prompt, response, b.config.CostPer1KTokens))
+	pipe.Expire(ctx, key, costKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to record classification cost", "error", err)
+	}
+}
+
+// costCapReached reports whether today's estimated spend reached the cap, logging when the state changes
+func (b *Bot) costCapReached(ctx context.Context) bool {
+	if b.config.DailyCostCap <= 0 {
+		return false
+	}
+	spent, err := b.redis.Get(ctx, b.costKey(time.Now())).Float64()
+	if err != nil && err != redis.Nil {
+		b.logger.Error("Failed to get classification cost", "error", err)
+		return false
+	}
+
+	reached := spent >= b.config.DailyCostCap
+	if b.costCapped.Swap(reached) != reached {
+		if reached {
+			b.logger.Warn("Daily cost cap reached, running degraded", "spent", spent, "cap", b.config.DailyCostCap, "fallback", b.config.CostCapFallback)
+			metrics.CostCapReached.Set(1)
+		} else {
+			b.logger.Info("Daily cost cap reset, classifying again")
+			metrics.CostCapReached.Set(0)
+		}
+	}
+	return reached
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCostCapFallbacks(t *testing.T) {
+	const card = "send the fee to 4111 1111 1111 1111"
+	tests := []struct {
+		fallback CostCapFallback
+		text     string
+		known    bool // Sender already has messages in the chat
+		deleted  bool
+		reported bool
+	}{
+		{CostCapFallbackOff, card, false, false, false},
+		{CostCapFallbackHeuristics, card, false, true, true},
+		{CostCapFallbackHeuristics, "hello everyone", false, false, false},
+		{CostCapFallbackNotify, "hello everyone", false, false, true},
+		{CostCapFallbackNotify, "hello everyone", true, false, false},
+	}
+	for _, tt := range tests {
+		name := string(tt.fallback) + " " + tt.text
+		if tt.known {
+			name += " from a known user"
+		}
+		t.Run(name, func(t *testing.T) {
+			config := testConfig()
+			config.DailyCostCap = 1
+			config.CostCapFallback = tt.fallback
+			config.CardBoost = 0.9
+			provider := &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`}
+			b, messenger, server := newTestBot(t, config, provider)
+			server.Set(b.costKey(time.Now()), "5")
+			if tt.known {
+				server.Set(b.key("%d:%d", testUserID, testChatID), "3")
+			}
+
+			handle(b, textMessage(10, tt.text))
+
+			if provider.callCount() != 0 {
+				t.Errorf("classified %d times with the cost cap reached", provider.callCount())
+			}
+			if deleted := len(messenger.deleted) > 0; deleted != tt.deleted {
+				t.Errorf("deleted = %v, want deleted %t", messenger.deleted, tt.deleted)
+			}
+			if reported := len(messenger.forwarded) > 0; reported != tt.reported {
+				t.Errorf("forwarded = %v, want reported %t", messenger.forwarded, tt.reported)
+			}
+		})
+	}
+}
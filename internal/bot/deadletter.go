@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	deadLetterKey         = "dead_letter"
+	deadLetterMaxAttempts = 5                  // Sweeps after which a failed action is dropped
+	deadLetterMaxLength   = 1000               // Newest failed actions kept, older ones are dropped
+	deadLetterTTL         = 7 * 24 * time.Hour // Dead-letter list expiry, renewed by every failure
+	actionRetries         = 3
+	minRestrictionLeft    = 30 * time.Second // Telegram treats restrictions ending sooner as permanent
+)
+
+// actionRetryDelay is the backoff before the first retry of a failed action, doubled for every next one
+var actionRetryDelay = 500 * time.Millisecond
+
+type failedActionKind string
+
+const (
+	failedDelete   failedActionKind = "delete"
+	failedRestrict failedActionKind = "restrict"
+)
+
+// failedAction is a Telegram action that failed after retries, kept for a later sweep
+type failedAction struct {
+	Kind      failedActionKind `json:"kind"`
+	ChatID    int64            `json:"chat_id"`
+	UserID    int64            `json:"user_id,omitempty"`
+	MessageID int              `json:"message_id,omitempty"`
+	Until     int64            `json:"until,omitempty"` // End of a temporary restriction as a Unix time, permanent if zero
+	Error     string           `json:"error"`
+	Attempts  int              `json:"attempts"`
+	FailedAt  time.Time        `json:"failed_at"`
+}
+
+// perform carries out the action through the messenger
+func (a failedAction) perform(messenger Messenger) error {
+	if a.Kind == failedDelete {
+		return messenger.Delete(a.ChatID, a.MessageID)
+	}
+	var until time.Time
+	if a.Until != 0 {
+		until = time.Unix(a.Until, 0)
+	}
+	return messenger.Restrict(a.ChatID, a.UserID, until)
+}
+
+// deleteMessage deletes a message, verifying in the background that it is gone if DeleteVerifyAttempts is set
+func (b *Bot) deleteMessage(chatID int64, messageID int) error {
+	if err := b.requestWithRetry(failedAction{Kind: failedDelete, ChatID: chatID, MessageID: messageID}); err != nil {
+		return err
+	}
+	if b.config.DeleteVerifyAttempts > 0 {
+		b.goSafe(func() { b.verifyDeletion(chatID, messageID) })
+	}
+	return nil
+}
+
+func (b *Bot) restrictUser(chatID, userID int64) error {
+	return b.requestWithRetry(failedAction{Kind: failedRestrict, ChatID: chatID, UserID: userID})
+}
+
+// muteUser restricts a user until the given time
+func (b *Bot) muteUser(chatID, userID int64, until time.Time) error {
+	return b.requestWithRetry(failedAction{Kind: failedRestrict, ChatID: chatID, UserID: userID, Until: until.Unix()})
+}
+
+// requestWithRetry performs the action once. If it fails for a reason retrying may
+// fix, the retries with backoff run in the background, so a flaky Telegram API doesn't
+// hold up the update worker, and the action is dead-lettered if they all fail.
+func (b *Bot) requestWithRetry(action failedAction) error {
+	err := action.perform(b.messenger)
+	b.recordActionResult(action.ChatID, err)
+	if err == nil {
+		return nil
+	}
+	if isPermissionError(err) {
+		return fmt.Errorf("%s failed: %w", action.Kind, err) // Retrying won't help until rights are restored
+	}
+	if action.Kind == failedDelete && isUndeletable(err) {
+		return fmt.Errorf("%s failed: %w", action.Kind, err)
+	}
+	b.goSafe(func() { b.retryAction(action, err) })
+	return fmt.Errorf("%s failed, retrying in the background: %w", action.Kind, err)
+}
+
+// retryAction retries an action that failed with err, dead-lettering it if all attempts fail
+func (b *Bot) retryAction(action failedAction, err error) {
+	delay := actionRetryDelay
+	for i := 1; i < actionRetries; i++ {
+		b.logger.Warn("Telegram action failed, retrying", "kind", action.Kind, "attempt", i, "error", err, "chatID", action.ChatID)
+		time.Sleep(delay)
+		delay *= 2
+		err = action.perform(b.messenger)
+		b.recordActionResult(action.ChatID, err)
+		// A failed delete may have gone through anyway, leaving nothing for the retry to delete
+		if err == nil || action.Kind == failedDelete && isMessageGone(err) {
+			b.logger.Info("Telegram action succeeded on retry", "kind", action.Kind, "attempt", i+1, "chatID", action.ChatID)
+			return
+		}
+		if isPermissionError(err) || action.Kind == failedDelete && isUndeletable(err) {
+			b.logger.Warn("Giving up on Telegram action", "kind", action.Kind, "error", err, "chatID", action.ChatID)
+			return
+		}
+	}
+
+	action.Error = err.Error()
+	action.Attempts = 1
+	action.FailedAt = time.Now()
+	b.logger.Error("Telegram action failed after retries", "kind", action.Kind, "attempts", actionRetries, "error", err, "chatID", action.ChatID)
+	b.pushDeadLetter(context.Background(), action)
+}
+
+func (b *Bot) pushDeadLetter(ctx context.Context, action failedAction) {
+	data, err := json.Marshal(action)
+	if err != nil {
+		b.logger.Error("Failed to marshal dead-letter action", "error", err)
+		return
+	}
+	key := b.key(deadLetterKey)
+	pipe := b.redis.TxPipeline()
+	pipe.RPush(ctx, key, data)
+	pipe.LTrim(ctx, key, -deadLetterMaxLength, -1)
+	pipe.Expire(ctx, key, deadLetterTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to store dead-letter action", "error", err, "kind", action.Kind, "chatID", action.ChatID)
+	}
+}
+
+// dropDeadLetters removes the dead-lettered restrictions of a user in a chat, so they aren't retried
+func (b *Bot) dropDeadLetters(ctx context.Context, chatID, userID int64) error {
+	key := b.key(deadLetterKey)
+	stored, err := b.redis.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	pipe := b.redis.Pipeline()
+	for _, data := range stored {
+		var action failedAction
+		if err := json.Unmarshal([]byte(data), &action); err == nil && action.Kind == failedRestrict && action.ChatID == chatID && action.UserID == userID {
+			pipe.LRem(ctx, key, 0, data)
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// sweepDeadLetters retries dead-lettered actions once, for a single chat or all chats if chatID is 0.
+// Actions that fail again are put back until they exceed deadLetterMaxAttempts.
+func (b *Bot) sweepDeadLetters(ctx context.Context, chatID int64) (retried, failed int) {
+	pending, err := b.redis.LLen(ctx, b.key(deadLetterKey)).Result()
+	if err != nil {
+		b.logger.Error("Failed to read dead-letter list", "error", err)
+		return 0, 0
+	}
+
+	for i := int64(0); i < pending; i++ {
+		data, err := b.redis.LPop(ctx, b.key(deadLetterKey)).Bytes()
+		if err != nil {
+			break
+		}
+
+		var action failedAction
+		if err := json.Unmarshal(data, &action); err != nil {
+			b.logger.Error("Dropping malformed dead-letter action", "error", err)
+			continue
+		}
+
+		if chatID != 0 && action.ChatID != chatID {
+			b.redis.RPush(ctx, b.key(deadLetterKey), data)
+			continue
+		}
+
+		// A mute that ended or is about to end would be replayed as a permanent restriction
+		if action.Kind == failedRestrict && action.Until != 0 && action.Until <= time.Now().Add(minRestrictionLeft).Unix() {
+			b.logger.Info("Dropping expired dead-letter restriction", "chatID", action.ChatID, "userID", action.UserID, "until", time.Unix(action.Until, 0))
+			continue
+		}
+
+		err = action.perform(b.messenger)
+		if err == nil {
+			retried++
+			b.logger.Info("Retried dead-letter action", "kind", action.Kind, "chatID", action.ChatID, "userID", action.UserID, "messageID", action.MessageID)
+			continue
+		}
+
+		failed++
+		action.Error = err.Error()
+		action.Attempts++
+		if action.Attempts > deadLetterMaxAttempts {
+			b.logger.Warn("Dropping dead-letter action after too many attempts", "kind", action.Kind, "chatID", action.ChatID, "error", action.Error)
+			continue
+		}
+		b.pushDeadLetter(ctx, action)
+	}
+	return retried, failed
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fastRetries shortens the backoff of failed actions for the test
+func fastRetries(t *testing.T) {
+	delay := actionRetryDelay
+	actionRetryDelay = time.Millisecond
+	t.Cleanup(func() { actionRetryDelay = delay })
+}
+
+// waitFor fails the test if condition doesn't hold within a second
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestWithRetryRetriesInBackground(t *testing.T) {
+	fastRetries(t)
+	b, messenger, server := newTestBot(t, testConfig(), &countingProvider{})
+	messenger.deleteErr = errors.New("Internal Server Error")
+	messenger.failures = 2
+
+	if err := b.deleteMessage(testChatID, 10); err == nil {
+		t.Fatal("deleteMessage() succeeded although the first attempt failed")
+	}
+
+	waitFor(t, "the retried delete", func() bool {
+		messenger.mutex.Lock()
+		defer messenger.mutex.Unlock()
+		return slices.Contains(messenger.deleted, 10)
+	})
+	if server.Exists(b.key(deadLetterKey)) {
+		t.Error("an action that succeeded on retry was dead-lettered")
+	}
+}
+
+func TestRequestWithRetryDeadLettersAndSweeps(t *testing.T) {
+	fastRetries(t)
+	b, messenger, server := newTestBot(t, testConfig(), &countingProvider{})
+	ctx := context.Background()
+	messenger.restrictErr = errors.New("Too Many Requests")
+
+	if err := b.restrictUser(testChatID, testUserID); err == nil {
+		t.Fatal("restrictUser() succeeded although Restrict failed")
+	}
+	waitFor(t, "the dead-lettered restriction", func() bool {
+		return server.Exists(b.key(deadLetterKey))
+	})
+
+	messenger.mutex.Lock()
+	messenger.restrictErr = nil
+	messenger.mutex.Unlock()
+	b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: -1009, UserID: 7})
+
+	retried, failed := b.sweepDeadLetters(ctx, testChatID)
+
+	if retried != 1 || failed != 0 {
+		t.Errorf("sweepDeadLetters() = %d retried, %d failed, want 1 and 0", retried, failed)
+	}
+	if len(messenger.restricted) != 1 || messenger.restricted[0] != testUserID {
+		t.Errorf("restricted = %v, want only the dead-lettered user of the swept chat", messenger.restricted)
+	}
+	if kept, _ := server.List(b.key(deadLetterKey)); len(kept) != 1 {
+		t.Errorf("dead-letter list = %v, want the other chat's action kept", kept)
+	}
+}
+
+func TestRequestWithRetrySkipsPermissionErrors(t *testing.T) {
+	fastRetries(t)
+	b, messenger, server := newTestBot(t, testConfig(), &countingProvider{})
+	messenger.restrictErr = errors.New("Bad Request: not enough rights to restrict/unrestrict chat member")
+
+	if err := b.restrictUser(testChatID, testUserID); err == nil {
+		t.Fatal("restrictUser() succeeded although Restrict failed")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if server.Exists(b.key(deadLetterKey)) {
+		t.Error("an action failing for missing rights was retried and dead-lettered")
+	}
+}
+
+func TestPushDeadLetterCapsList(t *testing.T) {
+	b, _, server := newTestBot(t, testConfig(), &countingProvider{})
+	ctx := context.Background()
+	for i := 0; i < deadLetterMaxLength+5; i++ {
+		b.pushDeadLetter(ctx, failedAction{Kind: failedDelete, ChatID: testChatID, MessageID: i})
+	}
+
+	kept, err := server.List(b.key(deadLetterKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != deadLetterMaxLength {
+		t.Errorf("dead-letter list has %d actions, want %d", len(kept), deadLetterMaxLength)
+	}
+	if ttl := server.TTL(b.key(deadLetterKey)); ttl != deadLetterTTL {
+		t.Errorf("dead-letter list TTL = %v, want %v", ttl, deadLetterTTL)
+	}
+}
+
+func TestSweepDropsExpiredRestrictions(t *testing.T) {
+	b, messenger, server := newTestBot(t, testConfig(), &countingProvider{})
+	ctx := context.Background()
+	now := time.Now()
+	b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: testChatID, UserID: 1, Until: now.Add(-time.Minute).Unix()})
+	b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: testChatID, UserID: 2, Until: now.Add(10 * time.Second).Unix()})
+	b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: testChatID, UserID: 3, Until: now.Add(time.Hour).Unix()})
+	b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: testChatID, UserID: 4})
+
+	retried, failed := b.sweepDeadLetters(ctx, 0)
+
+	if retried != 2 || failed != 0 {
+		t.Errorf("sweepDeadLetters() = %d retried, %d failed, want 2 and 0", retried, failed)
+	}
+	if len(messenger.restricted) != 2 || messenger.restricted[0] != 3 || messenger.restricted[1] != 4 {
+		t.Errorf("restricted = %v, want only the unexpired mute and the ban", messenger.restricted)
+	}
+	if server.Exists(b.key(deadLetterKey)) {
+		t.Error("expired restrictions were kept in the dead-letter list")
+	}
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	detailsCallbackPrefix = "details:"
+	maxNotificationChars  = 4000 // Below Telegram's limit of 4096 characters per message
+)
+
+// notificationDetails is the classification behind a notification, revealed on request
+type notificationDetails struct {
+	Threshold float64   `json:"threshold"`
+	Result    ai.Result `json:"result"`
+	Context   []string  `json:"context,omitempty"`
+}
+
+func (b *Bot) detailsKey(chatID int64, messageID int) string {
+	return b.key("details:%d:%d", chatID, messageID)
+}
+
+// contentKey holds the original content of a deleted message, kept apart from its details to expire on its own
+func (b *Bot) contentKey(chatID int64, messageID int) string {
+	return b.key("details_content:%d:%d", chatID, messageID)
+}
+
+// storeContent keeps the content of a message deleted by the bot for appeals, if enabled
+func (b *Bot) storeContent(ctx context.Context, message *tgbotapi.Message) {
+	if !b.config.AuditKeepContent {
+		return
+	}
+	text := messageText(message)
+	if text == "" {
+		return
+	}
+	if err := b.redis.Set(ctx, b.contentKey(message.Chat.ID, message.MessageID), text, b.config.AuditContentTTL).Err(); err != nil {
+		b.logger.Error("Failed to store deleted message content", "error", err, "chatID", message.Chat.ID)
+	}
+}
+
+// storeDetails keeps the classification of a message for the expand button of its notification
+func (b *Bot) storeDetails(ctx context.Context, message *tgbotapi.Message, processed *ai.Result, threshold float64) {
+	if b.config.NotificationDetailsTTL <= 0 {
+		return
+	}
+	details := notificationDetails{Threshold: threshold, Result: *processed}
+	if b.config.ContextMessages > 0 {
+		details.Context = b.chatContext(ctx, message.Chat.ID, message.MessageID)
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		b.logger.Error("Failed to encode notification details", "error", err)
+		return
+	}
+	if err := b.redis.Set(ctx, b.detailsKey(message.Chat.ID, message.MessageID), data, b.config.NotificationDetailsTTL).Err(); err != nil {
+		b.logger.Error("Failed to store notification details", "error", err, "chatID", message.Chat.ID)
+	}
+}
+
+// hasDetails reports whether the classification or the deleted content of a message is stored
+func (b *Bot) hasDetails(ctx context.Context, chatID int64, messageID int) bool {
+	if b.config.NotificationDetailsTTL <= 0 && !b.config.AuditKeepContent {
+		return false
+	}
+	exists, err := b.redis.Exists(ctx, b.detailsKey(chatID, messageID), b.contentKey(chatID, messageID)).Result()
+	return err == nil && exists > 0
+}
+
+// sendLogWithDetails sends a notification with a button expanding the classification
+// of the message and returns its ID, or 0 if it couldn't be sent
+func (b *Bot) sendLogWithDetails(logChannelID int64, text string, chatID int64, messageID int) int {
+	logMessageID, err := b.messenger.Send(OutgoingMessage{
+		ChatID: logChannelID,
+		Text:   text,
+		Button: &Button{Text: "🔍 Details", Data: fmt.Sprintf("%s%d:%d", detailsCallbackPrefix, chatID, messageID)},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send log message to log channel", "error", err, "logChannelID", logChannelID)
+		return 0
+	}
+	return logMessageID
+}
+
+// handleCallbackQuery expands notifications with the stored classification details
+func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	var chatID int64
+	var messageID int
+	if _, err := fmt.Sscanf(query.Data, detailsCallbackPrefix+"%d:%d", &chatID, &messageID); err != nil || query.Message == nil {
+		b.answerCallback(query, "")
+		return
+	}
+
+	ctx := context.Background()
+	var expanded []string
+	data, err := b.redis.Get(ctx, b.detailsKey(chatID, messageID)).Bytes()
+	if err != nil && err != redis.Nil {
+		b.logger.Error("Failed to load notification details", "error", err, "chatID", chatID)
+	}
+	if err == nil {
+		var details notificationDetails
+		if err := json.Unmarshal(data, &details); err != nil {
+			b.logger.Error("Failed to decode notification details", "error", err, "chatID", chatID)
+		} else {
+			expanded = append(expanded, details.String())
+		}
+	}
+	content, err := b.redis.Get(ctx, b.contentKey(chatID, messageID)).Result()
+	if err != nil && err != redis.Nil {
+		b.logger.Error("Failed to load deleted message content", "error", err, "chatID", chatID)
+	}
+	if err == nil {
+		expanded = append(expanded, "Deleted message:\n"+content)
+	}
+	if len(expanded) == 0 {
+		b.answerCallback(query, "Details are no longer available")
+		return
+	}
+
+	text := truncate(query.Message.Text+"\n\n"+strings.Join(expanded, "\n\n"), maxNotificationChars)
+	if err := b.messenger.Edit(query.Message.Chat.ID, query.Message.MessageID, text); err != nil {
+		b.logger.Error("Failed to expand notification", "error", err, "chatID", query.Message.Chat.ID)
+	}
+	b.answerCallback(query, "")
+}
+
+func (b *Bot) answerCallback(query *tgbotapi.CallbackQuery, text string) {
+	if err := b.messenger.AnswerCallback(query.ID, text); err != nil {
+		b.logger.Error("Failed to answer callback query", "error", err)
+	}
+}
+
+// String renders the details for the expanded notification
+func (d notificationDetails) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Spam score: %.2f / %.2f\n", d.Result.SpamScore, d.Threshold)
+	fmt.Fprintf(&sb, "Reasoning: %s\n", d.Result.Reasoning)
+	if result, err := json.Marshal(d.Result); err == nil {
+		fmt.Fprintf(&sb, "Result: %s\n", result)
+	}
+	if len(d.Context) > 0 {
+		sb.WriteString("Context:\n")
+		sb.WriteString(strings.Join(d.Context, "\n"))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
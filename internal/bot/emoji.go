@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"fmt"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// customEmojiPlaceholder stands in for a run of custom emoji when they are normalized
+const customEmojiPlaceholder = "[custom emoji]"
+
+// CustomEmojiMode controls how custom emoji are presented to the classifier
+type CustomEmojiMode string
+
+const (
+	CustomEmojiKeep      CustomEmojiMode = "keep"      // Classify the fallback emoji as sent
+	CustomEmojiStrip     CustomEmojiMode = "strip"     // Remove custom emoji from the text
+	CustomEmojiNormalize CustomEmojiMode = "normalize" // Replace each run of custom emoji with a placeholder
+)
+
+func ParseCustomEmojiMode(value string) (CustomEmojiMode, error) {
+	switch mode := CustomEmojiMode(value); mode {
+	case CustomEmojiKeep, CustomEmojiStrip, CustomEmojiNormalize:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown custom emoji mode: %s (expected keep, strip or normalize)", value)
+	}
+}
+
+func isCustomEmojiEntity(entity tgbotapi.MessageEntity) bool {
+	return entity.Type == "custom_emoji"
+}
+
+// classificationText returns the message text sent to the classifier, with custom emoji handled by the mode
+func (b *Bot) classificationText(message *tgbotapi.Message) string {
+	switch b.config.CustomEmoji {
+	case CustomEmojiStrip:
+		return rewriteEntities(messageText(message), messageEntities(message), isCustomEmojiEntity, "")
+	case CustomEmojiNormalize:
+		return rewriteEntities(messageText(message), messageEntities(message), isCustomEmojiEntity, customEmojiPlaceholder)
+	default:
+		return messageText(message)
+	}
+}
+
+// customEmojiRatio returns the share of custom emoji among the custom emoji,
+// letters and digits of a message, or zero if it has only a few of them
+func customEmojiRatio(message *tgbotapi.Message) float64 {
+	emoji := 0
+	for _, entity := range messageEntities(message) {
+		if isCustomEmojiEntity(entity) {
+			emoji++
+		}
+	}
+	if emoji < minDecorativeRunes {
+		return 0
+	}
+
+	letters := 0
+	for _, r := range messageText(message) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters++
+		}
+	}
+	return float64(emoji) / float64(emoji+letters)
+}
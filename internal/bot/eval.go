@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/evalstore"
+)
+
+// sampleForEval keeps an EvalSampleRate fraction of classifications with the model's
+// score in the rolling eval store, with the message text only if EvalKeepContent is set
+func (b *Bot) sampleForEval(ctx context.Context, message *tgbotapi.Message, text string, processed *ai.Result) {
+	if b.evalStore == nil || rand.Float64() >= b.config.EvalSampleRate {
+		return
+	}
+	sample := evalstore.Sample{
+		ChatID:    message.Chat.ID,
+		MessageID: message.MessageID,
+		Hash:      b.hashMessage(text),
+		Model:     b.config.BuildInfo.Model,
+		SpamScore: processed.SpamScore,
+		CreatedAt: time.Now(),
+	}
+	if b.config.EvalKeepContent {
+		sample.Text = text
+	}
+	if err := b.evalStore.Add(ctx, sample); err != nil {
+		b.logger.Error("Failed to store eval sample", "error", err, "chatID", sample.ChatID)
+	}
+}
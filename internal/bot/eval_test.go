@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+func TestSampleForEvalFraction(t *testing.T) {
+	const messages = 2000
+	tests := []struct {
+		rate     float64
+		min, max int
+	}{
+		{1, messages, messages},
+		{0.25, 350, 650},
+	}
+	for _, tt := range tests {
+		config := testConfig()
+		config.EvalSampleRate = tt.rate
+		config.EvalStoreSize = messages
+		b, _, _ := newTestBot(t, config, &countingProvider{})
+		ctx := context.Background()
+
+		for id := 1; id <= messages; id++ {
+			b.sampleForEval(ctx, textMessage(id, "hello everyone"), "hello everyone", &ai.Result{SpamScore: 0.1})
+		}
+
+		samples, err := b.evalStore.List(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) < tt.min || len(samples) > tt.max {
+			t.Errorf("rate %.2f kept %d of %d samples, want %d to %d", tt.rate, len(samples), messages, tt.min, tt.max)
+		}
+		if len(samples) > 0 && (samples[0].Text != "" || samples[0].Hash != b.hashMessage("hello everyone")) {
+			t.Errorf("sample = %+v, want only the hash kept without EvalKeepContent", samples[0])
+		}
+	}
+}
+
+func TestSampleForEvalBoundsStore(t *testing.T) {
+	config := testConfig()
+	config.EvalSampleRate = 1
+	config.EvalStoreSize = 10
+	config.EvalKeepContent = true
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	for id := 1; id <= 25; id++ {
+		b.sampleForEval(ctx, textMessage(id, "hello everyone"), "hello everyone", &ai.Result{SpamScore: 0.1})
+	}
+
+	samples, err := b.evalStore.List(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 10 || samples[0].MessageID != 25 || samples[0].Text != "hello everyone" {
+		t.Errorf("kept %d samples, newest %+v, want the 10 newest with their text", len(samples), samples[0])
+	}
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	spamExamplesStored = 50                  // Recent spam messages kept per chat
+	spamExamplesTTL    = 30 * 24 * time.Hour // Examples expire when a chat stops seeing spam
+)
+
+// spamExample is a spam message of a chat kept for few-shot prompting
+type spamExample struct {
+	Hash      string    `json:"hash"`
+	Text      string    `json:"text"`
+	Confirmed bool      `json:"confirmed,omitempty"` // Reported by an admin rather than detected
+	StoredAt  time.Time `json:"stored_at"`
+}
+
+// spamExamplesKey holds the chat's examples with their hashes, the earlier
+// plain text list under spam_examples is left to expire
+func (b *Bot) spamExamplesKey(chatID int64) string {
+	return b.key("spam_example_entries:%d", chatID)
+}
+
+// storeSpamExample remembers a spam message for later few-shot prompting. Detected
+// messages are only used once UndoWindow passes without an admin undoing them,
+// those confirmed by an admin right away.
+func (b *Bot) storeSpamExample(ctx context.Context, chatID int64, hash, text string, confirmed bool) {
+	if b.config.SpamExamples <= 0 {
+		return
+	}
+	data, err := json.Marshal(spamExample{Hash: hash, Text: text, Confirmed: confirmed, StoredAt: time.Now()})
+	if err != nil {
+		b.logger.Error("Failed to encode spam example", "error", err)
+		return
+	}
+	key := b.spamExamplesKey(chatID)
+	pipe := b.redis.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, spamExamplesStored-1)
+	pipe.Expire(ctx, key, spamExamplesTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to store spam example", "error", err, "chatID", chatID)
+	}
+}
+
+// removeSpamExample forgets the chat's examples of a message that turned out not to be spam
+func (b *Bot) removeSpamExample(ctx context.Context, chatID int64, hash string) {
+	if b.config.SpamExamples <= 0 {
+		return
+	}
+	key := b.spamExamplesKey(chatID)
+	stored, err := b.redis.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		b.logger.Error("Failed to load spam examples", "error", err, "chatID", chatID)
+		return
+	}
+	pipe := b.redis.Pipeline()
+	for _, data := range stored {
+		var example spamExample
+		if err := json.Unmarshal([]byte(data), &example); err == nil && example.Hash == hash {
+			pipe.LRem(ctx, key, 0, data)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to remove spam example", "error", err, "chatID", chatID)
+	}
+}
+
+// spamExamples returns the most recent confirmed spam examples for a chat, bounded by count and total length
+func (b *Bot) spamExamples(ctx context.Context, chatID int64) []string {
+	stored, err := b.redis.LRange(ctx, b.spamExamplesKey(chatID), 0, -1).Result()
+	if err != nil {
+		b.logger.Error("Failed to load spam examples", "error", err, "chatID", chatID)
+		return nil
+	}
+
+	examples := make([]string, 0, b.config.SpamExamples)
+	total := 0
+	for _, data := range stored {
+		var example spamExample
+		if err := json.Unmarshal([]byte(data), &example); err != nil {
+			continue
+		}
+		// A detection may still be undone as a false positive
+		if !example.Confirmed && time.Since(example.StoredAt) < b.config.UndoWindow {
+			continue
+		}
+		if len(examples) == b.config.SpamExamples || b.config.SpamExamplesChars > 0 && total+len(example.Text) > b.config.SpamExamplesChars {
+			break
+		}
+		total += len(example.Text)
+		examples = append(examples, example.Text)
+	}
+	return examples
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// examplesBot returns a bot injecting up to 5 spam examples, with detections open to undo for an hour
+func examplesBot(t *testing.T, provider *countingProvider) (*Bot, *fakeMessenger) {
+	t.Helper()
+	config := testConfig()
+	config.SpamExamples = 5
+	config.UndoWindow = time.Hour
+	config.UndoReaction = "🕊"
+	b, messenger, _ := newTestBot(t, config, provider)
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	return b, messenger
+}
+
+func TestDetectionsBecomeExamplesAfterUndoWindow(t *testing.T) {
+	b, _ := examplesBot(t, &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`})
+	ctx := context.Background()
+
+	handle(b, textMessage(10, "cheap followers in my profile"))
+
+	if examples := b.spamExamples(ctx, testChatID); len(examples) != 0 {
+		t.Errorf("spamExamples() = %q, detections that may still be undone aren't examples", examples)
+	}
+	b.config.UndoWindow = 0
+	if examples := b.spamExamples(ctx, testChatID); !slices.Equal(examples, []string{"cheap followers in my profile"}) {
+		t.Errorf("spamExamples() = %q, want the detection once it can't be undone", examples)
+	}
+}
+
+func TestReportedSpamIsExampleRightAway(t *testing.T) {
+	b, _ := examplesBot(t, &countingProvider{})
+	report := commandMessage(testAdminID, "/spam")
+	report.ReplyToMessage = textMessage(10, "join my crypto group")
+
+	b.handleCommand(report)
+
+	if examples := b.spamExamples(context.Background(), testChatID); !slices.Equal(examples, []string{"join my crypto group"}) {
+		t.Errorf("spamExamples() = %q, want the reported message", examples)
+	}
+}
+
+func TestFalsePositivesLeaveExamples(t *testing.T) {
+	tests := []struct {
+		name   string
+		revert func(b *Bot)
+	}{
+		{"not spam", func(b *Bot) {
+			report := commandMessage(testAdminID, "/notspam")
+			report.ReplyToMessage = textMessage(10, "join my crypto group")
+			b.handleCommand(report)
+		}},
+		{"undo", func(b *Bot) {
+			b.rememberUndo(context.Background(), testLogChatID, 300, undoRecord{
+				ChatID: testChatID, UserID: testUserID, MessageID: 10, Hash: b.hashMessage("join my crypto group"),
+			})
+			b.handleReaction(undoReaction(tgbotapi.Chat{ID: testLogChatID, Type: "supergroup"}, &tgbotapi.User{ID: testAdminID}, nil))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := examplesBot(t, &countingProvider{})
+			ctx := context.Background()
+			b.storeSpamExample(ctx, testChatID, b.hashMessage("join my crypto group"), "join my crypto group", true)
+			b.storeSpamExample(ctx, testChatID, b.hashMessage("cheap followers"), "cheap followers", true)
+
+			tt.revert(b)
+
+			if examples := b.spamExamples(ctx, testChatID); !slices.Equal(examples, []string{"cheap followers"}) {
+				t.Errorf("spamExamples() = %q, want only the other example", examples)
+			}
+		})
+	}
+}
+
+func TestPromptStripsExamplesPlaceholderWhenDisabled(t *testing.T) {
+	config := testConfig()
+	config.Prompt = "Examples: {{SPAM_EXAMPLES}} Message: {{MESSAGE}}"
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	prompt := b.promptFor(context.Background(), textMessage(10, "hello"), testUserID)
+
+	if strings.Contains(prompt, "{{SPAM_EXAMPLES}}") {
+		t.Errorf("promptFor() = %q, the examples placeholder is left in", prompt)
+	}
+}
@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Feedback counters kept per chat until the learning mode consumes them
+const (
+	feedbackDetections     = "detections"      // Messages the bot deleted or whose senders it restricted on its own
+	feedbackFalsePositives = "false_positives" // Flagged messages admins marked as not spam
+	feedbackFalseNegatives = "false_negatives" // Missed messages admins marked as spam
+)
+
+// reportedSpamLabel labels actions taken on messages admins reported with /spam, which aren't detections
+const reportedSpamLabel = "Reported spam"
+
+func (b *Bot) feedbackKey(chatID int64) string {
+	return b.key("feedback:%d", chatID)
+}
+
+func (b *Bot) recordFeedback(ctx context.Context, chatID int64, field string) {
+	if err := b.redis.HIncrBy(ctx, b.feedbackKey(chatID), field, 1).Err(); err != nil {
+		b.logger.Error("Failed to record feedback", "error", err, "chatID", chatID, "field", field)
+	}
+}
+
+// feedbackChat resolves the working chat an admin's feedback is about. Feedback
+// is given either in the working chat itself or in its log channel; a log
+// channel shared by several chats needs the chat ID as the command argument.
+func (b *Bot) feedbackChat(message *tgbotapi.Message) (int64, error) {
+	if arg := strings.TrimSpace(message.CommandArguments()); arg != "" {
+		chatID, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid chat ID: %s", arg)
+		}
+		if logChannelID, exists := b.logChannel(chatID); !exists || logChannelID != message.Chat.ID {
+			return 0, fmt.Errorf("chat %d doesn't log to this channel", chatID)
+		}
+		return chatID, nil
+	}
+
+	if _, exists := b.logChannel(message.Chat.ID); exists || !b.isLogChannel(message.Chat.ID) {
+		return message.Chat.ID, nil
+	}
+
+	chatID, ok := b.soleLoggedChat(message.Chat.ID)
+	if !ok {
+		return 0, fmt.Errorf("this channel logs several chats, pass the chat ID as an argument")
+	}
+	return chatID, nil
+}
+
+// soleLoggedChat returns the working chat of a log channel, if it logs only one
+func (b *Bot) soleLoggedChat(logChannelID int64) (int64, bool) {
+	var chats []int64
+	for workingChatID, channelID := range b.config.LogChannels {
+		if channelID == logChannelID {
+			chats = append(chats, workingChatID)
+		}
+	}
+	// The default log channel may log any chat
+	if len(chats) != 1 || logChannelID == b.config.DefaultLogChannel {
+		return 0, false
+	}
+	return chats[0], true
+}
+
+// logChannel returns the channel notifications about a chat go to: its own log
+// channel, else DefaultLogChannel
+func (b *Bot) logChannel(chatID int64) (int64, bool) {
+	if logChannelID, exists := b.config.LogChannels[chatID]; exists {
+		return logChannelID, true
+	}
+	if b.config.DefaultLogChannel != 0 && chatID != b.config.DefaultLogChannel && !b.isLogChannel(chatID) {
+		return b.config.DefaultLogChannel, true
+	}
+	return 0, false
+}
+
+func (b *Bot) isLogChannel(chatID int64) bool {
+	if chatID == b.config.DefaultLogChannel && chatID != 0 {
+		return true
+	}
+	for _, logChannelID := range b.config.LogChannels {
+		if logChannelID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleFeedbackCommand records /spam and /notspam replies from admins. In the
+// working chat /spam also bans the sender; for forwarded copies in the log
+// channel the spam cache is updated so identical messages are handled next time.
+func (b *Bot) handleFeedbackCommand(message *tgbotapi.Message, isSpam bool) {
+	target := message.ReplyToMessage
+	if target == nil {
+		b.reply(message, b.t(message.Chat.ID, "feedback.usage"))
+		return
+	}
+
+	chatID, err := b.feedbackChat(message)
+	if err != nil {
+		b.reply(message, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	text := messageText(target)
+	hash := b.hashMessage(text)
+
+	if !isSpam {
+		b.recordFeedback(ctx, chatID, feedbackFalsePositives)
+		if err := b.removeSpamMessage(ctx, chatID, hash); err != nil {
+			b.logger.Error("Failed to remove message from spam cache", "error", err)
+		}
+		b.logger.Info("Admin marked message as not spam", "chatID", chatID, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "feedback.not_spam"))
+		return
+	}
+
+	b.recordFeedback(ctx, chatID, feedbackFalseNegatives)
+	if text != "" {
+		if err := b.addSpamMessage(ctx, chatID, hash); err != nil {
+			b.logger.Error("Failed to add spam message to cache", "error", err)
+		}
+		b.storeSpamExample(ctx, chatID, hash, text, true)
+		b.addSpamEmbedding(ctx, hash, b.embed(ctx, text))
+	}
+	b.logger.Info("Admin marked message as spam", "chatID", chatID, "admin", commandSender(message))
+
+	if target.Chat.ID == chatID && target.From != nil && target.SenderChat == nil {
+		adminRights := b.checkAdminRights(chatID, b.api.Self.ID)
+		b.enforce(target, chatID, target.From.ID, adminRights, ActionBan, reportedSpamLabel, 1, b.threshold(ctx, chatID))
+	}
+	b.reply(message, b.t(chatID, "feedback.spam"))
+}
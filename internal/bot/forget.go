@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// forgetUser clears what the bot tracks about a user in a chat, so they are treated
+// like a newcomer again: message count, join date, trust, recent messages, flagged
+// evidence, their inviter, the spam count of users they invited, their profile and
+// impersonation scores, clean replies in threads and restrictions waiting for a retry
+func (b *Bot) forgetUser(ctx context.Context, chatID, userID int64) error {
+	keys := []string{
+		b.key("%d:%d", userID, chatID),
+		b.joinedKey(chatID, userID),
+		b.recentMessagesKey(chatID, userID),
+		b.evidenceKey(chatID, userID),
+		b.inviterKey(chatID, userID),
+		b.invitedSpamKey(chatID, userID),
+		b.profileKey(chatID, userID),
+		b.postedTextsKey(chatID, userID),
+		b.impersonationKey(chatID, userID),
+	}
+	iter := b.redis.Scan(ctx, 0, b.key("thread_clean:%d:*:%d", chatID, userID), 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if err := b.dropDeadLetters(ctx, chatID, userID); err != nil {
+		return err
+	}
+
+	pipe := b.redis.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, b.trustedKey(chatID), userID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// handleForgetCommand resets the tracked state of the sender of the replied message: /forget
+func (b *Bot) handleForgetCommand(message *tgbotapi.Message) {
+	target := message.ReplyToMessage
+	if target == nil || target.From == nil || target.SenderChat != nil {
+		b.reply(message, b.t(message.Chat.ID, "forget.usage"))
+		return
+	}
+
+	chatID, userID := message.Chat.ID, target.From.ID
+	if err := b.forgetUser(context.Background(), chatID, userID); err != nil {
+		b.logger.Error("Failed to forget user", "error", err, "chatID", chatID, "userID", userID)
+		b.reply(message, b.t(chatID, "forget.failed"))
+		return
+	}
+	b.logger.Info("Forgot user", "chatID", chatID, "userID", userID, "admin", commandSender(message))
+	b.reply(message, b.t(chatID, "forget.done"))
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestForgetClearsUserState(t *testing.T) {
+	b, messenger, server := newTestBot(t, testConfig(), &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	ctx := context.Background()
+	const otherUserID = 43
+	userKeys := func(userID int64) []string {
+		return []string{
+			b.key("%d:%d", userID, testChatID),
+			b.joinedKey(testChatID, userID),
+			b.recentMessagesKey(testChatID, userID),
+			b.evidenceKey(testChatID, userID),
+			b.inviterKey(testChatID, userID),
+			b.invitedSpamKey(testChatID, userID),
+			b.profileKey(testChatID, userID),
+			b.postedTextsKey(testChatID, userID),
+			b.impersonationKey(testChatID, userID),
+			b.threadCleanKey(testChatID, 100, userID),
+			b.threadCleanKey(testChatID, 200, userID),
+		}
+	}
+	for _, userID := range []int64{testUserID, otherUserID} {
+		for _, key := range userKeys(userID) {
+			server.Set(key, "1")
+		}
+		if err := b.redis.SAdd(ctx, b.trustedKey(testChatID), userID).Err(); err != nil {
+			t.Fatal(err)
+		}
+		b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: testChatID, UserID: userID})
+	}
+	b.pushDeadLetter(ctx, failedAction{Kind: failedRestrict, ChatID: -1002, UserID: testUserID})
+
+	command := commandMessage(testAdminID, "/forget")
+	command.ReplyToMessage = textMessage(10, "hello")
+	b.handleCommand(command)
+
+	for _, key := range userKeys(testUserID) {
+		if server.Exists(key) {
+			t.Errorf("%s was kept", key)
+		}
+	}
+	for _, key := range userKeys(otherUserID) {
+		if !server.Exists(key) {
+			t.Errorf("%s of another user was cleared", key)
+		}
+	}
+	if trusted, _ := b.redis.SIsMember(ctx, b.trustedKey(testChatID), testUserID).Result(); trusted {
+		t.Error("the user is still trusted")
+	}
+	if trusted, _ := b.redis.SIsMember(ctx, b.trustedKey(testChatID), otherUserID).Result(); !trusted {
+		t.Error("another user's trust was cleared")
+	}
+	if retried, _ := b.sweepDeadLetters(ctx, 0); retried != 2 || len(messenger.restricted) != 2 {
+		t.Errorf("retried %d dead-lettered restrictions of users %v, want the other user's and the other chat's", retried, messenger.restricted)
+	}
+}
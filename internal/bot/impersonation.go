@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	impersonationTTL = 7 * 24 * time.Hour // Each user is reported once per chat within this time
+	minAliasLength   = 4                  // Shorter normalized names, such as initials, match too easily
+)
+
+// lookalikes maps Cyrillic letters and digits impersonators swap in for the Latin letters they resemble
+var lookalikes = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's',
+	'0': 'o', '1': 'l', '3': 'e', '5': 's', '@': 'a',
+}
+
+func (b *Bot) impersonationKey(chatID, userID int64) string {
+	return b.key("impersonation:%d:%d", chatID, userID)
+}
+
+// normalizeAlias lowercases a name, maps look-alike characters to Latin letters and
+// drops everything but letters and digits, so "Аdmin_Bob ✅" and "admin bob" are equal
+func normalizeAlias(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if mapped, ok := lookalikes[r]; ok {
+			r = mapped
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// nameSimilarity returns 1 minus the edit distance of two normalized names relative to the longer one
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance of two rune slices
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// impersonatedAdmin returns the admin alias most similar to any of the user's names and
+// whether it reaches the threshold. Names too short to tell apart are ignored.
+func impersonatedAdmin(names []string, admins map[int64][]string, threshold float64) (string, float64, bool) {
+	var best string
+	var bestSimilarity float64
+	for _, name := range names {
+		normalized := normalizeAlias(name)
+		if len([]rune(normalized)) < minAliasLength {
+			continue
+		}
+		for _, aliases := range admins {
+			for _, alias := range aliases {
+				normalizedAlias := normalizeAlias(alias)
+				if len([]rune(normalizedAlias)) < minAliasLength {
+					continue
+				}
+				if similarity := nameSimilarity(normalized, normalizedAlias); similarity > bestSimilarity {
+					best, bestSimilarity = alias, similarity
+				}
+			}
+		}
+	}
+	return best, bestSimilarity, bestSimilarity >= threshold
+}
+
+// screenImpersonation reports a new member whose name or username closely matches
+// one of the chat's administrators to the log channel for review. Impersonators
+// often only message members privately, so users are screened when they join as
+// well as on their first messages; each user is reported once.
+func (b *Bot) screenImpersonation(chatID int64, user tgbotapi.User) {
+	if b.config.ImpersonationSimilarity <= 0 || user.IsBot {
+		return
+	}
+	if len(b.whitelistChannels) > 0 && !b.whitelistChannels[chatID] {
+		return
+	}
+	admins := b.adminNames(chatID)
+	if _, isAdmin := admins[user.ID]; isAdmin || len(admins) == 0 {
+		return
+	}
+	names := []string{strings.TrimSpace(user.FirstName + " " + user.LastName)}
+	if user.UserName != "" {
+		names = append(names, user.UserName)
+	}
+	alias, similarity, matched := impersonatedAdmin(names, admins, b.config.ImpersonationSimilarity)
+	if !matched {
+		return
+	}
+
+	ctx := context.Background()
+	claimed, err := b.redis.SetNX(ctx, b.impersonationKey(chatID, user.ID), similarity, impersonationTTL).Result()
+	if err != nil {
+		b.logger.Error("Failed to claim impersonation report", "error", err, "chatID", chatID, "userID", user.ID)
+		return
+	}
+	if !claimed {
+		return
+	}
+	b.logger.Info("Possible admin impersonation", "chatID", chatID, "userID", user.ID, "admin", alias, "similarity", similarity)
+	if logChannelID, exists := b.logChannel(chatID); exists {
+		text := b.t(chatID, "notify.impersonation", alias, similarity, user.ID, chatID, profileText(user, ""))
+		b.sendLog(logChannelID, truncate(text, maxNotificationChars))
+	}
+}
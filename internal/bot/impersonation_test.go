@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"math"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestNormalizeAlias(t *testing.T) {
+	tests := map[string]string{
+		"Admin Bob":     "adminbob",
+		"Аdmin_Bob ✅":   "adminbob", // Cyrillic А
+		"@d3v_team":     "adevteam",
+		"   ":           "",
+		"Ivan Petrov 1": "ivanpetrovl",
+	}
+	for name, want := range tests {
+		if got := normalizeAlias(name); got != want {
+			t.Errorf("normalizeAlias(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"adminbob", "adminbob", 1},
+		{"adminbob", "admlnbob", 1 - 1.0/8},
+		{"adminbob", "adminbobb", 1 - 1.0/9},
+		{"alice", "bob", 0},
+		{"", "", 0},
+		{"", "abc", 0},
+	}
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("nameSimilarity(%q, %q) = %f, want %f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestImpersonatedAdmin(t *testing.T) {
+	admins := map[int64][]string{
+		1: {"Support Team", "support_team"},
+		2: {"Jo", "jo"}, // Too short to compare
+	}
+	tests := []struct {
+		name    string
+		names   []string
+		alias   string
+		matched bool
+	}{
+		{"exact copy", []string{"Support Team"}, "Support Team", true},
+		{"look-alike letters", []string{"Suppоrt Теam"}, "Support Team", true},
+		{"username copy", []string{"Mallory", "support_teem"}, "Support Team", true},
+		{"different name", []string{"Carol Smith"}, "", false},
+		{"short admin name", []string{"Jo"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alias, similarity, matched := impersonatedAdmin(tt.names, admins, 0.85)
+			if matched != tt.matched || (matched && alias != tt.alias) {
+				t.Errorf("impersonatedAdmin(%q) = %q, %.2f, %t, want %q, %t", tt.names, alias, similarity, matched, tt.alias, tt.matched)
+			}
+		})
+	}
+}
+
+func TestScreenImpersonationReportsOnce(t *testing.T) {
+	config := testConfig()
+	config.ImpersonationSimilarity = 0.85
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Support Team", Username: "support_team"}}
+	impersonator := tgbotapi.User{ID: testUserID, FirstName: "Support", LastName: "Теam"}
+
+	b.screenImpersonation(testChatID, impersonator)
+	b.screenImpersonation(testChatID, impersonator)
+	b.screenImpersonation(testChatID, tgbotapi.User{ID: testAdminID, FirstName: "Support", LastName: "Team"})
+
+	if sent := messenger.sentTexts(); len(sent) != 1 {
+		t.Errorf("sent %q, want the impersonator reported once", sent)
+	}
+}
@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// inviterTTL bounds how long an inviter is remembered and their spam invitations are counted
+const inviterTTL = 30 * 24 * time.Hour
+
+func (b *Bot) inviterKey(chatID, userID int64) string {
+	return b.key("inviter:%d:%d", chatID, userID)
+}
+
+func (b *Bot) invitedSpamKey(chatID, inviterID int64) string {
+	return b.key("invited_spam:%d:%d", chatID, inviterID)
+}
+
+// rememberInviter records who added a user to the chat
+func (b *Bot) rememberInviter(ctx context.Context, chatID, userID, inviterID int64) {
+	if b.config.InviterThreshold <= 0 || inviterID == 0 || inviterID == userID {
+		return
+	}
+	if err := b.redis.Set(ctx, b.inviterKey(chatID, userID), inviterID, inviterTTL).Err(); err != nil {
+		b.logger.Error("Failed to remember inviter", "error", err, "chatID", chatID, "userID", userID, "inviterID", inviterID)
+	}
+}
+
+// rememberJoinInviter records the inviter of a user joining in a chat_member update,
+// either whoever added them or the creator of the invite link they used
+func (b *Bot) rememberJoinInviter(update *tgbotapi.ChatMemberUpdated) {
+	if !memberJoined(update) || update.NewChatMember.User == nil {
+		return
+	}
+	userID := update.NewChatMember.User.ID
+	inviterID := update.From.ID
+	if inviterID == userID && update.InviteLink != nil {
+		inviterID = update.InviteLink.Creator.ID
+	}
+	b.rememberInviter(context.Background(), update.Chat.ID, userID, inviterID)
+}
+
+// escalateInviter counts a banned spammer against whoever invited them and
+// applies the inviter action once the inviter reaches the threshold.
+// Admins are never escalated, as they create most invite links.
+func (b *Bot) escalateInviter(ctx context.Context, chatID, userID int64, adminRights AdminRights) {
+	if b.config.InviterThreshold <= 0 {
+		return
+	}
+	inviterID, err := b.redis.Get(ctx, b.inviterKey(chatID, userID)).Int64()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get inviter", "error", err, "chatID", chatID, "userID", userID)
+		}
+		return
+	}
+	// Each spammer counts once against their inviter
+	if err := b.redis.Del(ctx, b.inviterKey(chatID, userID)).Err(); err != nil {
+		b.logger.Error("Failed to forget inviter", "error", err, "chatID", chatID, "userID", userID)
+	}
+	if b.isChatAdmin(chatID, inviterID) {
+		return
+	}
+
+	key := b.invitedSpamKey(chatID, inviterID)
+	count, err := b.redis.Incr(ctx, key).Result()
+	if err != nil {
+		b.logger.Error("Failed to count invited spammers", "error", err, "chatID", chatID, "inviterID", inviterID)
+		return
+	}
+	if count == 1 {
+		if err := b.redis.Expire(ctx, key, inviterTTL).Err(); err != nil {
+			b.logger.Error("Failed to set invited spammers expiry", "error", err, "chatID", chatID, "inviterID", inviterID)
+		}
+	}
+	b.logger.Info("Counted spammer against inviter", "chatID", chatID, "userID", userID, "inviterID", inviterID, "count", count)
+	if count != int64(b.config.InviterThreshold) {
+		return
+	}
+
+	action, adminRights := b.effectiveEnforcement(ctx, chatID, b.config.InviterAction, adminRights)
+	summary := fmt.Sprintf("📨 Inviter of %d spammers detected and logged", count)
+	switch {
+	case action.severity() >= ActionBan.severity() && adminRights.CanRestrictMembers:
+		if err := b.restrictUser(chatID, inviterID); err != nil {
+			b.logger.Error("Failed to ban inviter", "error", err, "chatID", chatID, "inviterID", inviterID)
+		} else {
+			summary = fmt.Sprintf("📨 Inviter of %d spammers banned", count)
+			b.logger.Info("Banned inviter", "chatID", chatID, "inviterID", inviterID)
+		}
+	case action == ActionMute && adminRights.CanRestrictMembers:
+		if err := b.muteUser(chatID, inviterID, time.Now().Add(b.config.MuteDuration)); err != nil {
+			b.logger.Error("Failed to mute inviter", "error", err, "chatID", chatID, "inviterID", inviterID)
+		} else {
+			summary = fmt.Sprintf("📨 Inviter of %d spammers muted for %s", count, b.config.MuteDuration)
+			b.logger.Info("Muted inviter", "chatID", chatID, "inviterID", inviterID, "duration", b.config.MuteDuration)
+		}
+	}
+	if logChannelID, exists := b.logChannel(chatID); exists {
+		b.sendLog(logChannelID, fmt.Sprintf("%s\nInviter ID: %d\nLast spammer ID: %d\nChannel ID: %d", summary, inviterID, userID, chatID))
+	}
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// JoinRequestPolicy controls how chat join requests are handled
+type JoinRequestPolicy string
+
+const (
+	JoinRequestPolicyOff               JoinRequestPolicy = "off"                // Leave all requests to the admins
+	JoinRequestPolicyDeclineSuspicious JoinRequestPolicy = "decline-suspicious" // Auto-decline requests matching the spam heuristics
+)
+
+func ParseJoinRequestPolicy(value string) (JoinRequestPolicy, error) {
+	switch policy := JoinRequestPolicy(value); policy {
+	case JoinRequestPolicyOff, JoinRequestPolicyDeclineSuspicious:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown join request policy: %s", value)
+	}
+}
+
+func (b *Bot) handleJoinRequest(request *tgbotapi.ChatJoinRequest) {
+	if b.config.JoinRequestPolicy != JoinRequestPolicyDeclineSuspicious {
+		return
+	}
+	if len(b.whitelistChannels) > 0 && !b.whitelistChannels[request.Chat.ID] {
+		return
+	}
+
+	reason := b.suspiciousJoinReason(context.Background(), &request.From)
+	if reason == "" {
+		b.logger.Debug("Passing join request through", "userID", request.From.ID, "chatID", request.Chat.ID)
+		return
+	}
+
+	if err := b.messenger.DeclineJoinRequest(request.Chat.ID, request.From.ID); err != nil {
+		b.logger.Error("Failed to decline join request", "error", err, "userID", request.From.ID, "chatID", request.Chat.ID)
+		return
+	}
+	b.logger.Info("Declined join request", "userID", request.From.ID, "chatID", request.Chat.ID, "reason", reason)
+
+	if logChannelID, exists := b.logChannel(request.Chat.ID); exists {
+		b.sendLog(logChannelID, fmt.Sprintf("🚪 Join request declined\nUser ID: %d\nChannel ID: %d\nReason: %s", request.From.ID, request.Chat.ID, reason))
+	}
+}
+
+// suspiciousJoinReason returns why a joining user looks like a spam bot, or "" if they don't
+func (b *Bot) suspiciousJoinReason(ctx context.Context, user *tgbotapi.User) string {
+	if b.cas != nil {
+		banned, err := b.cas.IsBanned(ctx, user.ID)
+		if err != nil {
+			b.logger.Warn("CAS check failed", "error", err, "userID", user.ID)
+		} else if banned {
+			return "CAS-banned"
+		}
+	}
+
+	if user.UserName == "" && b.isNewAccount(user.ID) {
+		return "no username and recently created account"
+	}
+	return ""
+}
+
+// isNewAccount estimates from the user ID whether the account was created recently
+func (b *Bot) isNewAccount(userID int64) bool {
+	return b.config.NewAccountID > 0 && userID >= b.config.NewAccountID
+}
@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// LabelRule maps a classifier label (e.g. "scam", "nsfw") to its own threshold and action
+type LabelRule struct {
+	Name      string
+	Threshold float64
+	Action    Action
+}
+
+// matchLabel returns the strictest rule whose label score exceeds its threshold
+func (b *Bot) matchLabel(result *ai.Result) (LabelRule, float64, bool) {
+	var (
+		matched LabelRule
+		score   float64
+		found   bool
+	)
+	for _, rule := range b.config.Labels {
+		labelScore, exists := result.Labels[rule.Name]
+		if !exists || labelScore <= rule.Threshold {
+			continue
+		}
+		if !found || rule.Action.severity() > matched.Action.severity() {
+			matched, score, found = rule, labelScore, true
+		}
+	}
+	return matched, score, found
+}
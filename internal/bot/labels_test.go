@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+func TestLabelRouting(t *testing.T) {
+	config := testConfig()
+	config.Labels = []LabelRule{
+		{Name: "scam", Threshold: 0.7, Action: ActionBan},
+		{Name: "nsfw", Threshold: 0.6, Action: ActionDelete},
+		{Name: "offtopic", Threshold: 0.8, Action: ActionNotify},
+	}
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	tests := []struct {
+		name     string
+		response string
+		want     verdict
+	}{
+		{
+			name:     "spam score decides first",
+			response: `{"reasoning": "", "spam_score": 0.9, "scam_score": 0.95}`,
+			want:     verdict{Action: ActionBan, Label: spamLabel, Score: 0.9, Threshold: 0.5},
+		},
+		{
+			name:     "label above its threshold",
+			response: `{"reasoning": "", "spam_score": 0.1, "nsfw_score": 0.65}`,
+			want:     verdict{Action: ActionDelete, Label: "nsfw", Score: 0.65, Threshold: 0.6},
+		},
+		{
+			name:     "strictest matching label",
+			response: `{"reasoning": "", "spam_score": 0.1, "offtopic_score": 0.9, "nsfw_score": 0.7, "scam_score": 0.75}`,
+			want:     verdict{Action: ActionBan, Label: "scam", Score: 0.75, Threshold: 0.7},
+		},
+		{
+			name:     "labels at their thresholds",
+			response: `{"reasoning": "", "spam_score": 0.1, "scam_score": 0.7, "offtopic_score": 0.8}`,
+			want:     verdict{Score: 0.1, Threshold: 0.5},
+		},
+		{
+			name:     "unconfigured label",
+			response: `{"reasoning": "", "spam_score": 0.1, "crypto_score": 1}`,
+			want:     verdict{Score: 0.1, Threshold: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ai.ParseResponse(tt.response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := b.decide(&result, 0.5, 0, false); got != tt.want {
+				t.Errorf("decide() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	result, err := ai.ParseResponse(`{"reasoning": "", "spam_score": 0.1, "offtopic_score": 0.9}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.decide(&result, 0.5, 0, true); got.Action != ActionBan || got.Label != "offtopic" {
+		t.Errorf("decide() during a raid = %+v, want the label's sender banned", got)
+	}
+}
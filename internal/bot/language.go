@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// minLanguageLetters keeps short messages and single foreign words from counting as off-language
+const minLanguageLetters = 10
+
+// languageScripts maps language codes to the script they are written in. Detection
+// works on scripts, so languages sharing one (e.g. English and German) can't be told apart.
+var languageScripts = map[string]*unicode.RangeTable{
+	"en": unicode.Latin, "de": unicode.Latin, "fr": unicode.Latin, "es": unicode.Latin, "it": unicode.Latin,
+	"pt": unicode.Latin, "nl": unicode.Latin, "pl": unicode.Latin, "tr": unicode.Latin, "cs": unicode.Latin,
+	"ro": unicode.Latin, "hu": unicode.Latin, "sv": unicode.Latin, "fi": unicode.Latin, "id": unicode.Latin,
+	"vi": unicode.Latin, "uz": unicode.Latin, "az": unicode.Latin,
+	"ru": unicode.Cyrillic, "uk": unicode.Cyrillic, "be": unicode.Cyrillic, "bg": unicode.Cyrillic,
+	"sr": unicode.Cyrillic, "kk": unicode.Cyrillic, "mk": unicode.Cyrillic,
+	"ar": unicode.Arabic, "fa": unicode.Arabic, "ur": unicode.Arabic,
+	"he": unicode.Hebrew, "el": unicode.Greek, "hy": unicode.Armenian, "ka": unicode.Georgian,
+	"hi": unicode.Devanagari, "th": unicode.Thai, "ko": unicode.Hangul, "zh": unicode.Han, "ja": unicode.Han,
+}
+
+// scripts lists the scripts of languageScripts once, for detection
+var scripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Arabic, unicode.Hebrew, unicode.Greek, unicode.Armenian,
+	unicode.Georgian, unicode.Devanagari, unicode.Thai, unicode.Hangul, unicode.Han,
+}
+
+func (b *Bot) languagesKey(chatID int64) string {
+	return b.key("languages:%d", chatID)
+}
+
+// allowedLanguages returns the languages expected in the chat, none if any language is fine
+func (b *Bot) allowedLanguages(ctx context.Context, chatID int64) []string {
+	languages, err := b.redis.SMembers(ctx, b.languagesKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get chat languages", "error", err, "chatID", chatID)
+		return nil
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// dominantScript returns the script most letters of the text are written in,
+// or nil if the text has too few letters to tell
+func dominantScript(text string) *unicode.RangeTable {
+	counts := make(map[*unicode.RangeTable]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scripts {
+			if unicode.Is(script, r) {
+				counts[script]++
+				break
+			}
+		}
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			counts[unicode.Han]++ // Japanese mixes kana with kanji
+		}
+	}
+	if letters < minLanguageLetters {
+		return nil
+	}
+
+	var dominant *unicode.RangeTable
+	for script, count := range counts {
+		if dominant == nil || count > counts[dominant] {
+			dominant = script
+		}
+	}
+	if dominant == nil {
+		return unicode.Other // Letters of a script no supported language uses
+	}
+	return dominant
+}
+
+// offLanguage reports whether the text is written in a script none of the languages use
+func offLanguage(text string, languages []string) bool {
+	if len(languages) == 0 {
+		return false
+	}
+	script := dominantScript(text)
+	if script == nil {
+		return false
+	}
+	for _, language := range languages {
+		if languageScripts[language] == script {
+			return false
+		}
+	}
+	return true
+}
+
+// handleLanguagesCommand shows, sets or clears the chat's expected languages: /languages [codes...|off]
+func (b *Bot) handleLanguagesCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	args := strings.Fields(strings.ToLower(message.CommandArguments()))
+
+	switch {
+	case len(args) == 0:
+		languages := b.allowedLanguages(ctx, chatID)
+		if len(languages) == 0 {
+			b.reply(message, "Languages: any. Set the expected ones with /languages en ru ...")
+			return
+		}
+		b.reply(message, fmt.Sprintf("Languages: %s", strings.Join(languages, ", ")))
+	case len(args) == 1 && args[0] == "off":
+		if err := b.redis.Del(ctx, b.languagesKey(chatID)).Err(); err != nil {
+			b.logger.Error("Failed to clear chat languages", "error", err, "chatID", chatID)
+			b.reply(message, "Failed to clear the languages")
+			return
+		}
+		b.logger.Info("Cleared chat languages", "chatID", chatID, "admin", commandSender(message))
+		b.reply(message, "Languages cleared, messages in any language are treated alike")
+	default:
+		members := make([]any, 0, len(args))
+		for _, language := range args {
+			if _, ok := languageScripts[language]; !ok {
+				b.reply(message, fmt.Sprintf("Unknown language %q, use two-letter codes such as en, ru or zh", language))
+				return
+			}
+			members = append(members, language)
+		}
+		pipe := b.redis.TxPipeline()
+		pipe.Del(ctx, b.languagesKey(chatID))
+		pipe.SAdd(ctx, b.languagesKey(chatID), members...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			b.logger.Error("Failed to set chat languages", "error", err, "chatID", chatID)
+			b.reply(message, "Failed to set the languages")
+			return
+		}
+		b.logger.Info("Set chat languages", "chatID", chatID, "languages", args, "admin", commandSender(message))
+		b.reply(message, fmt.Sprintf("Languages set to %s", strings.Join(args, ", ")))
+	}
+}
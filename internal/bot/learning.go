@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minLearningFeedback is the number of feedback events a chat needs before its threshold is adjusted
+const minLearningFeedback = 5
+
+// learningRoutine periodically nudges per-chat thresholds towards the target precision
+func (b *Bot) learningRoutine() {
+	ticker := time.NewTicker(b.config.LearningInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.adjustThresholds(context.Background())
+		case <-b.stopChan:
+			return
+		}
+	}
+}
+
+func (b *Bot) adjustThresholds(ctx context.Context) {
+	prefix := b.key("feedback:")
+	iter := b.redis.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		chatID, err := strconv.ParseInt(strings.TrimPrefix(iter.Val(), prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		b.adjustThreshold(ctx, chatID)
+	}
+	if err := iter.Err(); err != nil {
+		b.logger.Error("Failed to scan feedback", "error", err)
+	}
+}
+
+// adjustThreshold moves the chat threshold by one bounded step based on the
+// feedback accumulated since the last adjustment, then resets the feedback
+func (b *Bot) adjustThreshold(ctx context.Context, chatID int64) {
+	counts, err := b.redis.HGetAll(ctx, b.feedbackKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to load feedback", "error", err, "chatID", chatID)
+		return
+	}
+	detections, _ := strconv.Atoi(counts[feedbackDetections])
+	falsePositives, _ := strconv.Atoi(counts[feedbackFalsePositives])
+	falseNegatives, _ := strconv.Atoi(counts[feedbackFalseNegatives])
+	if falsePositives+falseNegatives < minLearningFeedback {
+		return
+	}
+
+	current := b.threshold(ctx, chatID)
+	next := nextThreshold(current, detections, falsePositives, falseNegatives, b.config.LearningTargetPrecision, b.config.LearningStep)
+
+	if err := b.redis.Del(ctx, b.feedbackKey(chatID)).Err(); err != nil {
+		b.logger.Error("Failed to reset feedback", "error", err, "chatID", chatID)
+	}
+	if next == current {
+		return
+	}
+	if err := b.setThreshold(ctx, chatID, next); err != nil {
+		b.logger.Error("Failed to adjust chat threshold", "error", err, "chatID", chatID)
+		return
+	}
+	b.logger.Info("Adjusted chat threshold from feedback",
+		"chatID", chatID,
+		"from", current,
+		"to", next,
+		"detections", detections,
+		"falsePositives", falsePositives,
+		"falseNegatives", falseNegatives)
+}
+
+// nextThreshold raises the threshold when precision is below the target and
+// lowers it when the target is met and spam is being missed without false positives
+func nextThreshold(current float64, detections, falsePositives, falseNegatives int, targetPrecision, step float64) float64 {
+	// /notspam may count false positives on messages that weren't detections
+	flagged := max(detections, falsePositives)
+	precision := 1.0
+	if flagged > 0 {
+		precision = float64(flagged-falsePositives) / float64(flagged)
+	}
+
+	next := current
+	switch {
+	case precision < targetPrecision:
+		next = current + step
+	case falseNegatives > 0 && falsePositives == 0:
+		next = current - step
+	}
+	next = math.Max(minChatThreshold, math.Min(maxChatThreshold, next))
+	return math.Round(next*100) / 100
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNextThreshold(t *testing.T) {
+	tests := []struct {
+		name                                       string
+		detections, falsePositives, falseNegatives int
+		want                                       float64
+	}{
+		{"precision below target", 10, 3, 0, 0.52},
+		{"more false positives than detections", 2, 6, 0, 0.52},
+		{"false positives without detections", 0, 5, 0, 0.52},
+		{"missed spam only", 10, 0, 5, 0.48},
+		{"missed spam without detections", 0, 0, 5, 0.48},
+		{"missed spam and false positives within target", 40, 1, 8, 0.5},
+		{"missed spam and false positives below target", 10, 4, 20, 0.52},
+		{"no mistakes", 10, 0, 0, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextThreshold(0.5, tt.detections, tt.falsePositives, tt.falseNegatives, 0.9, 0.02); got != tt.want {
+				t.Errorf("nextThreshold() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextThresholdStaysInBounds(t *testing.T) {
+	if got := nextThreshold(maxChatThreshold, 1, 5, 0, 0.9, 0.1); got != maxChatThreshold {
+		t.Errorf("raised to %v, want at most %v", got, maxChatThreshold)
+	}
+	if got := nextThreshold(minChatThreshold, 0, 0, 5, 0.9, 0.1); got != minChatThreshold {
+		t.Errorf("lowered to %v, want at least %v", got, minChatThreshold)
+	}
+}
+
+func TestEnforcementCountsDetections(t *testing.T) {
+	config := testConfig()
+	config.BareLinkAction = ActionDelete
+	b, messenger, server := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+
+	// Acted on by a heuristic without classification
+	handle(b, linkMessage(10, "https://example.com/promo", "https://example.com/promo"))
+	// Enforced on an admin's report, a false negative rather than a detection
+	report := commandMessage(testAdminID, "/spam")
+	report.ReplyToMessage = textMessage(11, "missed promo")
+	b.handleCommand(report)
+
+	if detections := server.HGet(b.feedbackKey(testChatID), feedbackDetections); detections != "1" {
+		t.Errorf("detections = %q, want 1", detections)
+	}
+	if missed := server.HGet(b.feedbackKey(testChatID), feedbackFalseNegatives); missed != "1" {
+		t.Errorf("false negatives = %q, want 1", missed)
+	}
+	if len(messenger.deleted) != 2 {
+		t.Errorf("deleted = %v, want both messages", messenger.deleted)
+	}
+}
+
+func TestAdjustThresholdRaisesOnUndoneActions(t *testing.T) {
+	config := testConfig()
+	config.LearningTargetPrecision = 0.9
+	config.LearningStep = 0.05
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		b.recordFeedback(ctx, testChatID, feedbackFalsePositives)
+		b.recordFeedback(ctx, testChatID, feedbackFalseNegatives)
+	}
+
+	b.adjustThreshold(ctx, testChatID)
+
+	if threshold := b.threshold(ctx, testChatID); threshold != 0.55 {
+		t.Errorf("threshold = %v, want it raised to 0.55 while false positives are reported", threshold)
+	}
+}
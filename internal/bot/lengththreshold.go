@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"context"
+	"unicode/utf8"
+)
+
+// LengthThreshold shifts the spam threshold for messages of MinLength up to, not
+// including, MaxLength characters, e.g. raising it for short messages whose scores
+// are noisy. A MaxLength of 0 means no upper bound.
+type LengthThreshold struct {
+	MinLength int
+	MaxLength int
+	Offset    float64
+}
+
+func (l LengthThreshold) matches(length int) bool {
+	return length >= l.MinLength && (l.MaxLength == 0 || length < l.MaxLength)
+}
+
+// lengthOffset returns the offset of the first length bucket the text falls into
+func (b *Bot) lengthOffset(text string) float64 {
+	length := utf8.RuneCountInString(text)
+	for _, bucket := range b.config.LengthThresholds {
+		if bucket.matches(length) {
+			return bucket.Offset
+		}
+	}
+	return 0
+}
+
+// messageThreshold returns the threshold a message is judged by: the chat's
+// threshold shifted by the message's length bucket, then tightened during a raid
+func (b *Bot) messageThreshold(ctx context.Context, chatID int64, raid bool, text string) float64 {
+	threshold := b.threshold(ctx, chatID)
+	if offset := b.lengthOffset(text); offset != 0 {
+		threshold = max(minChatThreshold, min(maxChatThreshold, threshold+offset))
+	}
+	if raid && b.config.RaidThreshold < threshold {
+		return b.config.RaidThreshold
+	}
+	return threshold
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/locale"
+)
+
+func (b *Bot) localeKey(chatID int64) string {
+	return b.key("locale:%d", chatID)
+}
+
+// chatLocale returns the locale of a chat's replies and notifications: the one set
+// with /locale, else the chat's only supported language from /languages, else the default
+func (b *Bot) chatLocale(ctx context.Context, chatID int64) string {
+	code, err := b.redis.Get(ctx, b.localeKey(chatID)).Result()
+	if err == nil && locale.Supported(code) {
+		return code
+	}
+	if err != nil && err != redis.Nil {
+		b.logger.Error("Failed to get chat locale", "error", err, "chatID", chatID)
+	}
+
+	detected := ""
+	for _, language := range b.allowedLanguages(ctx, chatID) {
+		if !locale.Supported(language) {
+			continue
+		}
+		if detected != "" {
+			detected = "" // Several candidates, none is the obvious choice
+			break
+		}
+		detected = language
+	}
+	if detected != "" {
+		return detected
+	}
+	return b.defaultLocale()
+}
+
+func (b *Bot) defaultLocale() string {
+	if locale.Supported(b.config.Locale) {
+		return b.config.Locale
+	}
+	return locale.Default
+}
+
+// t formats a message from the catalog of the chat's locale
+func (b *Bot) t(chatID int64, key string, args ...any) string {
+	return locale.T(b.chatLocale(context.Background(), chatID), key, args...)
+}
+
+// handleLocaleCommand shows, sets or resets the chat's locale: /locale [code|reset]
+func (b *Bot) handleLocaleCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	supported := strings.Join(locale.Codes(), ", ")
+
+	switch arg := strings.ToLower(strings.TrimSpace(message.CommandArguments())); {
+	case arg == "":
+		code := b.chatLocale(ctx, chatID)
+		b.reply(message, locale.T(code, "locale.show", code, supported))
+	case arg == "reset":
+		if err := b.redis.Del(ctx, b.localeKey(chatID)).Err(); err != nil {
+			b.logger.Error("Failed to reset chat locale", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "locale.set_failed"))
+			return
+		}
+		b.logger.Info("Reset chat locale", "chatID", chatID, "admin", commandSender(message))
+		code := b.chatLocale(ctx, chatID)
+		b.reply(message, locale.T(code, "locale.reset", code))
+	case locale.Supported(arg):
+		if err := b.redis.Set(ctx, b.localeKey(chatID), arg, 0).Err(); err != nil {
+			b.logger.Error("Failed to set chat locale", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "locale.set_failed"))
+			return
+		}
+		b.logger.Info("Set chat locale", "chatID", chatID, "locale", arg, "admin", commandSender(message))
+		b.reply(message, locale.T(arg, "locale.set", arg))
+	default:
+		b.reply(message, b.t(chatID, "locale.usage", strings.Join(locale.Codes(), "|")))
+	}
+}
@@ -0,0 +1,66 @@
+package bot
+
+import "time"
+
+// Messenger performs the bot's actions on a chat platform. The moderation core
+// only talks to the platform through it, so other platforms can be adapted by
+// implementing it; Telegram is the only implementation so far.
+type Messenger interface {
+	// Send posts a message, optionally replying to another one or with a button,
+	// and returns the ID of the sent message
+	Send(message OutgoingMessage) (int, error)
+	// Forward copies a message to another chat, keeping its original sender
+	Forward(toChatID, fromChatID int64, messageID int) error
+	// Edit replaces the text of a message sent by the bot, dropping its button
+	Edit(chatID int64, messageID int, text string) error
+	Delete(chatID int64, messageID int) error
+	// DeleteMany deletes up to 100 messages of a chat in one request, skipping ones that are already gone
+	DeleteMany(chatID int64, messageIDs []int) error
+	// Restrict stops a user from posting until the given time, permanently if it is zero
+	Restrict(chatID, userID int64, until time.Time) error
+	// Unrestrict lifts a user's restrictions, including a ban applied by Restrict
+	Unrestrict(chatID, userID int64) error
+	DeclineJoinRequest(chatID, userID int64) error
+	// AnswerCallback acknowledges a button press, showing text to the user if set
+	AnswerCallback(callbackID, text string) error
+	Member(chatID, userID int64) (ChatMember, error)
+	Administrators(chatID int64) ([]Administrator, error)
+	// Bio returns the profile bio of a user, empty if they have none or hide it
+	Bio(userID int64) (string, error)
+	// SetAdminCommands publishes the command menu shown to chat administrators
+	SetAdminCommands(commands []Command) error
+}
+
+// OutgoingMessage is a message sent by the bot
+type OutgoingMessage struct {
+	ChatID  int64
+	Text    string
+	ReplyTo int     // Message replied to, if non-zero
+	Button  *Button // Optional button sending Data back when pressed
+}
+
+// Button is an inline button attached to a message
+type Button struct {
+	Text string
+	Data string
+}
+
+// Administrator is an administrator of a chat as shown to its members
+type Administrator struct {
+	ID       int64
+	Name     string
+	Username string
+}
+
+// ChatMember describes a user's role and rights in a chat
+type ChatMember struct {
+	IsAdmin            bool
+	CanDeleteMessages  bool
+	CanRestrictMembers bool
+}
+
+// Command is an entry of the bot's command menu
+type Command struct {
+	Name        string
+	Description string
+}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+const (
+	// Entries carry the hash of their message; the older spam_embeddings list without them is left to expire
+	spamEmbeddingsKey = "spam_hash_embeddings"
+	spamEmbeddingsTTL = 30 * 24 * time.Hour
+)
+
+// spamEmbeddings keeps the embeddings of recent spam in memory, backed by a bounded Redis list
+type spamEmbeddings struct {
+	mutex   sync.Mutex
+	loaded  bool
+	entries []spamEmbedding // Newest first
+}
+
+// spamEmbedding is the embedding of a spam message with the hash it is cached under,
+// so it can be forgotten when the message turns out not to be spam
+type spamEmbedding struct {
+	hash   string
+	vector []float32
+}
+
+// embed computes the embedding of a scanned message, or nil if near-duplicate
+// detection is disabled or the embedding can't be computed
+func (b *Bot) embed(ctx context.Context, text string) []float32 {
+	if b.config.Embedder == nil || b.aiPaused.Load() {
+		return nil
+	}
+	embedding, err := b.config.Embedder.Embed(ctx, text)
+	if err != nil {
+		b.logger.Warn("Failed to compute message embedding", "error", err)
+		return nil
+	}
+	return embedding
+}
+
+// nearDuplicateSpam returns the highest similarity of the embedding to known
+// spam and whether it reaches the near-duplicate threshold
+func (b *Bot) nearDuplicateSpam(ctx context.Context, embedding []float32) (float64, bool) {
+	if embedding == nil {
+		return 0, false
+	}
+	b.spamEmbeddings.mutex.Lock()
+	defer b.spamEmbeddings.mutex.Unlock()
+	b.loadSpamEmbeddings(ctx)
+
+	best := 0.0
+	for _, entry := range b.spamEmbeddings.entries {
+		best = math.Max(best, ai.CosineSimilarity(embedding, entry.vector))
+	}
+	return best, best >= b.config.NearDuplicateThreshold
+}
+
+// addSpamEmbedding remembers the embedding of a detected spam message
+func (b *Bot) addSpamEmbedding(ctx context.Context, hash string, embedding []float32) {
+	if embedding == nil {
+		return
+	}
+	b.spamEmbeddings.mutex.Lock()
+	defer b.spamEmbeddings.mutex.Unlock()
+	b.loadSpamEmbeddings(ctx)
+
+	entries := append([]spamEmbedding{{hash: hash, vector: embedding}}, b.spamEmbeddings.entries...)
+	if len(entries) > b.config.NearDuplicateMax {
+		entries = entries[:b.config.NearDuplicateMax]
+	}
+	b.spamEmbeddings.entries = entries
+
+	key := b.key(spamEmbeddingsKey)
+	pipe := b.redis.TxPipeline()
+	pipe.LPush(ctx, key, encodeSpamEmbedding(hash, embedding))
+	pipe.LTrim(ctx, key, 0, int64(b.config.NearDuplicateMax)-1)
+	pipe.Expire(ctx, key, spamEmbeddingsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to store spam embedding", "error", err)
+	}
+}
+
+// removeSpamEmbeddings forgets the embeddings of messages with the given hashes
+func (b *Bot) removeSpamEmbeddings(ctx context.Context, hashes ...string) {
+	if b.config.Embedder == nil || len(hashes) == 0 {
+		return
+	}
+	removed := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		removed[hash] = true
+	}
+	b.spamEmbeddings.mutex.Lock()
+	defer b.spamEmbeddings.mutex.Unlock()
+	b.loadSpamEmbeddings(ctx)
+
+	kept := b.spamEmbeddings.entries[:0]
+	for _, entry := range b.spamEmbeddings.entries {
+		if !removed[entry.hash] {
+			kept = append(kept, entry)
+		}
+	}
+	b.spamEmbeddings.entries = kept
+
+	key := b.key(spamEmbeddingsKey)
+	stored, err := b.redis.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		b.logger.Error("Failed to load spam embeddings", "error", err)
+		return
+	}
+	pipe := b.redis.Pipeline()
+	for _, data := range stored {
+		if hash, _ := decodeSpamEmbedding([]byte(data)); removed[hash] {
+			pipe.LRem(ctx, key, 0, data)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to remove spam embeddings", "error", err)
+	}
+}
+
+// clearSpamEmbeddings forgets every spam embedding
+func (b *Bot) clearSpamEmbeddings(ctx context.Context) error {
+	b.spamEmbeddings.mutex.Lock()
+	defer b.spamEmbeddings.mutex.Unlock()
+	b.spamEmbeddings.entries = nil
+	b.spamEmbeddings.loaded = true
+	return b.redis.Del(ctx, b.key(spamEmbeddingsKey)).Err()
+}
+
+// loadSpamEmbeddings reads the stored embeddings once; the caller holds the mutex
+func (b *Bot) loadSpamEmbeddings(ctx context.Context) {
+	if b.spamEmbeddings.loaded {
+		return
+	}
+	stored, err := b.redis.LRange(ctx, b.key(spamEmbeddingsKey), 0, int64(b.config.NearDuplicateMax)-1).Result()
+	if err != nil {
+		b.logger.Error("Failed to load spam embeddings", "error", err)
+		return
+	}
+	for _, data := range stored {
+		hash, vector := decodeSpamEmbedding([]byte(data))
+		b.spamEmbeddings.entries = append(b.spamEmbeddings.entries, spamEmbedding{hash: hash, vector: vector})
+	}
+	b.spamEmbeddings.loaded = true
+}
+
+// encodeSpamEmbedding stores the message hash, a colon and the embedding
+func encodeSpamEmbedding(hash string, embedding []float32) []byte {
+	return append([]byte(hash+":"), encodeEmbedding(embedding)...)
+}
+
+// decodeSpamEmbedding returns the message hash and embedding of a stored entry
+func decodeSpamEmbedding(data []byte) (string, []float32) {
+	hash, embedding, _ := bytes.Cut(data, []byte(":")) // Hex hashes have no colon
+	return string(hash), decodeEmbedding(embedding)
+}
+
+// encodeEmbedding packs an embedding as little-endian float32 values
+func encodeEmbedding(embedding []float32) []byte {
+	data := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+func decodeEmbedding(data []byte) []float32 {
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return embedding
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// fakeEmbedder returns fixed embeddings for known texts
+type fakeEmbedder map[string][]float32
+
+func (e fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embedding, ok := e[text]
+	if !ok {
+		return nil, fmt.Errorf("no embedding for %q", text)
+	}
+	return embedding, nil
+}
+
+const knownSpam = "earn $500 a day from home"
+
+// nearDuplicateBot returns a bot that knows knownSpam and embeds texts with embedder
+func nearDuplicateBot(t *testing.T, embedder fakeEmbedder) (*Bot, *fakeMessenger, *countingProvider) {
+	t.Helper()
+	config := testConfig()
+	config.Embedder = embedder
+	config.NearDuplicateThreshold = 0.9
+	config.NearDuplicateMax = 10
+	provider := &countingProvider{response: `{"reasoning": "", "spam_score": 0}`}
+	b, messenger, _ := newTestBot(t, config, provider)
+	b.addSpamEmbedding(context.Background(), b.hashMessage(knownSpam), embedder[knownSpam])
+	return b, messenger, provider
+}
+
+func TestNearDuplicateThreshold(t *testing.T) {
+	embedder := fakeEmbedder{
+		knownSpam:                  {1, 0},
+		"earn $600 a day at home":  {0.95, 0.31}, // Similarity 0.95
+		"earn money, ask me how":   {0.91, 0.41}, // Similarity just above the threshold
+		"earn some money, ask me":  {0.89, 0.46}, // Similarity just below it
+		"what a lovely day it was": {0.6, 0.8},   // Similarity 0.6
+	}
+	tests := []struct {
+		text      string
+		duplicate bool
+	}{
+		{"earn $600 a day at home", true},
+		{"earn money, ask me how", true},
+		{"earn some money, ask me", false},
+		{"what a lovely day it was", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			b, messenger, provider := nearDuplicateBot(t, embedder)
+
+			handle(b, textMessage(10, tt.text))
+
+			if duplicate := len(messenger.deleted) == 1; duplicate != tt.duplicate {
+				t.Errorf("deleted = %v, want near-duplicate %t", messenger.deleted, tt.duplicate)
+			}
+			if classified := provider.callCount() > 0; classified == tt.duplicate {
+				t.Errorf("classified = %t, near-duplicates skip the classifier", classified)
+			}
+		})
+	}
+}
+
+func TestNotSpamForgetsEmbedding(t *testing.T) {
+	embedder := fakeEmbedder{knownSpam: {1, 0}, "another promo": {0, 1}}
+	b, messenger, _ := nearDuplicateBot(t, embedder)
+	ctx := context.Background()
+	b.addSpamEmbedding(ctx, b.hashMessage("another promo"), embedder["another promo"])
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+
+	report := commandMessage(testAdminID, "/notspam")
+	report.ReplyToMessage = textMessage(10, knownSpam)
+	b.handleCommand(report)
+
+	if _, ok := b.nearDuplicateSpam(ctx, embedder[knownSpam]); ok {
+		t.Error("the embedding of a message marked as not spam is still matched")
+	}
+	// The stored list is updated too, not only the loaded copy
+	b.spamEmbeddings.entries, b.spamEmbeddings.loaded = nil, false
+	if _, ok := b.nearDuplicateSpam(ctx, embedder[knownSpam]); ok {
+		t.Error("the embedding of a message marked as not spam is still stored")
+	}
+	if _, ok := b.nearDuplicateSpam(ctx, embedder["another promo"]); !ok {
+		t.Error("the embeddings of other spam were forgotten")
+	}
+}
+
+func TestClearChatSpamCacheForgetsEmbeddings(t *testing.T) {
+	embedder := fakeEmbedder{knownSpam: {1, 0}}
+	b, _, _ := nearDuplicateBot(t, embedder)
+	ctx := context.Background()
+	if err := b.addSpamMessage(ctx, testChatID, b.hashMessage(knownSpam)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.clearChatSpamCache(ctx, testChatID); err != nil {
+		t.Fatal(err)
+	}
+
+	b.spamEmbeddings.entries, b.spamEmbeddings.loaded = nil, false
+	if _, ok := b.nearDuplicateSpam(ctx, embedder[knownSpam]); ok {
+		t.Error("the embedding of a cleared message is still stored")
+	}
+}
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+func (b *Bot) newUserThresholdKey(chatID int64) string {
+	return b.key("new_user_threshold:%d", chatID)
+}
+
+// newUserThreshold returns the number of clean messages after which a user is no
+// longer new in a chat, which may be overridden per chat
+func (b *Bot) newUserThreshold(ctx context.Context, chatID int64) int {
+	threshold, err := b.redis.Get(ctx, b.newUserThresholdKey(chatID)).Int()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get chat new user threshold", "error", err, "chatID", chatID)
+		}
+		return b.config.NewUserThreshold
+	}
+	return threshold
+}
+
+// handleNewUserCommand shows, sets or resets the chat's new user threshold: /newuser [count|reset]
+func (b *Bot) handleNewUserCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	arg := strings.TrimSpace(message.CommandArguments())
+
+	switch arg {
+	case "":
+		b.reply(message, b.t(chatID, "newuser.show", b.newUserThreshold(ctx, chatID), b.config.NewUserThreshold))
+	case "reset":
+		if err := b.redis.Del(ctx, b.newUserThresholdKey(chatID)).Err(); err != nil {
+			b.logger.Error("Failed to reset chat new user threshold", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "newuser.reset_failed"))
+			return
+		}
+		b.logger.Info("Reset chat new user threshold", "chatID", chatID, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "newuser.reset", b.config.NewUserThreshold))
+	default:
+		threshold, err := strconv.Atoi(arg)
+		if err != nil || threshold <= 0 {
+			b.reply(message, b.t(chatID, "newuser.usage"))
+			return
+		}
+		if err := b.redis.Set(ctx, b.newUserThresholdKey(chatID), threshold, 0).Err(); err != nil {
+			b.logger.Error("Failed to set chat new user threshold", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "newuser.set_failed"))
+			return
+		}
+		b.logger.Info("Set chat new user threshold", "chatID", chatID, "threshold", threshold, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "newuser.set", threshold))
+	}
+}
@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/locale"
+)
+
+// noticeData is what a notice template can refer to
+type noticeData struct {
+	Label  string // What the message was flagged as, e.g. Spam
+	Action string // What was done, e.g. "removed and the sender banned"
+	User   string // The sender's name
+}
+
+// ParseNoticeTemplate parses the template of notices posted in chats when the bot
+// acts, e.g. "🧹 A {{.Label}} message from {{.User}} was {{.Action}}"
+func ParseNoticeTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("notice").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notice template: %w", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, noticeData{}); err != nil {
+		return nil, fmt.Errorf("invalid notice template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func (b *Bot) noticeKey(chatID int64) string {
+	return b.key("notice:%d", chatID)
+}
+
+// noticeAction returns the catalog key describing what enforce did for a notice,
+// or "" if nothing was done
+func noticeAction(deleted, muted, banned bool) string {
+	switch {
+	case banned && deleted:
+		return "notice.removed_banned"
+	case muted && deleted:
+		return "notice.removed_muted"
+	case banned:
+		return "notice.reported_banned"
+	case muted:
+		return "notice.reported_muted"
+	case deleted:
+		return "notice.removed"
+	default:
+		return ""
+	}
+}
+
+// postNotice tells the chat that the bot acted on a message, at most once per
+// NoticeInterval, and deletes the notice again after NoticeTTL
+func (b *Bot) postNotice(ctx context.Context, message *tgbotapi.Message, label, lang, action string) {
+	if b.config.Notice == nil || action == "" {
+		return
+	}
+	chatID := message.Chat.ID
+	if b.config.NoticeInterval > 0 {
+		allowed, err := b.redis.SetNX(ctx, b.noticeKey(chatID), time.Now().Unix(), b.config.NoticeInterval).Result()
+		if err != nil {
+			b.logger.Error("Failed to rate limit chat notice", "error", err, "chatID", chatID)
+			return
+		}
+		if !allowed {
+			return
+		}
+	}
+
+	var text strings.Builder
+	if err := b.config.Notice.Execute(&text, noticeData{Label: label, Action: locale.T(lang, action), User: senderName(message)}); err != nil {
+		b.logger.Error("Failed to render chat notice", "error", err, "chatID", chatID)
+		return
+	}
+	noticeID, err := b.messenger.Send(OutgoingMessage{ChatID: chatID, Text: text.String()})
+	if err != nil {
+		b.logger.Error("Failed to post chat notice", "error", err, "chatID", chatID)
+		return
+	}
+	if b.config.NoticeTTL > 0 {
+		time.AfterFunc(b.config.NoticeTTL, func() {
+			if err := b.messenger.Delete(chatID, noticeID); err != nil {
+				b.logger.Warn("Failed to delete chat notice", "error", err, "chatID", chatID, "messageID", noticeID)
+			}
+		})
+	}
+}
+
+// senderName returns how a message's sender is shown in a notice
+func senderName(message *tgbotapi.Message) string {
+	if message.SenderChat != nil {
+		return message.SenderChat.Title
+	}
+	if message.From == nil {
+		return "someone"
+	}
+	return strings.TrimSpace(message.From.FirstName + " " + message.From.LastName)
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type notifyKey struct {
+	chatID int64
+	userID int64
+}
+
+// notifyThrottle collapses repeated admin notifications about the same user
+// and caps the total notification rate, e.g. during a raid
+type notifyThrottle struct {
+	window     time.Duration
+	limiter    *rate.Limiter
+	last       map[notifyKey]time.Time
+	suppressed map[notifyKey]int // Suppressed notifications about users still in the window
+	pending    map[int64]int     // Suppressed notifications about other users, per chat
+	mutex      sync.Mutex
+}
+
+func newNotifyThrottle(window time.Duration, perMinute int) *notifyThrottle {
+	var limiter *rate.Limiter
+	if perMinute > 0 {
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute)
+	}
+	return &notifyThrottle{
+		window:     window,
+		limiter:    limiter,
+		last:       make(map[notifyKey]time.Time),
+		suppressed: make(map[notifyKey]int),
+		pending:    make(map[int64]int),
+	}
+}
+
+// allow reports whether a notification about the user may be sent now, and how many
+// notifications in the chat were suppressed since: about this user, and about users
+// whose window has passed. Notify-only actions are exempt from the rate limit, as the
+// notification is all they do.
+func (t *notifyThrottle) allow(chatID, userID int64, now time.Time, notifyOnly bool) (bool, int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := notifyKey{chatID: chatID, userID: userID}
+	if last, exists := t.last[key]; exists && t.window > 0 && now.Sub(last) < t.window {
+		t.suppressed[key]++
+		return false, 0
+	}
+	if t.limiter != nil && !notifyOnly && !t.limiter.AllowN(now, 1) {
+		t.suppressed[key]++
+		return false, 0
+	}
+
+	t.prune(now)
+	if t.window > 0 {
+		t.last[key] = now
+	}
+	suppressed := t.suppressed[key] + t.pending[chatID]
+	delete(t.suppressed, key)
+	delete(t.pending, chatID)
+	return true, suppressed
+}
+
+// prune forgets users whose dedupe window has passed, moving their suppressed
+// notifications to their chat's pending count
+func (t *notifyThrottle) prune(now time.Time) {
+	for key, last := range t.last {
+		if now.Sub(last) >= t.window {
+			delete(t.last, key)
+		}
+	}
+	for key, count := range t.suppressed {
+		if _, exists := t.last[key]; !exists {
+			t.pending[key.chatID] += count
+			delete(t.suppressed, key)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyThrottleCollapsesRepeats(t *testing.T) {
+	throttle := newNotifyThrottle(10*time.Minute, 0)
+	start := time.Now()
+
+	if ok, _ := throttle.allow(testChatID, 1, start, false); !ok {
+		t.Fatal("the first notification was suppressed")
+	}
+	for i := 1; i <= 3; i++ {
+		if ok, _ := throttle.allow(testChatID, 1, start.Add(time.Duration(i)*time.Minute), false); ok {
+			t.Errorf("repeat %d within the window was sent", i)
+		}
+	}
+	if ok, suppressed := throttle.allow(testChatID, 2, start.Add(4*time.Minute), false); !ok || suppressed != 0 {
+		t.Errorf("allow(other user) = %t, %d, want sent without the first user's repeats", ok, suppressed)
+	}
+
+	// The first user's window passed without another detection, the chat's next notification counts the repeats
+	if ok, suppressed := throttle.allow(testChatID, 3, start.Add(11*time.Minute), false); !ok || suppressed != 3 {
+		t.Errorf("allow(after the window) = %t, %d, want sent with 3 collapsed", ok, suppressed)
+	}
+	if len(throttle.last) != 2 || len(throttle.suppressed) != 0 {
+		t.Errorf("throttle keeps %d users and %d suppressed counts, want the passed windows pruned", len(throttle.last), len(throttle.suppressed))
+	}
+	if ok, suppressed := throttle.allow(testChatID, 4, start.Add(12*time.Minute), false); !ok || suppressed != 0 {
+		t.Errorf("allow() = %t, %d, want the collapsed count reported once", ok, suppressed)
+	}
+}
+
+func TestNotifyThrottleRateLimit(t *testing.T) {
+	throttle := newNotifyThrottle(0, 2)
+	now := time.Now()
+
+	sent := 0
+	for userID := int64(1); userID <= 5; userID++ {
+		if ok, _ := throttle.allow(testChatID, userID, now, false); ok {
+			sent++
+		}
+	}
+	if sent != 2 {
+		t.Errorf("sent %d notifications in a burst, want the limit of 2", sent)
+	}
+	// Sent right away, with the rate-limited ones
+	if ok, suppressed := throttle.allow(testChatID, 6, now, true); !ok || suppressed != 3 {
+		t.Errorf("allow(notify-only) = %t, %d, want sent with 3 collapsed despite the rate limit", ok, suppressed)
+	}
+
+	if ok, _ := throttle.allow(-1002, 7, now, false); ok {
+		t.Error("the rate limit doesn't apply to every chat")
+	}
+	if ok, suppressed := throttle.allow(testChatID, 8, now.Add(time.Minute), false); !ok || suppressed != 0 {
+		t.Errorf("allow(after the limit) = %t, %d, want sent without the other chat's count", ok, suppressed)
+	}
+	if ok, suppressed := throttle.allow(-1002, 9, now.Add(time.Minute), false); !ok || suppressed != 1 {
+		t.Errorf("allow(other chat) = %t, %d, want sent with its own rate-limited one", ok, suppressed)
+	}
+}
+
+func TestCollapsedCountInNotification(t *testing.T) {
+	config := testConfig()
+	config.NotifyDedupeWindow = time.Hour
+	b, messenger, _ := newTestBot(t, config, &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`})
+
+	handle(b, textMessage(10, "earn $500 a day"))
+	handle(b, textMessage(11, "earn $600 a day"))
+	b.notifications.last[notifyKey{chatID: testChatID, userID: testUserID}] = time.Now().Add(-2 * time.Hour)
+	handle(b, textMessage(12, "earn $700 a day"))
+
+	texts := messenger.sentTexts()
+	if len(messenger.forwarded) != 2 || len(texts) != 2 {
+		t.Fatalf("forwarded %v and sent %q, want the repeat collapsed", messenger.forwarded, texts)
+	}
+	if want := "(1 more detections collapsed since the last notification)"; !strings.Contains(texts[1], want) {
+		t.Errorf("notification = %q, want it to mention %q", texts[1], want)
+	}
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrorPolicy controls what happens to a message whose classification could not complete
+type ErrorPolicy string
+
+const (
+	ErrorPolicyIgnore       ErrorPolicy = "ignore"        // Let the message through
+	ErrorPolicyNotifyAdmins ErrorPolicy = "notify-admins" // Forward the message to the log channel for review
+	ErrorPolicyQuarantine   ErrorPolicy = "quarantine"    // Restrict the user until an admin reviews the message
+)
+
+func ParseErrorPolicy(value string) (ErrorPolicy, error) {
+	switch policy := ErrorPolicy(value); policy {
+	case ErrorPolicyIgnore, ErrorPolicyNotifyAdmins, ErrorPolicyQuarantine:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown error policy: %s", value)
+	}
+}
+
+// handleClassificationError applies the configured error policy to an unclassified message
+func (b *Bot) handleClassificationError(message *tgbotapi.Message, channelID, userID int64, adminRights AdminRights, classifyErr error) {
+	b.reportUnexpected(classifyErr, map[string]string{"chat_id": strconv.FormatInt(channelID, 10), "stage": "classification"})
+
+	policy := b.config.OnError
+	if policy == "" || policy == ErrorPolicyIgnore {
+		return
+	}
+
+	summary := "⚠️ Message could not be classified"
+	if policy == ErrorPolicyQuarantine && adminRights.CanRestrictMembers && !b.warmingUp(context.Background(), channelID) {
+		if err := b.restrictUser(channelID, userID); err != nil {
+			b.logger.Error("Failed to quarantine user", "error", err, "userID", userID, "channelID", channelID)
+		} else {
+			summary += "\n🔒 User quarantined until reviewed"
+			b.logger.Info("Quarantined user after classification error", "userID", userID, "channelID", channelID)
+		}
+	}
+
+	notify, suppressed := b.notifications.allow(channelID, userID, time.Now(), false)
+	if !notify {
+		return
+	}
+
+	logChannelID, exists := b.logChannel(channelID)
+	if !exists {
+		b.logger.Warn("No log channel to notify about classification error", "channelID", channelID, "policy", policy)
+		return
+	}
+
+	if err := b.messenger.Forward(logChannelID, channelID, message.MessageID); err != nil {
+		b.logger.Error("Failed to forward unclassified message to log channel", "error", err, "messageID", message.MessageID, "logChannelID", logChannelID)
+	}
+	logMessage := fmt.Sprintf("%s\nUser ID: %d\nChannel ID: %d\nError: %v", summary, userID, channelID, classifyErr)
+	if suppressed > 0 {
+		logMessage += "\n" + b.t(channelID, "notify.collapsed", suppressed)
+	}
+	b.sendLog(logChannelID, logMessage)
+}
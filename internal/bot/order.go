@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"sync"
+)
+
+// userOrder serializes the actions taken on each user's messages in the order
+// they arrived, while their classification runs concurrently
+type userOrder struct {
+	receiving sync.Mutex // Held while receiving, so turns are issued in arrival order
+	mutex     sync.Mutex
+	last      map[userKey]chan struct{}
+}
+
+type userKey struct {
+	chatID int64
+	userID int64
+}
+
+// userTurn lets a message act once the previous message of the same user is done
+type userTurn struct {
+	order *userOrder
+	key   userKey
+	prev  <-chan struct{}
+	done  chan struct{}
+}
+
+func newUserOrder() *userOrder {
+	return &userOrder{last: make(map[userKey]chan struct{})}
+}
+
+// next receives the next update and issues its turn; the turn is nil for
+// updates without a sender or if ordering is disabled
+func (o *userOrder) next(updates <-chan receivedUpdate) (receivedUpdate, *userTurn, bool) {
+	if o == nil {
+		update, ok := <-updates
+		return update, nil, ok
+	}
+	o.receiving.Lock()
+	defer o.receiving.Unlock()
+
+	update, ok := <-updates
+	if !ok || update.Message == nil || update.Message.From == nil {
+		return update, nil, ok
+	}
+	key := userKey{chatID: update.Message.Chat.ID, userID: update.Message.From.ID}
+	if update.Message.SenderChat != nil {
+		key.userID = update.Message.SenderChat.ID
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	turn := &userTurn{order: o, key: key, prev: o.last[key], done: make(chan struct{})}
+	o.last[key] = turn.done
+	return update, turn, true
+}
+
+// wait blocks until the user's previous message is done; it may be called repeatedly
+func (t *userTurn) wait() {
+	if t != nil && t.prev != nil {
+		<-t.prev
+	}
+}
+
+// finish lets the user's next message act
+func (t *userTurn) finish() {
+	if t == nil {
+		return
+	}
+	t.order.mutex.Lock()
+	defer t.order.mutex.Unlock()
+	close(t.done)
+	if t.order.last[t.key] == t.done {
+		delete(t.order.last, t.key)
+	}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// heldProvider flags every message as spam, holding the classification of
+// messages containing "first" until release is closed
+type heldProvider struct {
+	countingProvider
+	release chan struct{}
+}
+
+func (p *heldProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	p.countingProvider.ProcessMessage(ctx, message)
+	if strings.Contains(message, "first") {
+		<-p.release
+	}
+	return `{"reasoning": "promo", "spam_score": 0.9}`, nil
+}
+
+func TestPerUserOrderUnderConcurrentClassification(t *testing.T) {
+	config := testConfig()
+	config.Workers = 4
+	config.PerUserOrder = true
+	config.Prompt = "Classify: {{CHANNEL_CONTENT}}"
+	provider := &heldProvider{release: make(chan struct{})}
+	b, messenger, _ := newTestBot(t, config, provider)
+
+	other := textMessage(4, "cheap followers from another user")
+	other.From = &tgbotapi.User{ID: 43, FirstName: "Other"}
+	updates := make(chan receivedUpdate, 4)
+	for _, message := range []*tgbotapi.Message{
+		textMessage(1, "first cheap followers"),
+		textMessage(2, "second cheap followers"),
+		textMessage(3, "third cheap followers"),
+		other,
+	} {
+		updates <- receivedUpdate{incomingUpdate: incomingUpdate{Update: tgbotapi.Update{Message: message}}, received: time.Now()}
+	}
+	close(updates)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.handleUpdates(updates, b.api.Self)
+	}()
+
+	deleted := func() []int {
+		messenger.mutex.Lock()
+		defer messenger.mutex.Unlock()
+		return append([]int(nil), messenger.deleted...)
+	}
+	// Other users don't wait for the held message
+	waitFor(t, "the other user's message", func() bool { return slices.Contains(deleted(), 4) })
+	waitFor(t, "every message classified", func() bool { return provider.callCount() == 4 })
+	if got := deleted(); !slices.Equal(got, []int{4}) {
+		t.Errorf("deleted = %v before the first message was classified, want only the other user's message", got)
+	}
+
+	close(provider.release)
+	wg.Wait()
+
+	if got := deleted(); !slices.Equal(got, []int{4, 1, 2, 3}) {
+		t.Errorf("deleted = %v, want the user's messages acted on in arrival order", got)
+	}
+}
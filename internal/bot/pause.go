@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// setAIPaused suspends or resumes provider calls for every chat
+func (b *Bot) setAIPaused(paused bool) {
+	if b.aiPaused.Swap(paused) == paused {
+		return
+	}
+	if paused {
+		b.logger.Warn("AI classification paused, running on heuristics")
+		metrics.AIPaused.Set(1)
+	} else {
+		b.logger.Info("AI classification resumed")
+		metrics.AIPaused.Set(0)
+	}
+}
+
+// isSuperAdmin reports whether a message was sent by one of the configured super-admins
+func (b *Bot) isSuperAdmin(message *tgbotapi.Message) bool {
+	if message.From == nil {
+		return false
+	}
+	for _, adminID := range b.config.SuperAdmins {
+		if adminID == message.From.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePauseAICommand suspends or resumes provider calls: /pause_ai, /resume_ai
+func (b *Bot) handlePauseAICommand(message *tgbotapi.Message, paused bool) {
+	b.setAIPaused(paused)
+	b.logger.Info("Changed AI classification state", "paused", paused, "admin", commandSender(message))
+	if paused {
+		b.reply(message, "⏸ AI classification paused in every chat. Commands, the spam cache, blocklists and heuristic signals stay active; resume with /resume_ai")
+	} else {
+		b.reply(message, "▶️ AI classification resumed")
+	}
+}
@@ -0,0 +1,24 @@
+package bot
+
+import "testing"
+
+func TestPauseAISuppressesProviderCalls(t *testing.T) {
+	config := testConfig()
+	config.SuperAdmins = []int64{testSuperAdminID}
+	provider := &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`}
+	b, _, _ := newTestBot(t, config, provider)
+
+	b.handleCommand(commandMessage(testSuperAdminID, "/pause_ai"))
+	handle(b, textMessage(10, "cheap followers in my profile"))
+
+	if provider.callCount() != 0 {
+		t.Errorf("provider called %d times while AI classification was paused", provider.callCount())
+	}
+
+	b.handleCommand(commandMessage(testSuperAdminID, "/resume_ai"))
+	handle(b, textMessage(11, "cheap likes in my profile"))
+
+	if provider.callCount() != 1 {
+		t.Errorf("provider called %d times after resuming, want 1", provider.callCount())
+	}
+}
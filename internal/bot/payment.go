@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Candidates for contact and payment details, each validated further to keep
+// prices, dates, IDs and long words from matching
+var (
+	phoneCandidateRegex = regexp.MustCompile(`\+?\d[\d ()\-.]{7,20}\d`)
+	cardCandidateRegex  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	walletRegexes       = []*regexp.Regexp{
+		regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`),                // Ethereum and other EVM chains
+		regexp.MustCompile(`\bbc1[ac-hj-np-z02-9]{25,71}\b`),       // Bitcoin bech32
+		regexp.MustCompile(`\b[13][1-9A-HJ-NP-Za-km-z]{25,34}\b`),  // Bitcoin legacy
+		regexp.MustCompile(`\bT[1-9A-HJ-NP-Za-km-z]{33}\b`),        // TRON, popular for USDT
+		regexp.MustCompile(`\b(?:EQ|UQ)[0-9A-Za-z_-]{46}(?:\b|$)`), // TON
+	}
+)
+
+// paymentSignals returns the kinds of contact and payment details found in text:
+// "card", "phone" and "wallet"
+func paymentSignals(text string) []string {
+	var signals []string
+	for _, candidate := range cardCandidateRegex.FindAllString(text, -1) {
+		if isCardNumber(candidate) {
+			signals = append(signals, "card")
+			break
+		}
+	}
+	for _, candidate := range phoneCandidateRegex.FindAllString(text, -1) {
+		// A card number's groups look like a phone number too
+		if !isCardNumber(candidate) && isPhoneNumber(candidate) {
+			signals = append(signals, "phone")
+			break
+		}
+	}
+	if hasWallet(text) {
+		signals = append(signals, "wallet")
+	}
+	return signals
+}
+
+// isCardNumber reports whether a sequence of 13 to 19 digits passes the Luhn check
+func isCardNumber(candidate string) bool {
+	digits := onlyDigits(candidate)
+	if len(digits) < 13 || len(digits) > 19 || strings.Count(digits, digits[:1]) == len(digits) {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// isPhoneNumber reports whether a candidate is written like a phone number: 10 to 15
+// digits, either in international format or split into groups. Bare digit runs are
+// more likely IDs, a single separator more likely a range, groups of three digits an
+// amount such as 1 000 000 000, and dates and ISBNs have shapes of their own.
+func isPhoneNumber(candidate string) bool {
+	digits := onlyDigits(candidate)
+	if len(digits) < 10 || len(digits) > 15 {
+		return false
+	}
+	if strings.HasPrefix(candidate, "+") {
+		return true
+	}
+	groups := strings.FieldsFunc(candidate, func(r rune) bool { return !unicode.IsDigit(r) })
+	if len(groups) < 3 || strings.Contains(candidate, ".") || isDateLike(groups) || isISBNLike(candidate, groups, digits) {
+		return false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return true
+		}
+	}
+	return false
+}
+
+// isDateLike reports whether digit groups start like a date and time such as
+// 2024-01-15 10:30: a year followed by groups of one or two digits
+func isDateLike(groups []string) bool {
+	if len(groups[0]) != 4 || !strings.HasPrefix(groups[0], "19") && !strings.HasPrefix(groups[0], "20") {
+		return false
+	}
+	for _, group := range groups[1:3] {
+		if len(group) > 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// isISBNLike reports whether a candidate is written like a hyphenated ISBN such as
+// 978-3-16-148410-0: 4 or 5 groups ending in a single check digit
+func isISBNLike(candidate string, groups []string, digits string) bool {
+	if strings.ContainsAny(candidate, " ()") || len(groups) < 4 || len(groups) > 5 || len(groups[len(groups)-1]) != 1 {
+		return false
+	}
+	return len(digits) == 10 || len(digits) == 13 && (strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979"))
+}
+
+// hasWallet reports whether text contains a crypto wallet address. Base58 addresses
+// must mix digits with upper and lower case letters, which words and hashtags don't.
+func hasWallet(text string) bool {
+	for _, regex := range walletRegexes {
+		for _, candidate := range regex.FindAllString(text, -1) {
+			if strings.HasPrefix(candidate, "0x") || strings.HasPrefix(candidate, "bc1") || mixedAlphanumeric(candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func mixedAlphanumeric(s string) bool {
+	var digit, upper, lower bool
+	for _, r := range s {
+		switch {
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		}
+	}
+	return digit && upper && lower
+}
+
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// paymentBoost returns the spam score added for the kinds of payment details found
+func (b *Bot) paymentBoost(signals []string) float64 {
+	boost := 0.0
+	for _, signal := range signals {
+		switch signal {
+		case "card":
+			boost += b.config.CardBoost
+		case "phone":
+			boost += b.config.PhoneBoost
+		case "wallet":
+			boost += b.config.WalletBoost
+		}
+	}
+	return boost
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPaymentSignals(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		// Phone numbers
+		{"international phone", "call me +7 912 345-67-89", []string{"phone"}},
+		{"grouped phone", "whatsapp 8 (912) 345-67-89", []string{"phone"}},
+		{"hyphenated phone", "text 555-123-4567 now", []string{"phone"}},
+		{"date and time", "meeting on 2024-01-15 10:30", nil},
+		{"date and time with seconds", "logged 2024-01-15 10:30:45", nil},
+		{"hyphenated ISBN-13", "ISBN 978-3-16-148410-0", nil},
+		{"hyphenated ISBN-10", "ISBN 0-306-40615-2", nil},
+		{"amount", "raised 1 000 000 000 so far", nil},
+		{"range", "pages 1234567-8901234", nil},
+		{"bare ID", "order 12345678901", nil},
+		{"dotted version", "build 10.20.30.40.50", nil},
+
+		// Card numbers
+		{"grouped card", "pay to 4111 1111 1111 1111", []string{"card"}},
+		{"plain card", "card 5500005555555559", []string{"card"}},
+		{"fails Luhn", "card 4111 1111 1111 1112", nil},
+		{"repeated digit", "0000 0000 0000 0000", nil},
+
+		// Wallets
+		{"ethereum", "send to 0x52908400098527886E0F7030069857D2E4169EE7", []string{"wallet"}},
+		{"bitcoin bech32", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", []string{"wallet"}},
+		{"bitcoin legacy", "btc 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", []string{"wallet"}},
+		{"tron", "usdt TNPeeaaFB7K9cmo4uQpcU32zGK8G1NYqeL", []string{"wallet"}},
+		{"long word", "Supercalifragilisticexpialidocious", nil},
+
+		{"card and wallet", "4111 1111 1111 1111 or 0x52908400098527886E0F7030069857D2E4169EE7", []string{"card", "wallet"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paymentSignals(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("paymentSignals(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPhoneNumber(t *testing.T) {
+	for candidate, want := range map[string]bool{
+		"+44 20 7946 0958":  true,
+		"+4420794609":       true,
+		"8 800 555-35-35":   true,
+		"555-123-4567":      true,
+		"2024-01-15 10":     false,
+		"1999-12-31 23":     false,
+		"978-3-16-148410-0": false,
+		"0-306-40615-2":     false,
+		"123 456 789 012":   false,
+		"1234567890":        false,
+	} {
+		if got := isPhoneNumber(candidate); got != want {
+			t.Errorf("isPhoneNumber(%q) = %t, want %t", candidate, got, want)
+		}
+	}
+}
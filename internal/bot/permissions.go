@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// permissionFailuresToPause is the number of consecutive permission errors
+// after which moderation in a chat is paused
+const permissionFailuresToPause = 3
+
+// permissionErrors are fragments of Telegram errors caused by missing admin rights
+var permissionErrors = []string{
+	"CHAT_ADMIN_REQUIRED",
+	"USER_ADMIN_INVALID",
+	"not enough rights",
+	"need administrator rights",
+	"have no rights",
+	"bot is not a member",
+	"bot was kicked",
+}
+
+// moderationState tracks chats where the bot lacks the rights to moderate
+type moderationState struct {
+	mutex    sync.Mutex
+	failures map[int64]int
+	paused   map[int64]bool
+}
+
+func isPermissionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	for _, fragment := range permissionErrors {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// moderationPaused reports whether deletes and bans are paused in a chat
+func (b *Bot) moderationPaused(chatID int64) bool {
+	b.moderation.mutex.Lock()
+	defer b.moderation.mutex.Unlock()
+	return b.moderation.paused[chatID]
+}
+
+// recordActionResult counts permission errors of a chat, pausing moderation
+// after repeated ones, and resets the count once an action succeeds
+func (b *Bot) recordActionResult(chatID int64, err error) {
+	if err != nil && !isPermissionError(err) {
+		return
+	}
+
+	b.moderation.mutex.Lock()
+	if err == nil {
+		delete(b.moderation.failures, chatID)
+		b.moderation.mutex.Unlock()
+		return
+	}
+	b.moderation.failures[chatID]++
+	pause := b.moderation.failures[chatID] >= permissionFailuresToPause && !b.moderation.paused[chatID]
+	if pause {
+		b.moderation.paused[chatID] = true
+	}
+	b.moderation.mutex.Unlock()
+
+	// Fetch the rights again on the next message instead of trusting the cached ones
+	b.clearAdminCacheEntry(chatID)
+
+	if pause {
+		b.logger.Warn("Paused moderation after permission errors", "chatID", chatID, "error", err)
+		b.notifySuperAdmins(chatID, fmt.Sprintf("⚠️ Moderation paused in chat %d: the bot lacks admin rights (%s). Spam is only reported until the rights are restored.", chatID, err))
+	}
+}
+
+// checkModerationRestored resumes moderation in a paused chat once freshly fetched
+// rights cover what the chat's action policy needs
+func (b *Bot) checkModerationRestored(chatID int64, rights AdminRights) {
+	if !b.actionPolicy(context.Background(), chatID).allowedBy(rights) {
+		return
+	}
+
+	b.moderation.mutex.Lock()
+	restored := b.moderation.paused[chatID]
+	delete(b.moderation.paused, chatID)
+	delete(b.moderation.failures, chatID)
+	b.moderation.mutex.Unlock()
+
+	if restored {
+		b.logger.Info("Resumed moderation, admin rights restored", "chatID", chatID)
+		b.notifySuperAdmins(chatID, fmt.Sprintf("✅ Moderation resumed in chat %d: admin rights restored.", chatID))
+	}
+}
+
+// notifySuperAdmins messages the configured super-admins and the chat's log channel
+func (b *Bot) notifySuperAdmins(chatID int64, text string) {
+	for _, adminID := range b.config.SuperAdmins {
+		b.sendLog(adminID, text)
+	}
+	if logChannelID, exists := b.logChannel(chatID); exists {
+		b.sendLog(logChannelID, text)
+	}
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestModerationResumesWithRightsPolicyNeeds(t *testing.T) {
+	deleteOnly := AdminRights{CanDeleteMessages: true}
+	restrictOnly := AdminRights{CanRestrictMembers: true}
+	full := AdminRights{CanDeleteMessages: true, CanRestrictMembers: true}
+	tests := []struct {
+		name    string
+		policy  ActionPolicy
+		rights  AdminRights
+		resumed bool
+	}{
+		{"ban without restricting", ActionPolicyBan, deleteOnly, false},
+		{"ban without deleting", ActionPolicyBan, restrictOnly, false},
+		{"ban with full rights", ActionPolicyBan, full, true},
+		{"mute without restricting", ActionPolicyMute, deleteOnly, false},
+		{"delete-only with deleting", ActionPolicyDeleteOnly, deleteOnly, true},
+		{"delete-only without deleting", ActionPolicyDeleteOnly, restrictOnly, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, messenger, _ := newTestBot(t, testConfig(), &countingProvider{})
+			// The chat's own policy decides, not the global one
+			if err := b.redis.Set(context.Background(), b.actionPolicyKey(testChatID), string(tt.policy), 0).Err(); err != nil {
+				t.Fatal(err)
+			}
+			b.moderation.paused[testChatID] = true
+
+			b.checkModerationRestored(testChatID, tt.rights)
+
+			if resumed := !b.moderation.paused[testChatID]; resumed != tt.resumed {
+				t.Errorf("resumed = %t, want %t", resumed, tt.resumed)
+			}
+			if notified := len(messenger.sentTexts()) > 0; notified != tt.resumed {
+				t.Errorf("sent %q, want the log channel notified %t", messenger.sentTexts(), tt.resumed)
+			}
+		})
+	}
+}
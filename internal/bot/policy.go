@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// ActionPolicy is the strictest enforcement a chat allows, set globally and overridable per chat
+type ActionPolicy string
+
+const (
+	ActionPolicyDeleteOnly ActionPolicy = "delete-only" // Delete messages but never restrict users
+	ActionPolicyMute       ActionPolicy = "mute"        // Mute users temporarily instead of banning them
+	ActionPolicyBan        ActionPolicy = "ban"         // Apply actions as configured
+)
+
+func ParseActionPolicy(value string) (ActionPolicy, error) {
+	switch policy := ActionPolicy(value); policy {
+	case ActionPolicyDeleteOnly, ActionPolicyMute, ActionPolicyBan:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown action policy: %s (expected delete-only, mute or ban)", value)
+	}
+}
+
+// limit downgrades an action to the strictest one the policy allows
+func (p ActionPolicy) limit(action Action) Action {
+	switch p {
+	case ActionPolicyDeleteOnly:
+		if action.severity() > ActionDelete.severity() {
+			return ActionDelete
+		}
+	case ActionPolicyMute:
+		if action.severity() > ActionMute.severity() {
+			return ActionMute
+		}
+	}
+	return action
+}
+
+// allowedBy reports whether the bot's rights cover every action the policy may take
+func (p ActionPolicy) allowedBy(rights AdminRights) bool {
+	if p == ActionPolicyDeleteOnly {
+		return rights.CanDeleteMessages
+	}
+	return rights.CanDeleteMessages && rights.CanRestrictMembers
+}
+
+func (b *Bot) actionPolicyKey(chatID int64) string {
+	return b.key("policy:%d", chatID)
+}
+
+// actionPolicy returns the chat's action policy, falling back to the global one
+func (b *Bot) actionPolicy(ctx context.Context, chatID int64) ActionPolicy {
+	value, err := b.redis.Get(ctx, b.actionPolicyKey(chatID)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get chat action policy", "error", err, "chatID", chatID)
+		}
+		return b.config.ActionPolicy
+	}
+	policy, err := ParseActionPolicy(value)
+	if err != nil {
+		return b.config.ActionPolicy
+	}
+	return policy
+}
+
+// handlePolicyCommand shows or sets the chat's action policy: /policy [delete-only|mute|ban|reset]
+func (b *Bot) handlePolicyCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	arg := strings.TrimSpace(message.CommandArguments())
+
+	switch arg {
+	case "":
+		b.reply(message, b.t(chatID, "policy.show", b.actionPolicy(ctx, chatID), b.config.ActionPolicy))
+	case "reset":
+		if err := b.redis.Del(ctx, b.actionPolicyKey(chatID)).Err(); err != nil {
+			b.logger.Error("Failed to reset chat action policy", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "policy.reset_failed"))
+			return
+		}
+		b.logger.Info("Reset chat action policy", "chatID", chatID, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "policy.reset", b.config.ActionPolicy))
+	default:
+		policy, err := ParseActionPolicy(arg)
+		if err != nil {
+			b.reply(message, b.t(chatID, "policy.usage"))
+			return
+		}
+		if err := b.redis.Set(ctx, b.actionPolicyKey(chatID), string(policy), 0).Err(); err != nil {
+			b.logger.Error("Failed to set chat action policy", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "policy.set_failed"))
+			return
+		}
+		b.logger.Info("Set chat action policy", "chatID", chatID, "policy", policy, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "policy.set", policy))
+	}
+}
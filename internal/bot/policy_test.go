@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolicyCommandPersistsPolicy(t *testing.T) {
+	b, messenger, server := newTestBot(t, testConfig(), &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	ctx := context.Background()
+
+	b.handleCommand(commandMessage(testAdminID, "/policy delete-only"))
+	if value, _ := server.Get(b.actionPolicyKey(testChatID)); value != string(ActionPolicyDeleteOnly) {
+		t.Errorf("stored policy = %q, want %q", value, ActionPolicyDeleteOnly)
+	}
+	if policy := b.actionPolicy(ctx, testChatID); policy != ActionPolicyDeleteOnly {
+		t.Errorf("actionPolicy() = %q, want %q", policy, ActionPolicyDeleteOnly)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/policy strict"))
+	if policy := b.actionPolicy(ctx, testChatID); policy != ActionPolicyDeleteOnly {
+		t.Errorf("actionPolicy() = %q after an invalid policy, want it unchanged", policy)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/policy reset"))
+	if server.Exists(b.actionPolicyKey(testChatID)) {
+		t.Error("/policy reset kept the chat's policy")
+	}
+	if policy := b.actionPolicy(ctx, testChatID); policy != ActionPolicyBan {
+		t.Errorf("actionPolicy() = %q after reset, want the global %q", policy, ActionPolicyBan)
+	}
+}
+
+func TestPolicyLimitsEnforcement(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     ActionPolicy // Chat policy, global ban if empty
+		action     Action
+		restricted bool
+	}{
+		{"global ban", "", ActionBan, true},
+		{"delete-only", ActionPolicyDeleteOnly, ActionDelete, false},
+		{"mute", ActionPolicyMute, ActionMute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.DecisionTTL = time.Hour
+			b, messenger, server := newTestBot(t, config, &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`})
+			if tt.policy != "" {
+				if err := b.redis.Set(context.Background(), b.actionPolicyKey(testChatID), string(tt.policy), 0).Err(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			handle(b, textMessage(10, "earn $500 a day from home, write me"))
+
+			if len(messenger.deleted) != 1 {
+				t.Errorf("deleted = %v, want the spam message", messenger.deleted)
+			}
+			if restricted := len(messenger.restricted) > 0; restricted != tt.restricted {
+				t.Errorf("restricted = %v, want restricted %t", messenger.restricted, tt.restricted)
+			}
+			if action := server.HGet(b.decisionKey(testChatID, 10), "action"); action != string(tt.action) {
+				t.Errorf("recorded action = %q, want %q", action, tt.action)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	pollRetryDelay   = 3 * time.Second // Pause after a failed getUpdates call, before jitter
+	conflictDelay    = 5 * time.Second // First pause after a conflict, doubled on each consecutive one
+	maxConflictDelay = 5 * time.Minute
+)
+
+// updateFetcher long-polls Telegram for updates
+type updateFetcher interface {
+	GetUpdates(config tgbotapi.UpdateConfig) ([]incomingUpdate, error)
+	DeleteWebhook() error
+}
+
+// incomingUpdate is an update from getUpdates, including update types the
+// Telegram library doesn't know about yet
+type incomingUpdate struct {
+	tgbotapi.Update
+	MessageReaction *messageReaction `json:"message_reaction,omitempty"`
+}
+
+// telegramFetcher fetches updates through the Bot API
+type telegramFetcher struct {
+	api *tgbotapi.BotAPI
+}
+
+func (f telegramFetcher) GetUpdates(config tgbotapi.UpdateConfig) ([]incomingUpdate, error) {
+	resp, err := f.api.Request(config)
+	if err != nil {
+		return nil, err
+	}
+	var updates []incomingUpdate
+	if err := json.Unmarshal(resp.Result, &updates); err != nil {
+		return nil, fmt.Errorf("error decoding updates: %w", err)
+	}
+	return updates, nil
+}
+
+// DeleteWebhook removes a webhook left from an earlier run, keeping the updates it holds back
+func (f telegramFetcher) DeleteWebhook() error {
+	_, err := f.api.MakeRequest("deleteWebhook", tgbotapi.Params{"drop_pending_updates": "false"})
+	return err
+}
+
+// receivedUpdate is an update stamped with the time the bot received it
+type receivedUpdate struct {
+	incomingUpdate
+	received time.Time
+}
+
+// pollConfig returns the getUpdates parameters for the first call
+func (b *Bot) pollConfig() tgbotapi.UpdateConfig {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = int(b.config.PollTimeout.Seconds())
+	u.Limit = b.config.PollLimit
+	u.AllowedUpdates = allowedUpdates
+	if b.config.UndoWindow > 0 {
+		u.AllowedUpdates = append(u.AllowedUpdates[:len(u.AllowedUpdates):len(u.AllowedUpdates)], "message_reaction")
+	}
+	return u
+}
+
+// poll fetches updates until Stop is called, stamping them as they arrive so
+// time spent waiting for a worker counts towards decision latency.
+// A webhook set by an earlier run in webhook mode is deleted first, as Telegram
+// refuses getUpdates while one is set.
+// Empty polls and failures are followed by a random pause of up to PollJitter,
+// so instances sharing infrastructure don't stay in lockstep.
+func (b *Bot) poll(fetcher updateFetcher, config tgbotapi.UpdateConfig, buffer int) <-chan receivedUpdate {
+	updates := make(chan receivedUpdate, buffer)
+	go func() {
+		defer close(updates)
+		if err := fetcher.DeleteWebhook(); err != nil {
+			b.logger.Warn("Failed to delete webhook before polling", "error", err)
+		}
+		conflicts := 0
+		for {
+			select {
+			case <-b.stopChan:
+				return
+			default:
+			}
+
+			batch, err := fetcher.GetUpdates(config)
+			if isConflict(err) {
+				conflicts++
+				if b.config.ExitOnConflict > 0 && conflicts >= b.config.ExitOnConflict {
+					b.logger.Error("Exiting after repeated getUpdates conflicts, another instance is using the same token", "conflicts", conflicts)
+					os.Exit(1)
+				}
+				delay := conflictBackoff(conflicts)
+				if isWebhookConflict(err) {
+					// Deleted before polling, so another instance receiving updates by webhook has set it since
+					b.logger.Warn("getUpdates conflict, a webhook is set for the token, another instance may be receiving updates by webhook", "error", err, "conflicts", conflicts, "delay", delay)
+				} else {
+					b.logger.Warn("getUpdates conflict, another instance may be running with the same token", "error", err, "conflicts", conflicts, "delay", delay)
+				}
+				if !b.sleep(delay + b.pollJitter()) {
+					return
+				}
+				continue
+			}
+			if err != nil {
+				b.logger.Error("Failed to get updates, retrying", "error", err, "delay", pollRetryDelay)
+				if !b.sleep(pollRetryDelay + b.pollJitter()) {
+					return
+				}
+				continue
+			}
+			if len(batch) == 0 {
+				if !b.sleep(b.pollJitter()) {
+					return
+				}
+				continue
+			}
+
+			if conflicts > 0 {
+				b.logger.Info("getUpdates conflict resolved", "conflicts", conflicts)
+				conflicts = 0
+			}
+			received := time.Now()
+			for _, update := range batch {
+				if update.UpdateID >= config.Offset {
+					config.Offset = update.UpdateID + 1
+					updates <- receivedUpdate{incomingUpdate: update, received: received}
+				}
+			}
+		}
+	}()
+	return updates
+}
+
+// isConflict reports whether getUpdates failed because another poller or a webhook uses the token
+func isConflict(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+// isWebhookConflict reports whether a getUpdates conflict is due to a webhook being set,
+// rather than another instance polling
+func isWebhookConflict(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict && strings.Contains(apiErr.Message, "webhook is active")
+}
+
+// conflictBackoff returns the pause after the given number of consecutive conflicts
+func conflictBackoff(conflicts int) time.Duration {
+	delay := conflictDelay
+	for i := 1; i < conflicts && delay < maxConflictDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxConflictDelay)
+}
+
+func (b *Bot) pollJitter() time.Duration {
+	if b.config.PollJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(b.config.PollJitter)))
+}
+
+// sleep waits for the duration and reports false if the bot was stopped meanwhile
+func (b *Bot) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-b.stopChan:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// scriptedFetcher returns its batches in turn, then stops the bot
+type scriptedFetcher struct {
+	mutex   sync.Mutex
+	batches [][]incomingUpdate
+	calls   []string
+	stop    func()
+}
+
+func (f *scriptedFetcher) GetUpdates(config tgbotapi.UpdateConfig) ([]incomingUpdate, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf("getUpdates offset=%d", config.Offset))
+	if len(f.batches) == 0 {
+		if f.stop != nil {
+			f.stop()
+			f.stop = nil
+		}
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func (f *scriptedFetcher) DeleteWebhook() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, "deleteWebhook")
+	return nil
+}
+
+func TestPollDeletesWebhookFirst(t *testing.T) {
+	b, _, _ := newTestBot(t, testConfig(), &countingProvider{})
+	fetcher := &scriptedFetcher{
+		batches: [][]incomingUpdate{{{Update: tgbotapi.Update{UpdateID: 7}}, {Update: tgbotapi.Update{UpdateID: 8}}}},
+		stop:    b.Stop,
+	}
+
+	var received []int
+	for update := range b.poll(fetcher, b.pollConfig(), 10) {
+		received = append(received, update.UpdateID)
+	}
+
+	if len(received) != 2 || received[0] != 7 || received[1] != 8 {
+		t.Errorf("received updates %v, want 7 and 8", received)
+	}
+	want := []string{"deleteWebhook", "getUpdates offset=0", "getUpdates offset=9"}
+	if fmt.Sprint(fetcher.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", fetcher.calls, want)
+	}
+}
+
+func TestConflictKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		conflict bool
+		webhook  bool
+	}{
+		{"webhook", &tgbotapi.Error{Code: 409, Message: "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first"}, true, true},
+		{"poller", &tgbotapi.Error{Code: 409, Message: "Conflict: terminated by other getUpdates request; make sure that only one bot instance is running"}, true, false},
+		{"wrapped", fmt.Errorf("polling: %w", &tgbotapi.Error{Code: 409, Message: "Conflict: terminated by other getUpdates request"}), true, false},
+		{"other API error", &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}, false, false},
+		{"network", errors.New("connection reset"), false, false},
+		{"none", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConflict(tt.err); got != tt.conflict {
+				t.Errorf("isConflict() = %t, want %t", got, tt.conflict)
+			}
+			if got := isWebhookConflict(tt.err); got != tt.webhook {
+				t.Errorf("isWebhookConflict() = %t, want %t", got, tt.webhook)
+			}
+		})
+	}
+}
+
+func TestConflictBackoff(t *testing.T) {
+	tests := []struct {
+		conflicts int
+		want      time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{6, 160 * time.Second},
+		{7, 5 * time.Minute},
+		{100, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := conflictBackoff(tt.conflicts); got != tt.want {
+			t.Errorf("conflictBackoff(%d) = %s, want %s", tt.conflicts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// profileTTL bounds how long the profile score of a new member is kept for their first messages
+const profileTTL = 7 * 24 * time.Hour
+
+func (b *Bot) profileKey(chatID, userID int64) string {
+	return b.key("profile:%d:%d", chatID, userID)
+}
+
+// profileText describes a user's public profile for the bio prompt
+func profileText(user tgbotapi.User, bio string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Name: %s\n", strings.TrimSpace(user.FirstName+" "+user.LastName))
+	if user.UserName != "" {
+		fmt.Fprintf(&sb, "Username: @%s\n", user.UserName)
+	}
+	if bio != "" {
+		fmt.Fprintf(&sb, "Bio: %s\n", bio)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// screenProfile classifies the name and bio of a user joining a chat with BioPrompt,
+// keeping the score so their first messages are boosted by BioBoost and telling the
+// log channel when it reaches BioThreshold. Each join is screened once.
+func (b *Bot) screenProfile(chatID int64, user tgbotapi.User) {
+	if b.config.BioPrompt == "" || user.IsBot || b.aiPaused.Load() {
+		return
+	}
+	if len(b.whitelistChannels) > 0 && !b.whitelistChannels[chatID] {
+		return
+	}
+	ctx := context.Background()
+	if b.costCapReached(ctx) {
+		return
+	}
+	// Joins are reported both as a service message and as a chat_member update
+	claimed, err := b.redis.SetNX(ctx, b.profileKey(chatID, user.ID), 0, profileTTL).Result()
+	if err != nil {
+		b.logger.Error("Failed to claim profile screening", "error", err, "chatID", chatID, "userID", user.ID)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	bio, err := b.messenger.Bio(user.ID)
+	if err != nil {
+		// Telegram only shares profiles of users the bot has seen; the name is still worth a look
+		b.logger.Debug("Failed to get user bio", "error", err, "userID", user.ID)
+	}
+	prompt := ai.RenderPrompt(profileText(user, bio), b.config.BioPrompt)
+	response, err := b.aiprovider.ProcessMessage(ctx, prompt)
+	if err != nil {
+		b.logger.Warn("Failed to classify new member profile", "error", err, "chatID", chatID, "userID", user.ID)
+		return
+	}
+	b.recordCost(ctx, prompt, response)
+	result, err := ai.ParseResponse(response)
+	if err != nil {
+		b.logger.Warn("Failed to parse profile classification", "error", err, "chatID", chatID, "userID", user.ID)
+		return
+	}
+	if err := b.redis.Set(ctx, b.profileKey(chatID, user.ID), result.SpamScore, profileTTL).Err(); err != nil {
+		b.logger.Error("Failed to store profile score", "error", err, "chatID", chatID, "userID", user.ID)
+	}
+	b.logger.Debug("Screened new member profile", "chatID", chatID, "userID", user.ID, "score", result.SpamScore, "hasBio", bio != "")
+
+	if result.SpamScore < b.config.BioThreshold {
+		return
+	}
+	b.logger.Info("Suspicious new member profile", "chatID", chatID, "userID", user.ID, "score", result.SpamScore)
+	if logChannelID, exists := b.logChannel(chatID); exists {
+		text := b.t(chatID, "notify.suspicious_profile", result.SpamScore, user.ID, chatID, profileText(user, bio))
+		b.sendLog(logChannelID, truncate(text, maxNotificationChars))
+	}
+}
+
+// suspiciousProfile reports whether the profile of a user scored at least BioThreshold when they joined
+func (b *Bot) suspiciousProfile(ctx context.Context, chatID, userID int64) bool {
+	score, err := b.redis.Get(ctx, b.profileKey(chatID, userID)).Float64()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get profile score", "error", err, "chatID", chatID, "userID", userID)
+		}
+		return false
+	}
+	return score >= b.config.BioThreshold
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// profileScorer scores profiles mentioning crypto as spam and keeps the prompts it was called with
+type profileScorer struct {
+	mutex   sync.Mutex
+	prompts []string
+}
+
+func (p *profileScorer) ProcessMessage(ctx context.Context, message string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.prompts = append(p.prompts, message)
+	if strings.Contains(strings.ToLower(message), "crypto") {
+		return `{"reasoning": "promotes crypto", "spam_score": 0.9}`, nil
+	}
+	return `{"reasoning": "fine", "spam_score": 0.1}`, nil
+}
+
+func (p *profileScorer) last() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.prompts) == 0 {
+		return ""
+	}
+	return p.prompts[len(p.prompts)-1]
+}
+
+func TestScreenProfile(t *testing.T) {
+	tests := []struct {
+		name           string
+		user           tgbotapi.User
+		bio            string
+		seen           bool // Bio lookups fail for users the bot hasn't seen
+		wantSuspicious bool
+		wantBio        bool
+	}{
+		{"spammy bio", tgbotapi.User{ID: testUserID, FirstName: "Anna"}, "Daily crypto signals, DM me", true, true, true},
+		{"clean bio", tgbotapi.User{ID: testUserID, FirstName: "Anna"}, "Gardener and cat person", true, false, true},
+		{"empty bio", tgbotapi.User{ID: testUserID, FirstName: "Anna"}, "", true, false, false},
+		{"missing bio", tgbotapi.User{ID: testUserID, FirstName: "Anna"}, "", false, false, false},
+		{"missing bio with a spammy name", tgbotapi.User{ID: testUserID, FirstName: "Crypto", LastName: "Signals"}, "", false, true, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.BioPrompt = "Profile: {{CHANNEL_CONTENT}}"
+			config.BioThreshold = 0.7
+			provider := &profileScorer{}
+			b, messenger, _ := newTestBot(t, config, provider)
+			messenger.bios = map[int64]string{}
+			if tt.seen {
+				messenger.bios[tt.user.ID] = tt.bio
+			}
+
+			b.screenProfile(testChatID, tt.user)
+			prompt := provider.last()
+			if !strings.Contains(prompt, "Name: "+strings.TrimSpace(tt.user.FirstName+" "+tt.user.LastName)) {
+				t.Errorf("prompt = %q, want the user's name", prompt)
+			}
+			if got := strings.Contains(prompt, "Bio: "); got != tt.wantBio {
+				t.Errorf("prompt = %q, has a bio = %v, want %v", prompt, got, tt.wantBio)
+			}
+			if got := b.suspiciousProfile(context.Background(), testChatID, tt.user.ID); got != tt.wantSuspicious {
+				t.Errorf("suspiciousProfile = %v, want %v", got, tt.wantSuspicious)
+			}
+			notified := false
+			for _, text := range messenger.sentTexts() {
+				notified = notified || strings.Contains(text, "Suspicious profile")
+			}
+			if notified != tt.wantSuspicious {
+				t.Errorf("log channel notified = %v, want %v, sent %q", notified, tt.wantSuspicious, messenger.sentTexts())
+			}
+		})
+	}
+}
+
+func TestScreenProfileOncePerJoin(t *testing.T) {
+	config := testConfig()
+	config.BioPrompt = "Profile: {{CHANNEL_CONTENT}}"
+	config.BioThreshold = 0.7
+	provider := &profileScorer{}
+	b, _, _ := newTestBot(t, config, provider)
+
+	user := tgbotapi.User{ID: testUserID, FirstName: "Anna"}
+	b.screenProfile(testChatID, user)
+	b.screenProfile(testChatID, user)
+	if len(provider.prompts) != 1 {
+		t.Errorf("profile classified %d times, want once", len(provider.prompts))
+	}
+}
+
+func TestSuspiciousProfileBoostsNewUsers(t *testing.T) {
+	config := testConfig()
+	config.BioPrompt = "Profile: {{CHANNEL_CONTENT}}"
+	config.BioThreshold = 0.7
+	config.BioBoost = 0.2
+	b, messenger, _ := newTestBot(t, config, &profileScorer{})
+	messenger.bios = map[int64]string{testUserID: "Daily crypto signals, DM me"}
+	b.screenProfile(testChatID, tgbotapi.User{ID: testUserID, FirstName: "Anna"})
+
+	message := textMessage(10, "hello everyone")
+	for _, newUser := range []bool{true, false} {
+		processed := &ai.Result{SpamScore: 0.4}
+		b.adjustScore(message, testUserID, message.Text, newUser, false, processed)
+		want := 0.4
+		if newUser {
+			want = 0.6
+		}
+		if diff := processed.SpamScore - want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("new user %v: score = %.2f, want %.2f", newUser, processed.SpamScore, want)
+		}
+	}
+
+	processed := &ai.Result{SpamScore: 0.4}
+	b.adjustScore(message, 43, message.Text, true, false, processed)
+	if processed.SpamScore != 0.4 {
+		t.Errorf("score of a user without a screened profile = %.2f, want 0.40", processed.SpamScore)
+	}
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// promptFor returns the prompt template for a message in a chat, the chat's override if set, with recent
+// spam examples, preceding chat messages and notes about the sender and message injected if enabled
+func (b *Bot) promptFor(ctx context.Context, message *tgbotapi.Message, senderID int64) string {
+	chatID, messageID := message.Chat.ID, message.MessageID
+	prompt := b.chatPrompt(ctx, chatID)
+	var examples []string
+	if b.config.SpamExamples > 0 {
+		examples = b.spamExamples(ctx, chatID)
+	}
+	// Injecting nothing still strips the placeholders
+	prompt = ai.InjectExamples(prompt, examples)
+	var preceding []string
+	if b.config.ContextMessages > 0 {
+		preceding = b.chatContext(ctx, chatID, messageID)
+	}
+	prompt = ai.InjectChatContext(prompt, preceding)
+	notes := b.senderNotes(senderID)
+	if b.config.IgnoreCodeBlocks && hasCode(message) {
+		notes = append(notes, "Parts of the message are formatted as code; links and unusual tokens inside code are likely part of it rather than promotion.")
+	}
+	return ai.InjectContext(prompt, notes)
+}
+
+// senderNotes describes what the bot knows about the sender for the prompt
+func (b *Bot) senderNotes(senderID int64) []string {
+	var notes []string
+	if b.isNewAccount(senderID) {
+		notes = append(notes, "The sender's Telegram account was created recently.")
+	}
+	return notes
+}
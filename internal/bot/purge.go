@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	recentMessagesKept = 100             // Most recent message IDs tracked per user and chat
+	recentMessagesTTL  = deletableWindow // Telegram doesn't allow bots to delete older messages
+)
+
+func (b *Bot) recentMessagesKey(chatID, userID int64) string {
+	return b.key("recent:%d:%d", chatID, userID)
+}
+
+// trackMessage remembers a user's message ID so it can be purged later
+func (b *Bot) trackMessage(ctx context.Context, message *tgbotapi.Message, userID int64) {
+	key := b.recentMessagesKey(message.Chat.ID, userID)
+	now := time.Now()
+
+	pipe := b.redis.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(message.Time().Unix()), Member: message.MessageID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-recentMessagesTTL).Unix(), 10))
+	pipe.ZRemRangeByRank(ctx, key, 0, -recentMessagesKept-1)
+	pipe.Expire(ctx, key, recentMessagesTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to track message", "error", err, "chatID", message.Chat.ID, "userID", userID)
+	}
+}
+
+// recentMessages returns the tracked message IDs of a user posted within the window
+func (b *Bot) recentMessages(ctx context.Context, chatID, userID int64, window time.Duration) ([]int, error) {
+	since := time.Now().Add(-window).Unix()
+	members, err := b.redis.ZRangeByScore(ctx, b.recentMessagesKey(chatID, userID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (b *Bot) purgeWindow() time.Duration {
+	if b.config.PurgeWindow > 0 && b.config.PurgeWindow < recentMessagesTTL {
+		return b.config.PurgeWindow
+	}
+	return recentMessagesTTL
+}
+
+// handlePurge deletes the recent messages of the replied-to user and bans them,
+// refusing to for chat admins and the bot itself
+func (b *Bot) handlePurge(message *tgbotapi.Message) {
+	target := message.ReplyToMessage
+	if target == nil || target.From == nil {
+		b.reply(message, "Reply to a message of the user to purge")
+		return
+	}
+
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	userID := target.From.ID
+	if userID == b.api.Self.ID {
+		b.reply(message, "The bot can't purge itself")
+		return
+	}
+	// Anonymous admins post on behalf of the chat itself
+	if b.isChatAdmin(chatID, userID) || target.SenderChat != nil && target.SenderChat.ID == chatID {
+		b.reply(message, "Chat admins can't be purged")
+		return
+	}
+
+	ids, err := b.recentMessages(ctx, chatID, userID, b.purgeWindow())
+	if err != nil {
+		b.logger.Error("Failed to load recent messages", "error", err, "chatID", chatID, "userID", userID)
+		b.reply(message, "Failed to load the user's recent messages")
+		return
+	}
+	ids = appendMissing(ids, target.MessageID)
+
+	deleted := b.deleteMessages(chatID, ids)
+	result := fmt.Sprintf("🧹 Purged %d of %d messages from user %d", deleted, len(ids), userID)
+	if err := b.restrictUser(chatID, userID); err != nil {
+		b.logger.Error("Failed to restrict purged user", "error", err, "userID", userID, "chatID", chatID)
+		result += "\nFailed to ban the user"
+	} else {
+		result += "\n👩‍⚖️User banned"
+	}
+
+	b.redis.Del(ctx, b.recentMessagesKey(chatID, userID))
+	b.logger.Info("Purged user", "userID", userID, "chatID", chatID, "deleted", deleted, "admin", commandSender(message))
+	b.reply(message, result)
+}
+
+func appendMissing(ids []int, id int) []int {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
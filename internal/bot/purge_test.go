@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// purgeBot returns a bot with a one hour purge window and testAdminID as the chat's admin
+func purgeBot(t *testing.T) (*Bot, *fakeMessenger) {
+	t.Helper()
+	config := testConfig()
+	config.PurgeWindow = time.Hour
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	return b, messenger
+}
+
+// postedAt returns a message of testUserID posted ago
+func postedAt(messageID int, ago time.Duration) *tgbotapi.Message {
+	message := textMessage(messageID, "hello")
+	message.Date = int(time.Now().Add(-ago).Unix())
+	return message
+}
+
+func TestPurgeDeletesMessagesWithinWindow(t *testing.T) {
+	b, messenger := purgeBot(t)
+	ctx := context.Background()
+	for _, message := range []*tgbotapi.Message{postedAt(1, 3*time.Hour), postedAt(2, 30*time.Minute), postedAt(3, time.Minute)} {
+		b.trackMessage(ctx, message, testUserID)
+	}
+	b.trackMessage(ctx, &tgbotapi.Message{MessageID: 4, Chat: &tgbotapi.Chat{ID: testChatID}, Date: int(time.Now().Unix())}, 99)
+	command := commandMessage(testAdminID, "/purge")
+	command.ReplyToMessage = textMessage(5, "spam")
+
+	b.handleCommand(command)
+
+	var deleted []int
+	for _, ids := range messenger.bulk {
+		deleted = append(deleted, ids...)
+	}
+	slices.Sort(deleted)
+	if !slices.Equal(deleted, []int{2, 3, 5}) {
+		t.Errorf("deleted = %v, want the user's messages within the window and the replied-to one", deleted)
+	}
+	if !slices.Equal(messenger.restricted, []int64{testUserID}) {
+		t.Errorf("restricted = %v, want the purged user banned", messenger.restricted)
+	}
+	if ids, _ := b.recentMessages(ctx, testChatID, testUserID, time.Hour); len(ids) != 0 {
+		t.Errorf("recent messages = %v, want them forgotten after the purge", ids)
+	}
+}
+
+func TestPurgeRefusesAdminsAndBot(t *testing.T) {
+	tests := []struct {
+		name   string
+		target *tgbotapi.Message
+	}{
+		{"admin", &tgbotapi.Message{MessageID: 5, From: &tgbotapi.User{ID: testAdminID}, Chat: &tgbotapi.Chat{ID: testChatID}}},
+		{"anonymous admin", &tgbotapi.Message{MessageID: 5, From: &tgbotapi.User{ID: 1087968824}, SenderChat: &tgbotapi.Chat{ID: testChatID}, Chat: &tgbotapi.Chat{ID: testChatID}}},
+		{"bot", &tgbotapi.Message{MessageID: 5, From: &tgbotapi.User{ID: testBotID, IsBot: true}, Chat: &tgbotapi.Chat{ID: testChatID}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, messenger := purgeBot(t)
+			command := commandMessage(testAdminID, "/purge")
+			command.ReplyToMessage = tt.target
+
+			b.handleCommand(command)
+
+			if len(messenger.bulk) != 0 || len(messenger.deleted) != 0 || len(messenger.restricted) != 0 {
+				t.Errorf("bulk = %v, deleted = %v, restricted = %v, want nothing purged", messenger.bulk, messenger.deleted, messenger.restricted)
+			}
+		})
+	}
+}
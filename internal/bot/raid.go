@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// autoRaidWindows is how many detection windows automatic raid mode outlasts the last burst
+const autoRaidWindows = 5
+
+func (b *Bot) raidKey(chatID int64) string {
+	return b.key("raid:%d", chatID)
+}
+
+func (b *Bot) newUserRateKey(chatID int64) string {
+	return b.key("new_user_rate:%d", chatID)
+}
+
+// trackNewUserMessage counts new users' messages per detection window and starts
+// or extends raid mode while their rate is above the configured one
+func (b *Bot) trackNewUserMessage(ctx context.Context, chatID int64) {
+	if b.config.RaidDetectRate <= 0 {
+		return
+	}
+	key := b.newUserRateKey(chatID)
+	// The first message opens the window; counting and expiring at once keeps a
+	// failure in between from leaving a counter that never expires
+	pipe := b.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, b.config.RaidDetectWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to count new user messages", "error", err, "chatID", chatID)
+		return
+	}
+	count := incr.Val()
+	if count < int64(b.config.RaidDetectRate) {
+		return
+	}
+
+	// Never shorten a raid mode started by an admin
+	duration := autoRaidWindows * b.config.RaidDetectWindow
+	ttl, err := b.redis.TTL(ctx, b.raidKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get raid mode", "error", err, "chatID", chatID)
+		return
+	}
+	if ttl >= duration {
+		return
+	}
+	if err := b.redis.Set(ctx, b.raidKey(chatID), time.Now().Unix(), duration).Err(); err != nil {
+		b.logger.Error("Failed to start raid mode", "error", err, "chatID", chatID)
+		return
+	}
+	if ttl <= 0 {
+		b.logger.Warn("Detected raid, started raid mode", "chatID", chatID, "newUserMessages", count, "window", b.config.RaidDetectWindow)
+		if logChannelID, exists := b.logChannel(chatID); exists {
+			b.sendLog(logChannelID, fmt.Sprintf("🚨 Raid detected in chat %d: %d messages from new users within %s. Raid mode is on until the rate subsides.", chatID, count, b.config.RaidDetectWindow))
+		}
+	}
+}
+
+// handleNewMembers remembers when and by whom new users were added and mutes users joining during a raid
+func (b *Bot) handleNewMembers(ctx context.Context, message *tgbotapi.Message, adminRights AdminRights) {
+	chatID := message.Chat.ID
+	if len(b.whitelistChannels) > 0 && !b.whitelistChannels[chatID] {
+		return
+	}
+	for _, member := range message.NewChatMembers {
+		if message.From != nil {
+			b.rememberInviter(ctx, chatID, member.ID, message.From.ID)
+		}
+		b.recordJoin(ctx, chatID, member.ID, message.Time())
+		member := member
+		b.goSafe(func() { b.screenProfile(chatID, member) })
+		b.goSafe(func() { b.screenImpersonation(chatID, member) })
+	}
+	if !adminRights.CanRestrictMembers || !b.raidMode(ctx, chatID) {
+		return
+	}
+	for _, member := range message.NewChatMembers {
+		if member.IsBot {
+			continue
+		}
+		if err := b.muteUser(chatID, member.ID, time.Now().Add(b.config.MuteDuration)); err != nil {
+			b.logger.Error("Failed to mute user joining during raid", "error", err, "userID", member.ID, "chatID", chatID)
+			continue
+		}
+		b.logger.Info("Muted user joining during raid", "userID", member.ID, "chatID", chatID, "duration", b.config.MuteDuration)
+	}
+}
+
+// raidMode reports whether raid mode is active in a chat; it expires on its own
+func (b *Bot) raidMode(ctx context.Context, chatID int64) bool {
+	active, err := b.redis.Exists(ctx, b.raidKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get raid mode", "error", err, "chatID", chatID)
+		return false
+	}
+	return active > 0
+}
+
+// effectiveThreshold returns the threshold messages are judged by, tightened to the raid threshold during a raid
+func (b *Bot) effectiveThreshold(ctx context.Context, chatID int64, raid bool) float64 {
+	threshold := b.threshold(ctx, chatID)
+	if raid && b.config.RaidThreshold < threshold {
+		return b.config.RaidThreshold
+	}
+	return threshold
+}
+
+// handleRaidCommand shows, starts or ends raid mode: /raid [on [duration]|off]
+func (b *Bot) handleRaidCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	args := strings.Fields(message.CommandArguments())
+
+	if len(args) == 0 {
+		ttl, err := b.redis.TTL(ctx, b.raidKey(chatID)).Result()
+		if err != nil {
+			b.logger.Error("Failed to get raid mode", "error", err, "chatID", chatID)
+			b.reply(message, "Failed to get the raid mode")
+			return
+		}
+		if ttl <= 0 {
+			b.reply(message, "Raid mode is off")
+			return
+		}
+		b.reply(message, fmt.Sprintf("Raid mode is on for %s more, threshold %.2f", ttl.Round(time.Second), b.effectiveThreshold(ctx, chatID, true)))
+		return
+	}
+
+	switch args[0] {
+	case "on":
+		duration := b.config.RaidDuration
+		if len(args) > 1 {
+			parsed, err := time.ParseDuration(args[1])
+			if err != nil || parsed <= 0 {
+				b.reply(message, "Usage: /raid on [duration, e.g. 30m]")
+				return
+			}
+			duration = parsed
+		}
+		if err := b.redis.Set(ctx, b.raidKey(chatID), time.Now().Unix(), duration).Err(); err != nil {
+			b.logger.Error("Failed to start raid mode", "error", err, "chatID", chatID)
+			b.reply(message, "Failed to start raid mode")
+			return
+		}
+		b.logger.Info("Started raid mode", "chatID", chatID, "duration", duration, "admin", commandSender(message))
+		b.reply(message, fmt.Sprintf("🚨 Raid mode on for %s: threshold %.2f, label detections are banned", duration, b.effectiveThreshold(ctx, chatID, true)))
+	case "off":
+		// A burst still being counted would turn raid mode right back on
+		if err := b.redis.Del(ctx, b.raidKey(chatID), b.newUserRateKey(chatID)).Err(); err != nil {
+			b.logger.Error("Failed to end raid mode", "error", err, "chatID", chatID)
+			b.reply(message, "Failed to end raid mode")
+			return
+		}
+		b.logger.Info("Ended raid mode", "chatID", chatID, "admin", commandSender(message))
+		b.reply(message, "Raid mode off")
+	default:
+		b.reply(message, "Usage: /raid [on [duration]|off]")
+	}
+}
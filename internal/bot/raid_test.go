@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRaidDetectionCountsBurst(t *testing.T) {
+	config := testConfig()
+	config.RaidDetectRate = 20
+	config.RaidDetectWindow = time.Minute
+	b, _, server := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.trackNewUserMessage(ctx, testChatID)
+		}()
+	}
+	wg.Wait()
+
+	if count, _ := server.Get(b.newUserRateKey(testChatID)); count != "30" {
+		t.Errorf("new user messages = %s, want every message of the burst counted", count)
+	}
+	if ttl := server.TTL(b.newUserRateKey(testChatID)); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("detection window TTL = %v, want at most a minute", ttl)
+	}
+	if !b.raidMode(ctx, testChatID) {
+		t.Error("raid mode is off after the burst")
+	}
+
+	server.FastForward(time.Minute)
+	b.trackNewUserMessage(ctx, testChatID)
+	if count, _ := server.Get(b.newUserRateKey(testChatID)); count != "1" {
+		t.Errorf("new user messages = %s, want the next window counted from 1", count)
+	}
+}
+
+func TestRaidOffResetsDetection(t *testing.T) {
+	config := testConfig()
+	config.RaidDetectRate = 20
+	config.RaidDetectWindow = time.Minute
+	b, messenger, server := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		b.trackNewUserMessage(ctx, testChatID)
+	}
+
+	b.handleCommand(commandMessage(testAdminID, "/raid off"))
+	b.trackNewUserMessage(ctx, testChatID)
+
+	if b.raidMode(ctx, testChatID) {
+		t.Error("the ongoing burst turned raid mode back on")
+	}
+	if count, _ := server.Get(b.newUserRateKey(testChatID)); count != "1" {
+		t.Errorf("new user messages = %s, want the count reset by /raid off", count)
+	}
+}
+
+func TestRaidModeTightensThresholdUntilExpiry(t *testing.T) {
+	config := testConfig()
+	config.RaidThreshold = 0.3
+	config.RaidDuration = time.Hour
+	b, messenger, server := newTestBot(t, config, &countingProvider{response: `{"reasoning": "borderline", "spam_score": 0.4}`})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	ctx := context.Background()
+
+	b.handleCommand(commandMessage(testAdminID, "/raid on 30m"))
+	if threshold := b.effectiveThreshold(ctx, testChatID, b.raidMode(ctx, testChatID)); threshold != 0.3 {
+		t.Errorf("threshold during a raid = %.2f, want the raid threshold", threshold)
+	}
+	handle(b, textMessage(10, "borderline message"))
+	if !slices.Equal(messenger.deleted, []int{10}) {
+		t.Errorf("deleted = %v, want a message above the raid threshold deleted", messenger.deleted)
+	}
+
+	server.FastForward(31 * time.Minute)
+	if b.raidMode(ctx, testChatID) {
+		t.Fatal("raid mode is still on after its duration")
+	}
+	if threshold := b.effectiveThreshold(ctx, testChatID, b.raidMode(ctx, testChatID)); threshold != 0.5 {
+		t.Errorf("threshold after the raid = %.2f, want the chat's threshold", threshold)
+	}
+	next := textMessage(11, "another borderline message")
+	next.From = &tgbotapi.User{ID: 43, FirstName: "Other"}
+	handle(b, next)
+	if !slices.Equal(messenger.deleted, []int{10}) {
+		t.Errorf("deleted = %v, want the same score let through after the raid", messenger.deleted)
+	}
+}
+
+func TestRaidModeNeverLoosensThreshold(t *testing.T) {
+	config := testConfig()
+	config.Threshold = 0.2
+	config.RaidThreshold = 0.3
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	if threshold := b.effectiveThreshold(context.Background(), testChatID, true); threshold != 0.2 {
+		t.Errorf("threshold during a raid = %.2f, want the stricter chat threshold kept", threshold)
+	}
+}
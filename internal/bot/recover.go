@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// recoverUpdate keeps a panic while handling an update from crashing the bot,
+// logging and reporting it instead. It must be deferred directly.
+func (b *Bot) recoverUpdate(update tgbotapi.Update) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	stack := debug.Stack()
+	b.logger.Error("Recovered from panic while handling update", "panic", value, "updateID", update.UpdateID, "stack", string(stack))
+	b.reporter.CapturePanic(value, stack, updateTags(update))
+}
+
+// goSafe runs fn in a goroutine, logging and reporting a panic in it instead of
+// crashing the bot as an unrecovered panic in any goroutine would
+func (b *Bot) goSafe(fn func()) {
+	go func() {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			stack := debug.Stack()
+			b.logger.Error("Recovered from panic in background task", "panic", value, "stack", string(stack))
+			b.reporter.CapturePanic(value, stack, map[string]string{"stage": "background"})
+		}()
+		fn()
+	}()
+}
+
+// reportUnexpected reports an error unless it has a known transient cause such as
+// rate limiting, provider outages or unparseable responses
+func (b *Bot) reportUnexpected(err error, tags map[string]string) {
+	for _, expected := range []error{ai.ErrRateLimited, ai.ErrServer, ai.ErrTimeout, ai.ErrParse, context.Canceled} {
+		if errors.Is(err, expected) {
+			return
+		}
+	}
+	b.reporter.CaptureError(err, tags)
+}
+
+// updateTags describes an update for error reports without including message content
+func updateTags(update tgbotapi.Update) map[string]string {
+	tags := map[string]string{"update_id": strconv.Itoa(update.UpdateID)}
+	if chat := update.FromChat(); chat != nil {
+		tags["chat_id"] = strconv.FormatInt(chat.ID, 10)
+	}
+	return tags
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// fakeReporter passes on the panics and errors it receives
+type fakeReporter struct {
+	panics chan any
+	errors chan error
+}
+
+func newFakeReporter() *fakeReporter {
+	return &fakeReporter{panics: make(chan any, 10), errors: make(chan error, 10)}
+}
+
+func (r *fakeReporter) CaptureError(err error, tags map[string]string) { r.errors <- err }
+func (r *fakeReporter) CapturePanic(value any, stack []byte, tags map[string]string) {
+	r.panics <- value
+}
+func (r *fakeReporter) Flush(timeout time.Duration) {}
+
+func TestGoSafeRecoversPanics(t *testing.T) {
+	reporter := newFakeReporter()
+	config := testConfig()
+	config.Reporter = reporter
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	b.goSafe(func() { panic("screening failed") })
+
+	select {
+	case value := <-reporter.panics:
+		if value != "screening failed" {
+			t.Errorf("reported panic = %v, want the handler's", value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the panic wasn't reported")
+	}
+
+	done := make(chan struct{})
+	b.goSafe(func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a task didn't run after a recovered panic")
+	}
+}
+
+// panickingProvider panics on every classification
+type panickingProvider struct{}
+
+func (panickingProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	panic("provider bug")
+}
+
+func TestPanickingShadowClassificationIsReported(t *testing.T) {
+	reporter := newFakeReporter()
+	config := testConfig()
+	config.Reporter = reporter
+	config.ShadowProvider = panickingProvider{}
+	b, messenger, _ := newTestBot(t, config, &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`})
+
+	handle(b, textMessage(10, "earn $500 a day from home"))
+
+	select {
+	case value := <-reporter.panics:
+		if value != "provider bug" {
+			t.Errorf("reported panic = %v, want the shadow provider's", value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the shadow provider's panic wasn't reported")
+	}
+	if len(messenger.deleted) != 1 {
+		t.Errorf("deleted = %v, the primary decision doesn't depend on the shadow provider", messenger.deleted)
+	}
+}
+
+func TestReportUnexpectedSkipsTransientErrors(t *testing.T) {
+	reporter := newFakeReporter()
+	config := testConfig()
+	config.Reporter = reporter
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	b.reportUnexpected(ai.ErrRateLimited, nil)
+	b.reportUnexpected(errors.New("unexpected"), nil)
+
+	if len(reporter.errors) != 1 {
+		t.Fatalf("reported %d errors, want only the unexpected one", len(reporter.errors))
+	}
+	if err := <-reporter.errors; err.Error() != "unexpected" {
+		t.Errorf("reported %v, want the unexpected error", err)
+	}
+}
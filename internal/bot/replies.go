@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ReplyPolicy controls how replies are scanned, in particular replies to admins,
+// which are rarely spam
+type ReplyPolicy string
+
+const (
+	ReplyPolicyIgnore        ReplyPolicy = "ignore-replies"         // Never scan replies
+	ReplyPolicySkipAdmin     ReplyPolicy = "skip-admin-replies"     // Scan replies, except established users replying to admins
+	ReplyPolicyDiscountAdmin ReplyPolicy = "discount-admin-replies" // Scan replies, weighting down the score of replies to admins
+)
+
+func ParseReplyPolicy(value string) (ReplyPolicy, error) {
+	switch policy := ReplyPolicy(value); policy {
+	case ReplyPolicyIgnore, ReplyPolicySkipAdmin, ReplyPolicyDiscountAdmin:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown reply-to-admin policy: %s", value)
+	}
+}
+
+// isReplyToAdmin reports whether a message replies to a message by an admin of the chat
+func (b *Bot) isReplyToAdmin(message *tgbotapi.Message) bool {
+	reply := message.ReplyToMessage
+	if reply == nil {
+		return false
+	}
+	if reply.SenderChat != nil {
+		return reply.SenderChat.ID == message.Chat.ID // Posted by an anonymous admin
+	}
+	return reply.From != nil && b.chatAdmins(message.Chat.ID)[reply.From.ID]
+}
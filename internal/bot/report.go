@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	evidenceKept = 20                  // Flagged messages kept per user and chat for reports
+	evidenceTTL  = 30 * 24 * time.Hour // Evidence of users who stopped posting expires
+)
+
+// evidence is a flagged message kept for spammer reports
+type evidence struct {
+	MessageID int       `json:"message_id"`
+	Text      string    `json:"text"`
+	Label     string    `json:"label"`
+	Score     float64   `json:"score"`
+	Action    Action    `json:"action"`
+	Time      time.Time `json:"time"`
+}
+
+// spamReport collects what is known about a spammer in a chat
+type spamReport struct {
+	ChatID     int64      `json:"chat_id"`
+	ChatTitle  string     `json:"chat_title,omitempty"`
+	UserID     int64      `json:"user_id"`
+	Username   string     `json:"username,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Trigger    string     `json:"trigger"`
+	Messages   []evidence `json:"messages"`
+	ReportedAt time.Time  `json:"reported_at"`
+}
+
+func (b *Bot) reportingEnabled() bool {
+	return b.config.ReportChannel != 0 || b.config.ReportDir != ""
+}
+
+func (b *Bot) evidenceKey(chatID, userID int64) string {
+	return b.key("evidence:%d:%d", chatID, userID)
+}
+
+// recordEvidence keeps a flagged message for later reports
+func (b *Bot) recordEvidence(ctx context.Context, message *tgbotapi.Message, userID int64, item evidence) {
+	if !b.reportingEnabled() {
+		return
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		b.logger.Error("Failed to encode evidence", "error", err)
+		return
+	}
+	key := b.evidenceKey(message.Chat.ID, userID)
+	pipe := b.redis.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, evidenceKept-1)
+	pipe.Expire(ctx, key, evidenceTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to store evidence", "error", err, "chatID", message.Chat.ID, "userID", userID)
+	}
+}
+
+// compileReport builds the report about the sender of a message from the stored evidence
+func (b *Bot) compileReport(ctx context.Context, message *tgbotapi.Message, userID int64, trigger string) (spamReport, error) {
+	report := spamReport{
+		ChatID:     message.Chat.ID,
+		ChatTitle:  message.Chat.Title,
+		UserID:     userID,
+		Trigger:    trigger,
+		ReportedAt: time.Now(),
+	}
+	if message.From != nil && message.From.ID == userID {
+		report.Username = message.From.UserName
+		report.Name = strings.TrimSpace(message.From.FirstName + " " + message.From.LastName)
+	}
+
+	stored, err := b.redis.LRange(ctx, b.evidenceKey(message.Chat.ID, userID), 0, -1).Result()
+	if err != nil {
+		return report, err
+	}
+	for i := len(stored) - 1; i >= 0; i-- { // Stored newest first
+		var item evidence
+		if err := json.Unmarshal([]byte(stored[i]), &item); err == nil {
+			report.Messages = append(report.Messages, item)
+		}
+	}
+	return report, nil
+}
+
+// text renders the report for the reporting channel
+func (r spamReport) text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🚨 Spammer report (%s)\n", r.Trigger)
+	fmt.Fprintf(&sb, "User ID: %d\n", r.UserID)
+	if r.Username != "" {
+		fmt.Fprintf(&sb, "Username: @%s\n", r.Username)
+	}
+	if r.Name != "" {
+		fmt.Fprintf(&sb, "Name: %s\n", r.Name)
+	}
+	fmt.Fprintf(&sb, "Chat: %s (%d)\n", r.ChatTitle, r.ChatID)
+	fmt.Fprintf(&sb, "Messages: %d\n", len(r.Messages))
+	for _, item := range r.Messages {
+		fmt.Fprintf(&sb, "\n[%s] %s %.2f, %s\n%s\n", item.Time.UTC().Format(time.RFC3339), item.Label, item.Score, item.Action, truncate(item.Text, 500))
+	}
+	return truncate(sb.String(), 4000) // Telegram messages are limited to 4096 characters
+}
+
+// report compiles a spammer report and posts it to the reporting channel and/or saves it as a file
+func (b *Bot) report(ctx context.Context, message *tgbotapi.Message, userID int64, trigger string) error {
+	report, err := b.compileReport(ctx, message, userID, trigger)
+	if err != nil {
+		return fmt.Errorf("error compiling report: %w", err)
+	}
+
+	if b.config.ReportChannel != 0 {
+		b.sendLog(b.config.ReportChannel, report.text())
+	}
+	if b.config.ReportDir != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding report: %w", err)
+		}
+		path := filepath.Join(b.config.ReportDir, fmt.Sprintf("%d_%d.json", report.ChatID, report.UserID))
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("error saving report: %w", err)
+		}
+	}
+	b.logger.Info("Reported spammer", "userID", userID, "chatID", report.ChatID, "messages", len(report.Messages), "trigger", trigger)
+	return nil
+}
+
+// handleReportCommand reports the sender of the replied message: /report
+func (b *Bot) handleReportCommand(message *tgbotapi.Message) {
+	target := message.ReplyToMessage
+	if target == nil || target.From == nil {
+		b.reply(message, "Reply to a message to report its sender")
+		return
+	}
+	if !b.reportingEnabled() {
+		b.reply(message, "Reporting is not configured")
+		return
+	}
+
+	ctx := context.Background()
+	b.recordEvidence(ctx, target, target.From.ID, evidence{
+		MessageID: target.MessageID,
+		Text:      messageText(target),
+		Label:     "Reported by admin",
+		Action:    ActionNotify,
+		Time:      target.Time(),
+	})
+	if err := b.report(ctx, target, target.From.ID, "reported by admin"); err != nil {
+		b.logger.Error("Failed to report spammer", "error", err, "userID", target.From.ID)
+		b.reply(message, "Failed to report the user")
+		return
+	}
+	b.reply(message, "📋 User reported")
+}
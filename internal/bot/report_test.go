@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testReportChatID = -3001
+
+// reportedMessage returns a message of testUserID in a titled chat
+func reportedMessage(messageID int, text string) *tgbotapi.Message {
+	message := textMessage(messageID, text)
+	message.From = &tgbotapi.User{ID: testUserID, FirstName: "Spam", LastName: "Bot", UserName: "spambot"}
+	message.Chat.Title = "Giraffes"
+	return message
+}
+
+func TestCompileReport(t *testing.T) {
+	config := testConfig()
+	config.ReportChannel = testReportChatID
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	posted := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	first := evidence{MessageID: 10, Text: "cheap crypto", Label: spamLabel, Score: 0.9, Action: ActionBan, Time: posted}
+	second := evidence{MessageID: 11, Text: "dm me", Label: "scam", Score: 0.8, Action: ActionDelete, Time: posted.Add(time.Minute)}
+	b.recordEvidence(ctx, reportedMessage(10, first.Text), testUserID, first)
+	b.recordEvidence(ctx, reportedMessage(11, second.Text), testUserID, second)
+	other := reportedMessage(12, "hello")
+	other.From = &tgbotapi.User{ID: 43, FirstName: "Other"}
+	b.recordEvidence(ctx, other, 43, evidence{MessageID: 12, Text: "hello", Label: spamLabel, Score: 0.6, Action: ActionDelete, Time: posted})
+
+	report, err := b.compileReport(ctx, reportedMessage(11, second.Text), testUserID, "banned")
+	if err != nil {
+		t.Fatalf("compileReport: %v", err)
+	}
+	if report.ChatID != testChatID || report.ChatTitle != "Giraffes" || report.UserID != testUserID || report.Trigger != "banned" {
+		t.Errorf("report = %+v, want the chat, user and trigger", report)
+	}
+	if report.Username != "spambot" || report.Name != "Spam Bot" {
+		t.Errorf("username, name = %q, %q, want the sender's", report.Username, report.Name)
+	}
+	if !reflect.DeepEqual(report.Messages, []evidence{first, second}) {
+		t.Errorf("messages = %+v, want the user's evidence oldest first", report.Messages)
+	}
+
+	text := report.text()
+	for _, want := range []string{
+		"Spammer report (banned)",
+		"User ID: 42",
+		"Username: @spambot",
+		"Name: Spam Bot",
+		"Chat: Giraffes (-1001)",
+		"Messages: 2",
+		"[2026-10-01T12:00:00Z] Spam 0.90, ban\ncheap crypto",
+		"[2026-10-01T12:01:00Z] scam 0.80, delete\ndm me",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report text = %q, missing %q", text, want)
+		}
+	}
+	if strings.Contains(text, "hello") {
+		t.Errorf("report text = %q, includes another user's evidence", text)
+	}
+}
+
+func TestCompileReportOfAnotherSender(t *testing.T) {
+	config := testConfig()
+	config.ReportChannel = testReportChatID
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	// Reported through a message of someone else, e.g. the one who forwarded it
+	report, err := b.compileReport(context.Background(), reportedMessage(10, "hello"), 43, "reported by admin")
+	if err != nil {
+		t.Fatalf("compileReport: %v", err)
+	}
+	if report.UserID != 43 || report.Username != "" || report.Name != "" || len(report.Messages) != 0 {
+		t.Errorf("report = %+v, want only the user ID without the message sender's names", report)
+	}
+}
+
+func TestRecordEvidenceBounded(t *testing.T) {
+	config := testConfig()
+	config.ReportChannel = testReportChatID
+	b, _, server := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	for id := 1; id <= evidenceKept+5; id++ {
+		b.recordEvidence(ctx, reportedMessage(id, "spam"), testUserID, evidence{MessageID: id, Text: "spam"})
+	}
+	report, err := b.compileReport(ctx, reportedMessage(1, "spam"), testUserID, "banned")
+	if err != nil {
+		t.Fatalf("compileReport: %v", err)
+	}
+	if len(report.Messages) != evidenceKept {
+		t.Fatalf("report has %d messages, want %d", len(report.Messages), evidenceKept)
+	}
+	if first := report.Messages[0].MessageID; first != 6 {
+		t.Errorf("report starts at message %d, want the newest %d from 6", first, evidenceKept)
+	}
+	if ttl := server.TTL(b.evidenceKey(testChatID, testUserID)); ttl <= 0 || ttl > evidenceTTL {
+		t.Errorf("TTL = %v, want up to %v", ttl, evidenceTTL)
+	}
+}
+
+func TestRecordEvidenceDisabled(t *testing.T) {
+	b, _, server := newTestBot(t, testConfig(), &countingProvider{})
+
+	b.recordEvidence(context.Background(), reportedMessage(10, "spam"), testUserID, evidence{MessageID: 10})
+	if server.Exists(b.evidenceKey(testChatID, testUserID)) {
+		t.Error("evidence kept without a report channel or directory")
+	}
+}
+
+func TestReportPostsAndSaves(t *testing.T) {
+	config := testConfig()
+	config.ReportChannel = testReportChatID
+	config.ReportDir = t.TempDir()
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	ctx := context.Background()
+
+	message := reportedMessage(10, "cheap crypto")
+	b.recordEvidence(ctx, message, testUserID, evidence{MessageID: 10, Text: "cheap crypto", Label: spamLabel, Score: 0.9, Action: ActionBan})
+	if err := b.report(ctx, message, testUserID, "banned"); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+
+	var posted *OutgoingMessage
+	for i := range messenger.sent {
+		if messenger.sent[i].ChatID == testReportChatID {
+			posted = &messenger.sent[i]
+		}
+	}
+	if posted == nil || !strings.Contains(posted.Text, "cheap crypto") {
+		t.Errorf("sent %+v, want the report in the report channel", messenger.sent)
+	}
+
+	data, err := os.ReadFile(filepath.Join(config.ReportDir, "-1001_42.json"))
+	if err != nil {
+		t.Fatalf("reading the saved report: %v", err)
+	}
+	var saved spamReport
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("decoding the saved report: %v", err)
+	}
+	if saved.UserID != testUserID || saved.Trigger != "banned" || len(saved.Messages) != 1 || saved.Messages[0].Text != "cheap crypto" {
+		t.Errorf("saved report = %+v, want the compiled one", saved)
+	}
+}
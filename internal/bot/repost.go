@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	repostSimilarity = 0.6 // Share of words a repost has in common with the earlier message
+	repostsKept      = 5   // Recent texts of a user compared against
+	repostTextChars  = 1000
+	repostMinWords   = 5 // Distinct words both texts need, as short greetings and replies repeat naturally
+)
+
+// postedText is a recent message of a user, kept to spot reposts of it
+type postedText struct {
+	MessageID int    `json:"message_id"`
+	Text      string `json:"text"`
+}
+
+func (b *Bot) postedTextsKey(chatID, userID int64) string {
+	return b.key("posted:%d:%d", chatID, userID)
+}
+
+func (b *Bot) repostKey(chatID int64, messageID int) string {
+	return b.key("repost:%d:%d", chatID, messageID)
+}
+
+// detectRepost remembers a new user's message and reports whether it repeats one they
+// posted within RepostWindow. The Bot API doesn't tell bots about deleted messages,
+// so posting, deleting and posting again shows as the same user sending a near-copy
+// shortly after. Detected reposts are flagged for RepostBoost.
+func (b *Bot) detectRepost(ctx context.Context, message *tgbotapi.Message, userID int64, text string) (float64, bool) {
+	if b.config.RepostWindow <= 0 {
+		return 0, false
+	}
+	key := b.postedTextsKey(message.Chat.ID, userID)
+	values, err := b.redis.LRange(ctx, key, 0, repostsKept-1).Result()
+	if err != nil {
+		b.logger.Error("Failed to get recent texts", "error", err, "chatID", message.Chat.ID, "userID", userID)
+		return 0, false
+	}
+
+	current := wordSet(truncate(text, repostTextChars))
+	var similarity float64
+	for _, value := range values {
+		var posted postedText
+		if err := json.Unmarshal([]byte(value), &posted); err != nil || posted.MessageID == message.MessageID {
+			continue
+		}
+		earlier := wordSet(posted.Text)
+		if len(current) < repostMinWords || len(earlier) < repostMinWords {
+			continue
+		}
+		similarity = max(similarity, jaccard(current, earlier))
+	}
+
+	data, err := json.Marshal(postedText{MessageID: message.MessageID, Text: truncate(text, repostTextChars)})
+	if err != nil {
+		b.logger.Error("Failed to encode recent text", "error", err)
+		return 0, false
+	}
+	pipe := b.redis.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, repostsKept-1)
+	pipe.Expire(ctx, key, b.config.RepostWindow)
+	if similarity >= repostSimilarity {
+		// Kept as long as the message may still be acted on, e.g. by /simulate or a cost capped fallback
+		pipe.Set(ctx, b.repostKey(message.Chat.ID, message.MessageID), similarity, deletableWindow)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to remember recent text", "error", err, "chatID", message.Chat.ID, "userID", userID)
+	}
+	if similarity < repostSimilarity {
+		return 0, false
+	}
+	b.logger.Info("Repost from new user", "chatID", message.Chat.ID, "userID", userID, "messageID", message.MessageID, "similarity", similarity)
+	return similarity, true
+}
+
+// isRepost reports whether a message was detected as a repost
+func (b *Bot) isRepost(ctx context.Context, chatID int64, messageID int) bool {
+	exists, err := b.redis.Exists(ctx, b.repostKey(chatID, messageID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to check repost", "error", err, "chatID", chatID, "messageID", messageID)
+		return false
+	}
+	return exists > 0
+}
+
+// recordedRepost returns the similarity of a message detected as a repost to the earlier
+// one and whether it was, without remembering the message as detectRepost does
+func (b *Bot) recordedRepost(ctx context.Context, message *tgbotapi.Message) (float64, bool) {
+	similarity, err := b.redis.Get(ctx, b.repostKey(message.Chat.ID, message.MessageID)).Float64()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to check repost", "error", err, "chatID", message.Chat.ID, "messageID", message.MessageID)
+		}
+		return 0, false
+	}
+	return similarity, true
+}
+
+// wordSet returns the distinct lowercased words of text, ignoring punctuation and emoji
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// jaccard returns the share of words two sets have in common among all their words
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteThenRepost(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		repost   string
+		detected bool
+	}{
+		{"reworded copy", "Join my channel for free crypto signals today", "Join my channel for free crypto signals now!", true},
+		{"same short greeting", "hi everyone", "hi everyone", false},
+		{"short reply to a long message", "Join my channel for free crypto signals today", "Join my channel", false},
+		{"different message", "Join my channel for free crypto signals today", "Does anyone know when the next meetup is planned?", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.NewUserThreshold = 3
+			config.RepostWindow = 10 * time.Minute
+			config.RepostAction = ActionDelete
+			provider := &countingProvider{response: `{"reasoning": "", "spam_score": 0.1}`}
+			b, messenger, _ := newTestBot(t, config, provider)
+
+			handle(b, textMessage(10, tt.first))
+			// The spammer deletes the message, which the bot isn't told about, and posts again
+			handle(b, textMessage(11, tt.repost))
+
+			if detected := len(messenger.deleted) == 1 && messenger.deleted[0] == 11; detected != tt.detected {
+				t.Errorf("deleted = %v, want repost detected %t", messenger.deleted, tt.detected)
+			}
+			want := 2
+			if tt.detected {
+				want = 1 // Reposts are acted on without classification
+			}
+			if calls := provider.callCount(); calls != want {
+				t.Errorf("classified %d messages, want %d", calls, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// ScanFilter selects which messages of established users reach the classifier
+type ScanFilter string
+
+const (
+	ScanFilterAll            ScanFilter = "all"              // Scan every message
+	ScanFilterLinksMediaOnly ScanFilter = "links-media-only" // Scan only messages with links, mentions, media or forwards
+)
+
+func ParseScanFilter(value string) (ScanFilter, error) {
+	switch filter := ScanFilter(value); filter {
+	case ScanFilterAll, ScanFilterLinksMediaOnly:
+		return filter, nil
+	default:
+		return "", fmt.Errorf("unknown scan filter: %s", value)
+	}
+}
+
+// sampled reports whether a message from an established user should be scanned.
+// The decision is derived from a hash of the chat and message IDs, so it is
+// reproducible from the logs.
+func (b *Bot) sampled(chatID int64, messageID int) bool {
+	if b.config.SampleRate <= 0 {
+		return false
+	}
+	if b.config.SampleRate >= 1 {
+		return true
+	}
+	return sampleValue(chatID, messageID) < b.config.SampleRate
+}
+
+// sampleValue maps a message to a deterministic value in [0, 1)
+func sampleValue(chatID int64, messageID int) float64 {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(chatID))
+	binary.BigEndian.PutUint64(buf[8:], uint64(messageID))
+
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return float64(h.Sum64()>>11) / float64(math.MaxUint64>>11+1)
+}
@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+)
+
+const (
+	maxUpdateBytes = 4 << 20 // Webhook request bodies are cut off here, far above any real update
+	garbageRatio   = 0.5     // Share of invalid or control characters from which a text is binary garbage
+)
+
+// sanitizeText replaces invalid UTF-8 with U+FFFD, drops control characters other
+// than whitespace and cuts the text to limit bytes if limit is positive. It returns
+// the text with the problems found, and an empty text if it was mostly garbage.
+func sanitizeText(text string, limit int) (string, []string) {
+	var problems []string
+	if limit > 0 && len(text) > limit {
+		// Cut before scanning, so megabytes of text cost no more than the limit
+		text = truncate(text, limit)
+		problems = append(problems, "oversized")
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+	var runes, invalid, control int
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		runes++
+		switch {
+		case r == utf8.RuneError && size == 1:
+			invalid++
+			if !strings.HasSuffix(sb.String(), string(utf8.RuneError)) {
+				sb.WriteRune(utf8.RuneError)
+			}
+		case unicode.IsControl(r) && !unicode.IsSpace(r):
+			control++
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if invalid > 0 {
+		problems = append(problems, "invalid_utf8")
+	}
+	if control > 0 {
+		problems = append(problems, "control_characters")
+	}
+	if runes > 0 && float64(invalid+control)/float64(runes) > garbageRatio {
+		return "", append(problems, "garbage")
+	}
+	if len(problems) == 0 {
+		return text, nil
+	}
+	sanitized := sb.String()
+	if limit > 0 && len(sanitized) > limit {
+		// Each invalid byte became a 3-byte U+FFFD
+		sanitized = truncate(sanitized, limit)
+	}
+	return sanitized, problems
+}
+
+// sanitizeMessage applies sanitizeText to every text of a message the bot processes,
+// with MaxTextLength, so no text can stall hashing, signals or the provider. Texts
+// that are mostly garbage are dropped, leaving the message treated as textless.
+func (b *Bot) sanitizeMessage(message *tgbotapi.Message) {
+	if message == nil {
+		return
+	}
+	var found []string
+	sanitize := func(text *string) {
+		if *text == "" {
+			return
+		}
+		var problems []string
+		*text, problems = sanitizeText(*text, b.config.MaxTextLength)
+		for _, problem := range problems {
+			if !slices.Contains(found, problem) {
+				found = append(found, problem)
+			}
+		}
+	}
+	sanitize(&message.Text)
+	sanitize(&message.Caption)
+	if message.Poll != nil {
+		sanitize(&message.Poll.Question)
+		for i := range message.Poll.Options {
+			sanitize(&message.Poll.Options[i].Text)
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	for _, problem := range found {
+		metrics.MessagesSanitized.WithLabelValues(problem).Inc()
+	}
+	b.logger.Warn("Sanitized malformed message", "chatID", message.Chat.ID, "messageID", message.MessageID, "problems", found)
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		want     string
+		problems []string
+	}{
+		{"clean", "hello world\n", 100, "hello world\n", nil},
+		{"invalid UTF-8", "buy \xff\xfe now", 100, "buy � now", []string{"invalid_utf8"}},
+		{"control characters", "free\x00 \x07coins", 100, "free coins", []string{"control_characters"}},
+		{"whitespace kept", "a\tb\r\nc", 100, "a\tb\r\nc", nil},
+		{"garbage", "\xff\xfe\x00\x01ab", 100, "", []string{"invalid_utf8", "control_characters", "garbage"}},
+		{"oversized", strings.Repeat("a", 50), 10, strings.Repeat("a", 10) + "…", []string{"oversized"}},
+		{"no limit", strings.Repeat("a", 50), 0, strings.Repeat("a", 50), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, problems := sanitizeText(tt.text, tt.limit)
+			if got != tt.want {
+				t.Errorf("sanitizeText() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(problems, tt.problems) {
+				t.Errorf("problems = %v, want %v", problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestSanitizeTextCutsOnRuneBoundary(t *testing.T) {
+	got, problems := sanitizeText(strings.Repeat("я", 10), 5) // 2 bytes per letter
+	if !utf8.ValidString(got) || got != "яя…" {
+		t.Errorf("sanitizeText() = %q, want two whole letters", got)
+	}
+	if !slices.Equal(problems, []string{"oversized"}) {
+		t.Errorf("problems = %v, want only oversized", problems)
+	}
+}
+
+func TestSanitizeTextOfMegabytes(t *testing.T) {
+	huge := strings.Repeat("spam \xff ", 1<<20)
+	got, problems := sanitizeText(huge, 4096)
+	if len(got) > 4096+len("…") || !utf8.ValidString(got) {
+		t.Errorf("sanitized %d bytes, valid UTF-8 %t, want at most the limit", len(got), utf8.ValidString(got))
+	}
+	if !slices.Equal(problems, []string{"oversized", "invalid_utf8"}) {
+		t.Errorf("problems = %v", problems)
+	}
+}
+
+func TestGarbageMessageIsTreatedAsTextless(t *testing.T) {
+	config := testConfig()
+	config.MaxTextLength = 4096
+	provider := &countingProvider{response: `{"reasoning": "", "spam_score": 0.1}`}
+	b, _, _ := newTestBot(t, config, provider)
+	message := textMessage(10, "\xff\xfe\xfd\x00\x01\x02")
+	message.Caption = "caption \x00"
+
+	b.sanitizeMessage(message)
+
+	if message.Text != "" || message.Caption != "caption " {
+		t.Errorf("text = %q, caption = %q, want the garbage text dropped and the caption cleaned", message.Text, message.Caption)
+	}
+
+	poll := &tgbotapi.Message{Chat: message.Chat, Poll: &tgbotapi.Poll{Question: "vote \xff", Options: []tgbotapi.PollOption{{Text: "yes\x00"}}}}
+	b.sanitizeMessage(poll)
+	if poll.Poll.Question != "vote �" || poll.Poll.Options[0].Text != "yes" {
+		t.Errorf("poll = %q, %q, want its texts sanitized", poll.Poll.Question, poll.Poll.Options[0].Text)
+	}
+}
@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// screening is the outcome of the checks a message goes through before the classifier.
+// At most one of Skip, Verdict and Heuristics is set; if none is, the message is classified.
+type screening struct {
+	Skip       string   // Why the message is let through unclassified
+	Verdict    *verdict // Action taken without classification
+	Detail     string   // What matched for a verdict, such as the blocklist entry
+	Heuristics string   // Reasoning of a message scored by the heuristic signals alone
+	CacheSpam  bool     // The verdict adds the message to the spam cache, as for near-duplicates
+	CostCapped bool     // Classification was skipped because of the daily cost cap
+	CountClean bool     // A heuristics-only message counts as clean unless acted on
+
+	countKey         string
+	count            int
+	newUserThreshold int
+	newUser          bool
+	messageHash      string
+	replyToAdmin     bool
+	thread           int
+	threadRegular    bool
+	embedding        []float32
+	raid             bool
+	threshold        float64
+}
+
+// ignoredReply reports whether a message is skipped for being a reply
+func (b *Bot) ignoredReply(message *tgbotapi.Message) bool {
+	return message.ReplyToMessage != nil && b.config.ReplyPolicy == ReplyPolicyIgnore
+}
+
+// screen runs the checks deciding on a message before and instead of the classifier.
+// Live messages are tracked on the way, while a dry run for /simulate only reads
+// the state earlier messages left.
+func (b *Bot) screen(ctx context.Context, message *tgbotapi.Message, text string, senderID int64, dryRun bool) (screening, error) { //nolint:gocyclo
+	var s screening
+	channelID := message.Chat.ID
+	isSenderChat := message.SenderChat != nil
+
+	if isSenderChat && b.config.SenderChatPolicy != SenderChatPolicyScan {
+		s.Skip = "posted on behalf of a chat"
+		return s, nil
+	}
+	if len(b.whitelistChannels) > 0 && !b.whitelistChannels[channelID] {
+		s.Skip = "the chat isn't whitelisted"
+		return s, nil
+	}
+	if !dryRun {
+		b.trackMessage(ctx, message, senderID)
+		b.pushChatContext(ctx, message, senderID, text)
+	}
+	if !isSenderChat && b.isTrusted(ctx, channelID, senderID) {
+		s.Skip = "the user is trusted"
+		return s, nil
+	}
+
+	s.countKey = b.key("%d:%d", senderID, channelID)
+	count, err := b.redis.Get(ctx, s.countKey).Int()
+	if err != nil && err != redis.Nil {
+		return s, fmt.Errorf("error retrieving count: %w", err)
+	}
+	s.count = b.withTenure(ctx, channelID, senderID, count)
+	s.newUserThreshold = b.newUserThreshold(ctx, channelID)
+	s.newUser = s.count < s.newUserThreshold
+	if s.newUser && !dryRun {
+		b.trackNewUserMessage(ctx, channelID)
+	}
+
+	s.messageHash = b.hashMessage(text)
+	isSpam, err := b.isSpamMessage(ctx, s.messageHash)
+	if err != nil {
+		return s, fmt.Errorf("error checking spam cache: %w", err)
+	}
+	if isSpam {
+		s.Verdict = &verdict{Action: ActionDelete, Label: "Known spam", Score: 1, Threshold: b.config.Threshold}
+		return s, nil
+	}
+
+	// Messages matching the chat's blocklist are removed without classification, new users are banned
+	if entry, blocked := b.blocklistMatch(ctx, channelID, text); blocked {
+		action := ActionDelete
+		if s.newUser {
+			action = ActionBan
+		}
+		s.Verdict = &verdict{Action: action, Label: "Blocklisted phrase", Score: 1, Threshold: b.config.Threshold}
+		s.Detail = entry
+		return s, nil
+	}
+
+	// New users deleting and reposting a message to get past scanning can be acted on without classification
+	if s.newUser {
+		var similarity float64
+		var repost bool
+		if dryRun {
+			similarity, repost = b.recordedRepost(ctx, message)
+		} else {
+			similarity, repost = b.detectRepost(ctx, message, senderID, text)
+		}
+		if repost && b.config.RepostAction != "" {
+			s.Verdict = &verdict{Action: b.config.RepostAction, Label: "Repost", Score: similarity, Threshold: repostSimilarity}
+			return s, nil
+		}
+	}
+
+	if s.newUser && !isSenderChat && message.From != nil && !dryRun {
+		sender := *message.From
+		b.goSafe(func() { b.screenImpersonation(channelID, sender) })
+	}
+
+	// New users posting phone numbers, card numbers or wallets can be acted on without classification
+	if b.config.PaymentAction != "" && s.newUser {
+		if signals := paymentSignals(b.signalText(message, text)); len(signals) > 0 {
+			s.Verdict = &verdict{Action: b.config.PaymentAction, Label: "Payment details", Score: 1, Threshold: b.config.Threshold}
+			s.Detail = strings.Join(signals, ", ")
+			return s, nil
+		}
+	}
+
+	// New users' bare links give the classifier little to go on and can be acted on without it
+	if b.config.BareLinkAction != "" && s.newUser && isBareLink(message) {
+		s.Verdict = &verdict{Action: b.config.BareLinkAction, Label: "Bare link", Score: 1, Threshold: b.config.Threshold}
+		return s, nil
+	}
+
+	s.replyToAdmin = b.config.ReplyPolicy != ReplyPolicyIgnore && b.isReplyToAdmin(message)
+	if s.replyToAdmin && b.config.ReplyPolicy == ReplyPolicySkipAdmin && !s.newUser {
+		s.Skip = "reply to an admin from an established user"
+		return s, nil
+	}
+	// Established users who replied cleanly in a thread before are low-risk there
+	s.thread = b.threadOf(ctx, message)
+	s.threadRegular = !s.newUser && b.threadRegular(ctx, channelID, s.thread, senderID)
+	if s.threadRegular && b.config.ThreadWeight <= 0 {
+		s.Skip = "reply from a regular of the thread"
+		return s, nil
+	}
+
+	// Beyond the scan window only the spam cache applies, unless the message is sampled
+	if s.count >= b.scanWindow(s.newUserThreshold) && !b.sampled(channelID, message.MessageID) {
+		s.Skip = "the user is beyond the scan window and the message wasn't sampled"
+		return s, nil
+	}
+
+	// Established users are only scanned for messages passing the scan filter
+	if !s.newUser && b.config.ScanFilter == ScanFilterLinksMediaOnly && !hasLinksOrMedia(message, b.config.IgnoreCodeBlocks) {
+		s.Skip = "plain text from an established user"
+		return s, nil
+	}
+
+	// Polls without words can't be classified, new users posting them are reported instead
+	if message.Poll != nil && !hasUsableText(message.Poll) {
+		if !s.newUser {
+			s.Skip = "poll without words from an established user"
+			return s, nil
+		}
+		s.Verdict = &verdict{Action: ActionNotify, Label: "Textless poll", Score: 1, Threshold: b.config.Threshold}
+		return s, nil
+	}
+
+	// Acting on messages long after users saw them only adds noise
+	if age := time.Since(message.Time()); b.config.MaxMessageAge > 0 && age > b.config.MaxMessageAge {
+		s.Skip = fmt.Sprintf("the message is %s old", age.Round(time.Second))
+		return s, nil
+	}
+
+	// Reworded copies of known spam are caught by their embedding
+	s.embedding = b.embed(ctx, text)
+	if similarity, ok := b.nearDuplicateSpam(ctx, s.embedding); ok {
+		s.Verdict = &verdict{Action: ActionBan, Label: "Near-duplicate spam", Score: similarity, Threshold: b.config.NearDuplicateThreshold}
+		s.CacheSpam = true
+		return s, nil
+	}
+
+	s.raid = b.raidMode(ctx, channelID)
+	s.threshold = b.messageThreshold(ctx, channelID, s.raid, text)
+	if b.aiPaused.Load() {
+		s.Heuristics = "Not classified, AI classification is paused"
+		return s, nil
+	}
+	if b.costCapReached(ctx) {
+		s.CostCapped = true
+		switch b.config.CostCapFallback {
+		case CostCapFallbackHeuristics:
+			s.Heuristics = "Not classified, the daily cost cap was reached"
+		case CostCapFallbackNotify:
+			if s.newUser || hasLinksOrMedia(message, b.config.IgnoreCodeBlocks) {
+				s.Verdict = &verdict{Action: ActionNotify, Label: "Unclassified message (cost cap)", Threshold: s.threshold}
+			} else {
+				s.Skip = "the daily cost cap was reached"
+			}
+		default:
+			s.Skip = "the daily cost cap was reached"
+		}
+		return s, nil
+	}
+	// Users whose first message was clean are only checked by heuristics from then on
+	if b.config.FirstMessageOnly && s.count > 0 {
+		s.Heuristics = "Not classified, the user's first message was clean"
+		s.CountClean = true
+		return s, nil
+	}
+	return s, nil
+}
+
+// heuristicVerdict decides on a message from the heuristic signals alone, without calling the classifier
+func (b *Bot) heuristicVerdict(ctx context.Context, message *tgbotapi.Message, senderID int64, text string, s screening) (*ai.Result, verdict) {
+	processed := &ai.Result{Reasoning: s.Heuristics}
+	b.adjustScore(message, senderID, text, s.newUser, false, processed)
+	return processed, b.decide(processed, s.threshold, b.uncertaintyBand(ctx, message.Chat.ID), s.raid)
+}
+
+// judge applies the heuristic signals and weights to a classified message and decides on it
+func (b *Bot) judge(ctx context.Context, message *tgbotapi.Message, senderID int64, text string, s screening, processed *ai.Result) verdict {
+	b.adjustScore(message, senderID, text, s.newUser, s.replyToAdmin, processed)
+	if s.threadRegular {
+		b.discountThreadReply(processed)
+	}
+	return b.decide(processed, s.threshold, b.uncertaintyBand(ctx, message.Chat.ID), s.raid)
+}
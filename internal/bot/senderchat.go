@@ -0,0 +1,21 @@
+package bot
+
+import "fmt"
+
+// SenderChatPolicy controls messages posted on behalf of a chat (sender_chat),
+// such as posts from a linked channel or by anonymous admins
+type SenderChatPolicy string
+
+const (
+	SenderChatPolicyIgnore SenderChatPolicy = "ignore"             // Skip these messages entirely
+	SenderChatPolicyScan   SenderChatPolicy = "scan-but-never-ban" // Classify and delete spam, but never restrict
+)
+
+func ParseSenderChatPolicy(value string) (SenderChatPolicy, error) {
+	switch policy := SenderChatPolicy(value); policy {
+	case SenderChatPolicyIgnore, SenderChatPolicyScan:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown sender chat policy: %s", value)
+	}
+}
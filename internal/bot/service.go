@@ -0,0 +1,29 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isServiceMessage reports whether a message is a join, leave or pin notification
+func isServiceMessage(message *tgbotapi.Message) bool {
+	return len(message.NewChatMembers) > 0 || message.LeftChatMember != nil || message.PinnedMessage != nil
+}
+
+// cleanServiceMessage deletes join and leave notifications, and pin notifications if enabled
+func (b *Bot) cleanServiceMessage(message *tgbotapi.Message, adminRights AdminRights) {
+	chatID := message.Chat.ID
+	if !b.config.CleanServiceMessages || !adminRights.CanDeleteMessages {
+		return
+	}
+	if message.PinnedMessage != nil && !b.config.CleanPinnedMessages {
+		return
+	}
+	if len(b.whitelistChannels) > 0 && !b.whitelistChannels[chatID] {
+		return
+	}
+	if err := b.messenger.Delete(chatID, message.MessageID); err != nil {
+		b.logger.Error("Failed to delete service message", "error", err, "messageID", message.MessageID, "chatID", chatID)
+		return
+	}
+	b.logger.Debug("Deleted service message", "messageID", message.MessageID, "chatID", chatID)
+}
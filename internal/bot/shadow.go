@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+)
+
+// shadowConcurrency bounds the shadow classifications in flight, so a slow
+// candidate provider can't pile up goroutines during floods
+const shadowConcurrency = 8
+
+// shadowStats tracks how often the shadow provider agrees with the primary one
+type shadowStats struct {
+	total    atomic.Int64
+	agreed   atomic.Int64
+	failures atomic.Int64
+	dropped  atomic.Int64
+}
+
+// startShadowClassify runs shadowClassify in the background, skipping the
+// message if shadowConcurrency classifications are still in flight
+func (b *Bot) startShadowClassify(chatID int64, messageID int, prompt string, primary ai.Result, threshold float64) {
+	if b.shadowProvider == nil {
+		return
+	}
+	select {
+	case b.shadowSlots <- struct{}{}:
+	default:
+		b.shadowStats.dropped.Add(1)
+		metrics.ShadowClassifications.WithLabelValues("dropped").Inc()
+		b.logger.Debug("Skipped shadow classification, too many in flight", "chatID", chatID, "messageID", messageID)
+		return
+	}
+	b.goSafe(func() {
+		defer func() { <-b.shadowSlots }()
+		b.shadowClassify(chatID, messageID, prompt, primary, threshold)
+	})
+}
+
+// shadowClassify runs the candidate provider on the same prompt and records
+// whether it agrees with the primary result. It never influences the decision.
+func (b *Bot) shadowClassify(chatID int64, messageID int, prompt string, primary ai.Result, threshold float64) {
+	if b.shadowProvider == nil {
+		return
+	}
+
+	response, err := b.shadowProvider.ProcessMessage(context.Background(), prompt)
+	if err == nil {
+		var shadow ai.Result
+		shadow, err = ai.ParseResponse(response)
+		if err == nil {
+			b.recordShadowResult(chatID, messageID, &primary, &shadow, threshold)
+			return
+		}
+	}
+	b.shadowStats.failures.Add(1)
+	metrics.ShadowClassifications.WithLabelValues("failed").Inc()
+	b.logger.Warn("Shadow classification failed", "error", err, "chatID", chatID, "messageID", messageID)
+}
+
+func (b *Bot) recordShadowResult(chatID int64, messageID int, primary, shadow *ai.Result, threshold float64) {
+	primarySpam := primary.SpamScore > threshold
+	shadowSpam := shadow.SpamScore > threshold
+
+	total := b.shadowStats.total.Add(1)
+	agreed := b.shadowStats.agreed.Load()
+	if primarySpam == shadowSpam {
+		agreed = b.shadowStats.agreed.Add(1)
+		metrics.ShadowClassifications.WithLabelValues("agreed").Inc()
+	} else {
+		metrics.ShadowClassifications.WithLabelValues("disagreed").Inc()
+		b.logger.Info("Shadow classification disagrees",
+			"chatID", chatID,
+			"messageID", messageID,
+			"primaryScore", primary.SpamScore,
+			"shadowScore", shadow.SpamScore,
+			"shadowReasoning", shadow.Reasoning)
+	}
+
+	b.logger.Debug("Shadow classification result",
+		"chatID", chatID,
+		"messageID", messageID,
+		"primaryScore", primary.SpamScore,
+		"shadowScore", shadow.SpamScore,
+		"agreementRate", float64(agreed)/float64(total),
+		"total", total)
+}
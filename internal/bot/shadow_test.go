@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+)
+
+// blockingProvider holds every call until release is closed
+type blockingProvider struct {
+	started chan struct{} // Receives a value when a call starts
+	release chan struct{}
+}
+
+func (p *blockingProvider) ProcessMessage(ctx context.Context, message string) (string, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return `{"reasoning": "promo", "spam_score": 0.9}`, nil
+}
+
+// shadowResults returns the count of shadow classifications with the result
+func shadowResults(t *testing.T, result string) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := metrics.ShadowClassifications.WithLabelValues(result).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestShadowClassifyRecordsAgreement(t *testing.T) {
+	config := testConfig()
+	config.ShadowProvider = &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`}
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+	agreed := shadowResults(t, "agreed")
+	disagreed := shadowResults(t, "disagreed")
+
+	b.shadowClassify(testChatID, 10, "prompt", ai.Result{SpamScore: 0.8}, 0.5)
+	b.shadowClassify(testChatID, 11, "prompt", ai.Result{SpamScore: 0.1}, 0.5)
+
+	if total, matched := b.shadowStats.total.Load(), b.shadowStats.agreed.Load(); total != 2 || matched != 1 {
+		t.Errorf("shadow stats = %d agreed of %d, want 1 of 2", matched, total)
+	}
+	if got := shadowResults(t, "agreed") - agreed; got != 1 {
+		t.Errorf("agreed metric rose by %v, want 1", got)
+	}
+	if got := shadowResults(t, "disagreed") - disagreed; got != 1 {
+		t.Errorf("disagreed metric rose by %v, want 1", got)
+	}
+}
+
+func TestShadowClassifyDropsWhenBusy(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}, shadowConcurrency+5), release: make(chan struct{})}
+	config := testConfig()
+	config.ShadowProvider = provider
+	b, _, _ := newTestBot(t, config, &countingProvider{})
+
+	for i := 0; i < shadowConcurrency+5; i++ {
+		b.startShadowClassify(testChatID, i, "prompt", ai.Result{SpamScore: 0.9}, 0.5)
+	}
+	for i := 0; i < shadowConcurrency; i++ {
+		<-provider.started
+	}
+
+	if dropped := b.shadowStats.dropped.Load(); dropped != 5 {
+		t.Errorf("dropped %d shadow classifications, want the 5 beyond the limit", dropped)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-provider.started // The freed slot takes the next message
+	}()
+	close(provider.release)
+	waitFor(t, "the shadow classifications to finish", func() bool { return len(b.shadowSlots) == 0 })
+	b.startShadowClassify(testChatID, 100, "prompt", ai.Result{SpamScore: 0.9}, 0.5)
+	wg.Wait()
+}
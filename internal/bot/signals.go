@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"unicode"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// minDecorativeRunes keeps a short reaction like "🔥🔥" from counting as an emoji flood
+const minDecorativeRunes = 5
+
+// applySignals adjusts the classifier's spam score with heuristic signals about the sender and message
+func (b *Bot) applySignals(message *tgbotapi.Message, senderID int64, text string, newUser bool, processed *ai.Result) {
+	if b.config.NewAccountBoost > 0 && b.isNewAccount(senderID) {
+		b.boostScore(processed, b.config.NewAccountBoost, "new_account")
+	}
+	if b.config.EmojiRatio > 0 && newUser && decorativeRatio(text) >= b.config.EmojiRatio {
+		b.boostScore(processed, b.config.EmojiRatioBoost, "emoji_ratio")
+	}
+	if b.config.CustomEmojiRatio > 0 && newUser && customEmojiRatio(message) >= b.config.CustomEmojiRatio {
+		b.boostScore(processed, b.config.CustomEmojiBoost, "custom_emoji_ratio")
+	}
+	if b.config.LanguageBoost > 0 && newUser && offLanguage(text, b.allowedLanguages(context.Background(), message.Chat.ID)) {
+		b.boostScore(processed, b.config.LanguageBoost, "off_language")
+	}
+	if b.config.RepostBoost > 0 && newUser && b.isRepost(context.Background(), message.Chat.ID, message.MessageID) {
+		b.boostScore(processed, b.config.RepostBoost, "repost")
+	}
+	if b.config.BareLinkBoost > 0 && newUser && isBareLink(message) {
+		b.boostScore(processed, b.config.BareLinkBoost, "bare_link")
+	}
+	if b.config.BioBoost > 0 && newUser && b.suspiciousProfile(context.Background(), message.Chat.ID, senderID) {
+		b.boostScore(processed, b.config.BioBoost, "suspicious_profile")
+	}
+	if newUser {
+		if boost := b.contactBoost(context.Background(), message.Chat.ID, text); boost > 0 {
+			b.boostScore(processed, boost, "contacts")
+		}
+	}
+	if b.config.PhoneBoost+b.config.WalletBoost+b.config.CardBoost > 0 && newUser {
+		if boost := b.paymentBoost(paymentSignals(text)); boost > 0 {
+			b.boostScore(processed, boost, "payment_details")
+		}
+	}
+}
+
+// decorativeRatio returns the share of emoji and other symbols among the
+// visible characters of text, or zero if it has only a few of them
+func decorativeRatio(text string) float64 {
+	decorative, letters := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			letters++
+		case isDecorative(r):
+			decorative++
+		}
+	}
+	if decorative < minDecorativeRunes {
+		return 0
+	}
+	return float64(decorative) / float64(decorative+letters)
+}
+
+// isDecorative reports whether r is an emoji or a symbol used to decorate text.
+// Joiners and variation selectors are parts of emoji rather than characters of their own.
+func isDecorative(r rune) bool {
+	if r == '\u200d' || unicode.Is(unicode.Variation_Selector, r) {
+		return false
+	}
+	return unicode.In(r, unicode.So, unicode.Sk, unicode.Co) ||
+		(r >= 0x1F1E6 && r <= 0x1F1FF) // Regional indicators forming flags
+}
+
+func (b *Bot) boostScore(processed *ai.Result, boost float64, signal string) {
+	score := math.Min(1, processed.SpamScore+boost)
+	b.logger.Debug("Boosted spam score", "signal", signal, "from", processed.SpamScore, "to", score)
+	processed.SpamScore = score
+}
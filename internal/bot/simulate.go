@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// spamLabel names detections by the spam score rather than a label rule
+const spamLabel = "Spam"
+
+// verdict is the decision reached for a classified message
+type verdict struct {
+	Action    Action // Empty if the message is clean
+	Label     string
+	Score     float64
+	Threshold float64
+}
+
+// decide turns a classification into the action the bot applies. Scores within
+// band of the threshold are only reported for review, unless a label rule matches.
+// During a raid label detections are banned regardless of their rule's action and
+// there is no band.
+func (b *Bot) decide(processed *ai.Result, threshold, band float64, raid bool) verdict {
+	inBand := !raid && uncertain(processed.SpamScore, threshold, band)
+	if processed.SpamScore > threshold && !inBand {
+		return verdict{Action: ActionBan, Label: spamLabel, Score: processed.SpamScore, Threshold: threshold}
+	}
+	if rule, score, ok := b.matchLabel(processed); ok {
+		action := rule.Action
+		if raid {
+			action = ActionBan
+		}
+		return verdict{Action: action, Label: rule.Name, Score: score, Threshold: rule.Threshold}
+	}
+	if inBand {
+		return verdict{Action: ActionNotify, Label: reviewLabel, Score: processed.SpamScore, Threshold: threshold}
+	}
+	return verdict{Score: processed.SpamScore, Threshold: threshold}
+}
+
+// adjustScore applies heuristic signals and the reply-to-admin weight to the classifier's score
+func (b *Bot) adjustScore(message *tgbotapi.Message, senderID int64, text string, newUser, replyToAdmin bool, processed *ai.Result) {
+	b.applySignals(message, senderID, b.signalText(message, text), newUser, processed)
+	if replyToAdmin && b.config.ReplyPolicy == ReplyPolicyDiscountAdmin {
+		b.logger.Debug("Weighted down reply to admin", "from", processed.SpamScore, "to", processed.SpamScore*b.config.ReplyToAdminWeight)
+		processed.SpamScore *= b.config.ReplyToAdminWeight
+	}
+}
+
+// signalText returns the text heuristic signals look at, without code if IgnoreCodeBlocks is set
+func (b *Bot) signalText(message *tgbotapi.Message, text string) string {
+	if b.config.IgnoreCodeBlocks {
+		return textWithoutCode(message)
+	}
+	return text
+}
+
+// effectiveEnforcement returns the action and rights enforce works with after
+// applying the chat's action policy, any warm-up and paused moderation
+func (b *Bot) effectiveEnforcement(ctx context.Context, channelID int64, action Action, adminRights AdminRights) (Action, AdminRights) {
+	action = b.actionPolicy(ctx, channelID).limit(action)
+	if b.warmingUp(ctx, channelID) {
+		action = ActionNotify
+	}
+	if b.moderationPaused(channelID) {
+		adminRights = AdminRights{} // Only report until the bot's rights are restored
+	}
+	return action, adminRights
+}
+
+// describeEnforcement lists the steps enforce takes for an action
+func (b *Bot) describeEnforcement(action Action, adminRights AdminRights) string {
+	steps := []string{"report to the log channel"}
+	if action.severity() >= ActionDelete.severity() && adminRights.CanDeleteMessages {
+		steps = append(steps, "delete the message")
+	}
+	if action == ActionMute && adminRights.CanRestrictMembers {
+		steps = append(steps, fmt.Sprintf("mute the user for %s", b.config.MuteDuration))
+	}
+	if action.severity() >= ActionBan.severity() && adminRights.CanRestrictMembers {
+		steps = append(steps, "ban the user")
+	}
+	return strings.Join(steps, ", ")
+}
+
+// handleSimulateCommand runs the decision pipeline on the replied message
+// without acting on it and reports what the bot would do: /simulate
+func (b *Bot) handleSimulateCommand(message *tgbotapi.Message) {
+	target := message.ReplyToMessage
+	if target == nil || (target.From == nil && target.SenderChat == nil) {
+		b.reply(message, "Reply to a message to simulate the bot's decision")
+		return
+	}
+	text := messageText(target)
+	if text == "" {
+		b.reply(message, "The message has no text to classify")
+		return
+	}
+
+	ctx := context.Background()
+	channelID := message.Chat.ID
+	senderID := commandSender(target)
+	adminRights := b.checkAdminRights(channelID, b.api.Self.ID)
+	if target.SenderChat != nil {
+		adminRights.CanRestrictMembers = false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🧪 Simulation, nothing was done\n")
+	if b.ignoredReply(target) {
+		sb.WriteString("Would: skip, replies are ignored")
+		b.reply(message, sb.String())
+		return
+	}
+
+	s, err := b.screen(ctx, target, text, senderID, true)
+	if err != nil {
+		b.logger.Error("Failed to screen message", "error", err, "chatID", channelID)
+		fmt.Fprintf(&sb, "Screening failed: %v", err)
+		b.reply(message, sb.String())
+		return
+	}
+	if s.countKey != "" {
+		fmt.Fprintf(&sb, "Clean messages: %d (new user: %t)\n", s.count, s.newUser)
+	}
+	if s.Skip != "" {
+		fmt.Fprintf(&sb, "Would: skip, %s", s.Skip)
+		b.reply(message, sb.String())
+		return
+	}
+	if s.Verdict != nil {
+		label := s.Verdict.Label
+		if s.Detail != "" {
+			label += " (" + s.Detail + ")"
+		}
+		action, rights := b.effectiveEnforcement(ctx, channelID, s.Verdict.Action, adminRights)
+		fmt.Fprintf(&sb, "Verdict: %s, without classification\nWould: %s", label, b.describeEnforcement(action, rights))
+		b.reply(message, sb.String())
+		return
+	}
+
+	band := b.uncertaintyBand(ctx, channelID)
+	if s.raid {
+		sb.WriteString("Raid mode is on\n")
+	}
+	if offset := b.lengthOffset(text); offset != 0 {
+		fmt.Fprintf(&sb, "Length threshold offset: %+.2f\n", offset)
+	}
+	if band > 0 && !s.raid {
+		fmt.Fprintf(&sb, "Uncertainty band: ±%.2f\n", band)
+	}
+
+	var processed *ai.Result
+	var outcome verdict
+	if s.Heuristics != "" {
+		processed, outcome = b.heuristicVerdict(ctx, target, senderID, text, s)
+		fmt.Fprintf(&sb, "%s, heuristic score: %.2f / %.2f\n", s.Heuristics, processed.SpamScore, s.threshold)
+	} else {
+		prompt := ai.RenderPrompt(b.classificationText(target), b.promptFor(ctx, target, senderID))
+		processed, _, err = b.checkForSpamWithRetry(prompt, 3, 100*time.Millisecond)
+		if err != nil {
+			b.logger.Error("Error checking for spam after retries", "error", err)
+			fmt.Fprintf(&sb, "Classification failed: %v\nWould: apply the %s error policy", err, b.config.OnError)
+			b.reply(message, sb.String())
+			return
+		}
+		outcome = b.judge(ctx, target, senderID, text, s, processed)
+		fmt.Fprintf(&sb, "Spam score: %.2f / %.2f\n", processed.SpamScore, s.threshold)
+	}
+
+	switch {
+	case outcome.Action != "":
+		action, rights := b.effectiveEnforcement(ctx, channelID, outcome.Action, adminRights)
+		fmt.Fprintf(&sb, "Verdict: %s (%.2f / %.2f, %s)\nWould: %s", outcome.Label, outcome.Score, outcome.Threshold, action, b.describeEnforcement(action, rights))
+	case s.Heuristics != "" && !s.CountClean:
+		sb.WriteString("Verdict: clean\nWould: let the message through")
+	default:
+		sb.WriteString("Verdict: clean\nWould: count the message as clean")
+	}
+	if s.Heuristics == "" {
+		fmt.Fprintf(&sb, "\nReasoning: %s", processed.Reasoning)
+	}
+
+	b.logger.Info("Simulated decision", "chatID", channelID, "userID", senderID, "label", outcome.Label, "action", outcome.Action, "admin", commandSender(message))
+	b.reply(message, sb.String())
+}
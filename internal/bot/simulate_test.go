@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// simulate runs /simulate as a reply to target and returns the bot's reply
+func simulate(t *testing.T, b *Bot, messenger *fakeMessenger, target *tgbotapi.Message) string {
+	t.Helper()
+	before := len(messenger.sentTexts())
+	b.handleSimulateCommand(&tgbotapi.Message{
+		MessageID:      target.MessageID + 1000,
+		From:           &tgbotapi.User{ID: 7, FirstName: "Admin"},
+		Chat:           target.Chat,
+		Text:           "/simulate",
+		ReplyToMessage: target,
+	})
+	texts := messenger.sentTexts()
+	if len(texts) != before+1 {
+		t.Fatalf("/simulate sent %d messages, want 1", len(texts)-before)
+	}
+	return texts[before]
+}
+
+func TestSimulateMatchesLiveDecision(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(config *Config)
+		setup     func(b *Bot, server *miniredis.Miniredis)
+		message   func() *tgbotapi.Message
+		response  string
+		want      string // Part of the /simulate reply
+		classify  bool   // Whether the message reaches the classifier
+		banned    bool   // Whether the live message gets its sender banned
+	}{
+		{
+			name:     "classified spam",
+			response: `{"reasoning": "crypto promo", "spam_score": 0.9}`,
+			want:     "Verdict: Spam",
+			classify: true,
+			banned:   true,
+		},
+		{
+			name:     "classified clean",
+			response: `{"reasoning": "greeting", "spam_score": 0.1}`,
+			want:     "Would: count the message as clean",
+			classify: true,
+		},
+		{
+			name: "blocklisted phrase",
+			setup: func(b *Bot, server *miniredis.Miniredis) {
+				server.SAdd(b.blocklistKey(testChatID), "free signals")
+			},
+			want:   "Verdict: Blocklisted phrase (free signals), without classification",
+			banned: true,
+		},
+		{
+			name: "trusted user",
+			setup: func(b *Bot, server *miniredis.Miniredis) {
+				server.SAdd(b.trustedKey(testChatID), "42")
+			},
+			want: "Would: skip, the user is trusted",
+		},
+		{
+			name:      "stale message",
+			configure: func(config *Config) { config.MaxMessageAge = time.Minute },
+			message: func() *tgbotapi.Message {
+				message := textMessage(10, "join for free signals")
+				message.Date = int(time.Now().Add(-time.Hour).Unix())
+				return message
+			},
+			want: "Would: skip, the message is",
+		},
+		{
+			name: "cost cap reached",
+			configure: func(config *Config) {
+				config.DailyCostCap = 1
+			},
+			setup: func(b *Bot, server *miniredis.Miniredis) {
+				server.Set(b.costKey(time.Now()), "2")
+			},
+			want: "Would: skip, the daily cost cap was reached",
+		},
+		{
+			name: "first message only",
+			configure: func(config *Config) {
+				config.FirstMessageOnly = true
+				config.NewUserThreshold = 3
+			},
+			setup: func(b *Bot, server *miniredis.Miniredis) {
+				server.Set(b.key("%d:%d", testUserID, testChatID), "1")
+			},
+			want: "Not classified, the user's first message was clean, heuristic score: 0.00",
+		},
+		{
+			name:      "established user beyond the scan window",
+			configure: func(config *Config) { config.ScanWindow = 2 },
+			setup: func(b *Bot, server *miniredis.Miniredis) {
+				server.Set(b.key("%d:%d", testUserID, testChatID), "5")
+			},
+			want: "Would: skip, the user is beyond the scan window",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			if tt.configure != nil {
+				tt.configure(config)
+			}
+			provider := &countingProvider{response: tt.response}
+			b, messenger, server := newTestBot(t, config, provider)
+			if tt.setup != nil {
+				tt.setup(b, server)
+			}
+			message := textMessage(10, "join for free signals")
+			if tt.message != nil {
+				message = tt.message()
+			}
+
+			reply := simulate(t, b, messenger, message)
+			if !strings.Contains(reply, tt.want) {
+				t.Errorf("/simulate replied %q, want it to contain %q", reply, tt.want)
+			}
+			if simulated := provider.callCount(); (simulated > 0) != tt.classify {
+				t.Errorf("/simulate made %d provider calls, want classification %t", simulated, tt.classify)
+			}
+			if len(messenger.deleted)+len(messenger.restricted) != 0 {
+				t.Fatalf("/simulate acted on the message: deleted %v, restricted %v", messenger.deleted, messenger.restricted)
+			}
+
+			calls := provider.callCount()
+			handle(b, message)
+			if live := provider.callCount() - calls; (live > 0) != tt.classify {
+				t.Errorf("live handling made %d provider calls, want classification %t", live, tt.classify)
+			}
+			if banned := len(messenger.restricted) > 0; banned != tt.banned {
+				t.Errorf("live handling banned the sender: %t, want %t", banned, tt.banned)
+			}
+		})
+	}
+}
+
+func TestSimulateReportsDetectedRepost(t *testing.T) {
+	config := testConfig()
+	config.RepostWindow = 10 * time.Minute
+	config.RepostAction = ActionMute
+	config.NewUserThreshold = 3
+	provider := &countingProvider{response: `{"reasoning": "fine", "spam_score": 0.1}`}
+	b, messenger, _ := newTestBot(t, config, provider)
+
+	handle(b, textMessage(9, "join my channel for free trading signals"))
+	repost := textMessage(10, "join my channel for free trading signals today")
+	handle(b, repost)
+	if len(messenger.restricted) != 1 {
+		t.Fatalf("restricted = %v, want the repost muted", messenger.restricted)
+	}
+
+	calls := provider.callCount()
+	reply := simulate(t, b, messenger, repost)
+	if !strings.Contains(reply, "Verdict: Repost, without classification") || !strings.Contains(reply, "mute the user") {
+		t.Errorf("/simulate replied %q, want the repost muted without classification", reply)
+	}
+	if provider.callCount() != calls {
+		t.Errorf("/simulate classified a repost")
+	}
+}
+
+func TestSimulateLeavesNoTrace(t *testing.T) {
+	config := testConfig()
+	config.RepostWindow = 10 * time.Minute
+	provider := &countingProvider{response: `{"reasoning": "fine", "spam_score": 0.1}`}
+	b, messenger, server := newTestBot(t, config, provider)
+	message := textMessage(10, "hello everyone")
+
+	simulate(t, b, messenger, message)
+
+	ctx := context.Background()
+	if count, _ := b.redis.Get(ctx, b.key("%d:%d", testUserID, testChatID)).Int(); count != 0 {
+		t.Errorf("message count = %d after /simulate, want 0", count)
+	}
+	if server.Exists(b.postedTextsKey(testChatID, testUserID)) {
+		t.Error("/simulate remembered the message for repost detection")
+	}
+}
@@ -0,0 +1,32 @@
+package bot
+
+import (
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// deletableWindow is how long after posting bots can delete messages in groups
+const deletableWindow = 48 * time.Hour
+
+// tooOldToDelete reports whether a message is past the window in which it can be deleted,
+// with a minute of margin for the time it takes to act
+func tooOldToDelete(message *tgbotapi.Message) bool {
+	return time.Since(message.Time()) >= deletableWindow-time.Minute
+}
+
+// isUndeletable reports whether Telegram refused a delete because of the message, e.g. its age,
+// so retrying won't help
+func isUndeletable(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message can't be deleted")
+}
+
+// staleFallback returns the action for a flagged message too old to delete: the
+// configured StaleAction if stricter than the original action, which is kept otherwise
+func (b *Bot) staleFallback(action Action) Action {
+	if b.config.StaleAction.severity() > action.severity() {
+		return b.config.StaleAction
+	}
+	return action
+}
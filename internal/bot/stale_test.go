@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStaleMessagesGetStaleAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		stale      Action
+		age        time.Duration
+		deleted    bool
+		restricted bool
+	}{
+		{"recent message", ActionMute, time.Hour, true, false},
+		{"old message, notify", ActionNotify, 49 * time.Hour, false, false},
+		{"old message, mute", ActionMute, 49 * time.Hour, false, true},
+		{"old message, ban", ActionBan, 49 * time.Hour, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.StaleAction = tt.stale
+			b, messenger, _ := newTestBot(t, config, &countingProvider{})
+			message := textMessage(10, "buy followers now")
+			message.Date = int(time.Now().Add(-tt.age).Unix())
+			// Known spam is only deleted, so any restriction comes from the stale action
+			if err := b.addSpamMessage(context.Background(), testChatID, b.hashMessage(message.Text)); err != nil {
+				t.Fatal(err)
+			}
+
+			handle(b, message)
+
+			if deleted := slices.Contains(messenger.deleted, 10); deleted != tt.deleted {
+				t.Errorf("deleted = %v, want deleted %t", messenger.deleted, tt.deleted)
+			}
+			if restricted := len(messenger.restricted) > 0; restricted != tt.restricted {
+				t.Errorf("restricted = %v, want restricted %t", messenger.restricted, tt.restricted)
+			}
+			tooOld := slices.ContainsFunc(messenger.sentTexts(), func(text string) bool { return strings.Contains(text, "too old to delete") })
+			if tooOld == tt.deleted {
+				t.Errorf("log messages = %q, want too old to delete reported %t", messenger.sentTexts(), !tt.deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// telegramMessenger performs the bot's actions through the Telegram Bot API
+type telegramMessenger struct {
+	api *tgbotapi.BotAPI
+}
+
+func (t *telegramMessenger) Send(message OutgoingMessage) (int, error) {
+	msg := tgbotapi.NewMessage(message.ChatID, message.Text)
+	msg.ReplyToMessageID = message.ReplyTo
+	if message.Button != nil {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(message.Button.Text, message.Button.Data),
+		))
+	}
+	sent, err := t.api.Send(msg)
+	return sent.MessageID, err
+}
+
+func (t *telegramMessenger) Forward(toChatID, fromChatID int64, messageID int) error {
+	_, err := t.api.Send(tgbotapi.NewForward(toChatID, fromChatID, messageID))
+	return err
+}
+
+func (t *telegramMessenger) Edit(chatID int64, messageID int, text string) error {
+	_, err := t.api.Send(tgbotapi.NewEditMessageText(chatID, messageID, text))
+	return err
+}
+
+func (t *telegramMessenger) Delete(chatID int64, messageID int) error {
+	_, err := t.api.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+	return err
+}
+
+// DeleteMany calls deleteMessages, which the Telegram library doesn't implement yet
+func (t *telegramMessenger) DeleteMany(chatID int64, messageIDs []int) error {
+	params := tgbotapi.Params{}
+	params.AddFirstValid("chat_id", chatID)
+	if err := params.AddInterface("message_ids", messageIDs); err != nil {
+		return err
+	}
+	_, err := t.api.MakeRequest("deleteMessages", params)
+	return err
+}
+
+func (t *telegramMessenger) Restrict(chatID, userID int64, until time.Time) error {
+	var untilDate int64
+	if !until.IsZero() {
+		untilDate = until.Unix()
+	}
+	_, err := t.api.Request(tgbotapi.RestrictChatMemberConfig{
+		ChatMemberConfig: tgbotapi.ChatMemberConfig{
+			ChatID: chatID,
+			UserID: userID,
+		},
+		UntilDate: untilDate,
+	})
+	return err
+}
+
+func (t *telegramMessenger) Unrestrict(chatID, userID int64) error {
+	_, err := t.api.Request(tgbotapi.RestrictChatMemberConfig{
+		ChatMemberConfig: tgbotapi.ChatMemberConfig{
+			ChatID: chatID,
+			UserID: userID,
+		},
+		Permissions: &tgbotapi.ChatPermissions{
+			CanSendMessages:       true,
+			CanSendMediaMessages:  true,
+			CanSendPolls:          true,
+			CanSendOtherMessages:  true,
+			CanAddWebPagePreviews: true,
+			CanInviteUsers:        true,
+		},
+	})
+	return err
+}
+
+func (t *telegramMessenger) DeclineJoinRequest(chatID, userID int64) error {
+	_, err := t.api.Request(tgbotapi.DeclineChatJoinRequest{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+		UserID:     userID,
+	})
+	return err
+}
+
+func (t *telegramMessenger) AnswerCallback(callbackID, text string) error {
+	_, err := t.api.Request(tgbotapi.NewCallback(callbackID, text))
+	return err
+}
+
+func (t *telegramMessenger) Member(chatID, userID int64) (ChatMember, error) {
+	member, err := t.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
+			ChatID: chatID,
+			UserID: userID,
+		},
+	})
+	if err != nil {
+		return ChatMember{}, err
+	}
+	return ChatMember{
+		IsAdmin:            member.IsCreator() || member.IsAdministrator(),
+		CanDeleteMessages:  member.CanDeleteMessages,
+		CanRestrictMembers: member.CanRestrictMembers,
+	}, nil
+}
+
+func (t *telegramMessenger) Administrators(chatID int64) ([]Administrator, error) {
+	members, err := t.api.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	admins := make([]Administrator, 0, len(members))
+	for _, member := range members {
+		admins = append(admins, Administrator{
+			ID:       member.User.ID,
+			Name:     strings.TrimSpace(member.User.FirstName + " " + member.User.LastName),
+			Username: member.User.UserName,
+		})
+	}
+	return admins, nil
+}
+
+func (t *telegramMessenger) Bio(userID int64) (string, error) {
+	chat, err := t.api.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: userID}})
+	if err != nil {
+		return "", err
+	}
+	return chat.Bio, nil
+}
+
+func (t *telegramMessenger) SetAdminCommands(commands []Command) error {
+	botCommands := make([]tgbotapi.BotCommand, 0, len(commands))
+	for _, command := range commands {
+		botCommands = append(botCommands, tgbotapi.BotCommand{Command: command.Name, Description: command.Description})
+	}
+	_, err := t.api.Request(tgbotapi.NewSetMyCommandsWithScope(tgbotapi.NewBotCommandScopeAllChatAdministrators(), botCommands...))
+	return err
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+func (b *Bot) joinedKey(chatID, userID int64) string {
+	return b.key("joined:%d:%d", chatID, userID)
+}
+
+// memberJoined reports whether a chat_member update is a user joining the chat
+func memberJoined(update *tgbotapi.ChatMemberUpdated) bool {
+	return (update.OldChatMember.HasLeft() || update.OldChatMember.WasKicked()) &&
+		!update.NewChatMember.HasLeft() && !update.NewChatMember.WasKicked()
+}
+
+// memberLeft reports whether a chat_member update is a user leaving or being removed from the chat
+func memberLeft(update *tgbotapi.ChatMemberUpdated) bool {
+	return !update.OldChatMember.HasLeft() && !update.OldChatMember.WasKicked() &&
+		(update.NewChatMember.HasLeft() || update.NewChatMember.WasKicked())
+}
+
+// recordJoin remembers when a user joined a chat; the first join seen is kept
+func (b *Bot) recordJoin(ctx context.Context, chatID, userID int64, joined time.Time) {
+	if b.config.TenureGrace <= 0 {
+		return
+	}
+	if err := b.redis.SetNX(ctx, b.joinedKey(chatID, userID), joined.Unix(), 0).Err(); err != nil {
+		b.logger.Error("Failed to record join date", "error", err, "chatID", chatID, "userID", userID)
+	}
+}
+
+// trackTenure records joins and forgets the join date of members who leave, so rejoining starts over
+func (b *Bot) trackTenure(update *tgbotapi.ChatMemberUpdated) {
+	if b.config.TenureGrace <= 0 || update.NewChatMember.User == nil {
+		return
+	}
+	ctx := context.Background()
+	userID := update.NewChatMember.User.ID
+	switch {
+	case memberJoined(update):
+		b.recordJoin(ctx, update.Chat.ID, userID, time.Unix(int64(update.Date), 0))
+	case memberLeft(update):
+		if err := b.redis.Del(ctx, b.joinedKey(update.Chat.ID, userID)).Err(); err != nil {
+			b.logger.Error("Failed to forget join date", "error", err, "chatID", update.Chat.ID, "userID", userID)
+		}
+	}
+}
+
+// withTenure treats members who joined longer than TenureGrace ago as established, whatever their message count
+func (b *Bot) withTenure(ctx context.Context, chatID, userID int64, count int) int {
+	if b.config.TenureGrace <= 0 {
+		return count
+	}
+	newUserThreshold := b.newUserThreshold(ctx, chatID)
+	if count >= newUserThreshold {
+		return count
+	}
+	joined, err := b.redis.Get(ctx, b.joinedKey(chatID, userID)).Int64()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get join date", "error", err, "chatID", chatID, "userID", userID)
+		}
+		return count
+	}
+	if tenure := time.Since(time.Unix(joined, 0)); tenure >= b.config.TenureGrace {
+		b.logger.Debug("Treating long-standing member as established", "chatID", chatID, "userID", userID, "tenure", tenure.Round(time.Hour), "count", count)
+		return newUserThreshold
+	}
+	return count
+}
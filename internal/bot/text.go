@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// messageText returns the text of a message used for classification
+func messageText(message *tgbotapi.Message) string {
+	if message.Poll != nil {
+		return pollText(message.Poll)
+	}
+	if message.Text == "" {
+		return message.Caption
+	}
+	return message.Text
+}
+
+// hasLinksOrMedia reports whether a message contains links, mentions, media or is forwarded.
+// Links and mentions inside code are skipped if ignoreCode is set.
+func hasLinksOrMedia(message *tgbotapi.Message, ignoreCode bool) bool {
+	if message.ForwardDate != 0 || message.Photo != nil || message.Video != nil || message.Document != nil ||
+		message.Animation != nil || message.Audio != nil || message.Voice != nil || message.VideoNote != nil || message.Sticker != nil {
+		return true
+	}
+	for _, entities := range [][]tgbotapi.MessageEntity{message.Entities, message.CaptionEntities} {
+		for _, entity := range entities {
+			if ignoreCode && insideCode(entity, entities) {
+				continue
+			}
+			switch entity.Type {
+			case "url", "text_link", "mention", "text_mention":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pollText flattens a poll's question and options into a single text
+func pollText(poll *tgbotapi.Poll) string {
+	var sb strings.Builder
+	sb.WriteString("Poll: ")
+	sb.WriteString(poll.Question)
+	for _, option := range poll.Options {
+		sb.WriteString("\n- ")
+		sb.WriteString(option.Text)
+	}
+	return sb.String()
+}
+
+// hasUsableText reports whether a poll contains any words to classify
+func hasUsableText(poll *tgbotapi.Poll) bool {
+	texts := []string{poll.Question}
+	for _, option := range poll.Options {
+		texts = append(texts, option.Text)
+	}
+	for _, text := range texts {
+		for _, r := range text {
+			if unicode.IsLetter(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// truncate cuts text to at most limit bytes without splitting a character
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit] + "…"
+}
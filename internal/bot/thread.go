@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/ai"
+)
+
+// threadTTL bounds how long reply threads and their participants' clean messages are remembered
+const threadTTL = 7 * 24 * time.Hour
+
+func (b *Bot) threadKey(chatID int64, messageID int) string {
+	return b.key("thread:%d:%d", chatID, messageID)
+}
+
+func (b *Bot) threadCleanKey(chatID int64, threadID int, userID int64) string {
+	return b.key("thread_clean:%d:%d:%d", chatID, threadID, userID)
+}
+
+// threadOf returns the thread a reply belongs to, identified by the first message of
+// its reply chain, which for forum topics is the topic's first message. Messages that
+// aren't replies belong to no thread and 0 is returned.
+func (b *Bot) threadOf(ctx context.Context, message *tgbotapi.Message) int {
+	if b.config.ThreadCleanMessages <= 0 || message.ReplyToMessage == nil {
+		return 0
+	}
+	chatID, parentID := message.Chat.ID, message.ReplyToMessage.MessageID
+	threadID, err := b.redis.Get(ctx, b.threadKey(chatID, parentID)).Int()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get reply thread", "error", err, "chatID", chatID, "messageID", parentID)
+		}
+		threadID = parentID // The parent starts the thread
+	}
+	if err := b.redis.Set(ctx, b.threadKey(chatID, message.MessageID), threadID, threadTTL).Err(); err != nil {
+		b.logger.Error("Failed to remember reply thread", "error", err, "chatID", chatID, "messageID", message.MessageID)
+	}
+	return threadID
+}
+
+// threadRegular reports whether a user already posted ThreadCleanMessages clean replies in a thread
+func (b *Bot) threadRegular(ctx context.Context, chatID int64, threadID int, userID int64) bool {
+	if threadID == 0 {
+		return false
+	}
+	clean, err := b.redis.Get(ctx, b.threadCleanKey(chatID, threadID, userID)).Int()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get clean thread replies", "error", err, "chatID", chatID, "threadID", threadID, "userID", userID)
+		}
+		return false
+	}
+	return clean >= b.config.ThreadCleanMessages
+}
+
+// countThreadClean records a clean reply of a user in a thread
+func (b *Bot) countThreadClean(ctx context.Context, chatID int64, threadID int, userID int64) {
+	if threadID == 0 {
+		return
+	}
+	key := b.threadCleanKey(chatID, threadID, userID)
+	pipe := b.redis.TxPipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, threadTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to count clean thread reply", "error", err, "chatID", chatID, "threadID", threadID, "userID", userID)
+	}
+}
+
+// discountThreadReply weights down the score of a reply from a regular participant of its thread
+func (b *Bot) discountThreadReply(processed *ai.Result) {
+	b.logger.Debug("Weighted down reply from thread regular", "from", processed.SpamScore, "to", processed.SpamScore*b.config.ThreadWeight)
+	processed.SpamScore *= b.config.ThreadWeight
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	minChatThreshold     = 0.05
+	maxChatThreshold     = 0.95
+	thresholdHistorySize = 20 // Previous per-chat thresholds kept for /threshold undo
+	globalThreshold      = "global"
+)
+
+func (b *Bot) thresholdKey(chatID int64) string {
+	return b.key("threshold:%d", chatID)
+}
+
+func (b *Bot) thresholdHistoryKey(chatID int64) string {
+	return b.key("threshold_history:%d", chatID)
+}
+
+// threshold returns the spam threshold for a chat, which may be overridden per chat
+func (b *Bot) threshold(ctx context.Context, chatID int64) float64 {
+	threshold, err := b.redis.Get(ctx, b.thresholdKey(chatID)).Float64()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to get chat threshold", "error", err, "chatID", chatID)
+		}
+		return b.config.Threshold
+	}
+	return threshold
+}
+
+// setThreshold overrides the chat threshold, remembering the previous one so it can be undone
+func (b *Bot) setThreshold(ctx context.Context, chatID int64, threshold float64) error {
+	previous, err := b.redis.Get(ctx, b.thresholdKey(chatID)).Result()
+	if err == redis.Nil {
+		previous = globalThreshold
+	} else if err != nil {
+		return err
+	}
+
+	pipe := b.redis.TxPipeline()
+	pipe.LPush(ctx, b.thresholdHistoryKey(chatID), previous)
+	pipe.LTrim(ctx, b.thresholdHistoryKey(chatID), 0, thresholdHistorySize-1)
+	pipe.Set(ctx, b.thresholdKey(chatID), threshold, 0)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// undoThreshold restores the chat threshold that was in effect before the last change
+func (b *Bot) undoThreshold(ctx context.Context, chatID int64) (float64, error) {
+	previous, err := b.redis.LPop(ctx, b.thresholdHistoryKey(chatID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if previous == globalThreshold {
+		return b.config.Threshold, b.redis.Del(ctx, b.thresholdKey(chatID)).Err()
+	}
+
+	threshold, err := strconv.ParseFloat(previous, 64)
+	if err != nil {
+		return 0, err
+	}
+	return threshold, b.redis.Set(ctx, b.thresholdKey(chatID), threshold, 0).Err()
+}
+
+// handleThresholdCommand shows, sets or undoes the chat threshold: /threshold [value|undo]
+func (b *Bot) handleThresholdCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID := message.Chat.ID
+	arg := strings.TrimSpace(message.CommandArguments())
+
+	switch arg {
+	case "":
+		b.reply(message, b.t(chatID, "threshold.show", b.threshold(ctx, chatID), b.config.Threshold))
+	case "undo":
+		threshold, err := b.undoThreshold(ctx, chatID)
+		if err == redis.Nil {
+			b.reply(message, b.t(chatID, "threshold.no_undo"))
+			return
+		} else if err != nil {
+			b.logger.Error("Failed to undo chat threshold", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "threshold.undo_failed"))
+			return
+		}
+		b.logger.Info("Restored chat threshold", "chatID", chatID, "threshold", threshold)
+		b.reply(message, b.t(chatID, "threshold.restored", threshold))
+	default:
+		threshold, err := strconv.ParseFloat(arg, 64)
+		if err != nil || threshold <= 0 || threshold >= 1 {
+			b.reply(message, b.t(chatID, "threshold.usage"))
+			return
+		}
+		if err := b.setThreshold(ctx, chatID, threshold); err != nil {
+			b.logger.Error("Failed to set chat threshold", "error", err, "chatID", chatID)
+			b.reply(message, b.t(chatID, "threshold.set_failed"))
+			return
+		}
+		b.logger.Info("Set chat threshold", "chatID", chatID, "threshold", threshold, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "threshold.set", threshold))
+	}
+}
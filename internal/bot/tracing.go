@@ -0,0 +1,19 @@
+package bot
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records spans around classification and actions, a no-op unless telemetry export is set up
+var tracer = otel.Tracer("github.com/ailabhub/giraffe-spam-crasher/internal/bot")
+
+// endSpan marks the span failed if err is set and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (b *Bot) trustedKey(chatID int64) string {
+	return b.key("trusted:%d", chatID)
+}
+
+// isTrusted reports whether an admin vouched for the user in the chat, exempting them from scanning
+func (b *Bot) isTrusted(ctx context.Context, chatID, userID int64) bool {
+	trusted, err := b.redis.SIsMember(ctx, b.trustedKey(chatID), userID).Result()
+	if err != nil {
+		b.logger.Error("Failed to check trusted user", "error", err, "chatID", chatID, "userID", userID)
+		return false
+	}
+	return trusted
+}
+
+// handleTrustCommand trusts or stops trusting the sender of the replied message: /trust, /untrust.
+// Trusting a user also lifts their restrictions.
+func (b *Bot) handleTrustCommand(message *tgbotapi.Message, trust bool) {
+	target := message.ReplyToMessage
+	if target == nil || target.From == nil || target.SenderChat != nil {
+		b.reply(message, b.t(message.Chat.ID, "trust.usage"))
+		return
+	}
+
+	ctx := context.Background()
+	chatID, userID := message.Chat.ID, target.From.ID
+	if !trust {
+		if err := b.redis.SRem(ctx, b.trustedKey(chatID), userID).Err(); err != nil {
+			b.logger.Error("Failed to untrust user", "error", err, "chatID", chatID, "userID", userID)
+			b.reply(message, b.t(chatID, "trust.untrust_failed"))
+			return
+		}
+		b.logger.Info("Untrusted user", "chatID", chatID, "userID", userID, "admin", commandSender(message))
+		b.reply(message, b.t(chatID, "trust.untrusted"))
+		return
+	}
+
+	if err := b.redis.SAdd(ctx, b.trustedKey(chatID), userID).Err(); err != nil {
+		b.logger.Error("Failed to trust user", "error", err, "chatID", chatID, "userID", userID)
+		b.reply(message, b.t(chatID, "trust.trust_failed"))
+		return
+	}
+	b.logger.Info("Trusted user", "chatID", chatID, "userID", userID, "admin", commandSender(message))
+
+	reply := b.t(chatID, "trust.trusted")
+	if b.checkAdminRights(chatID, b.api.Self.ID).CanRestrictMembers {
+		if err := b.messenger.Unrestrict(chatID, userID); err != nil {
+			b.logger.Error("Failed to lift restrictions of trusted user", "error", err, "chatID", chatID, "userID", userID)
+			reply += b.t(chatID, "trust.lift_failed", err)
+		} else {
+			reply += b.t(chatID, "trust.lifted")
+		}
+	}
+	b.reply(message, reply)
+}
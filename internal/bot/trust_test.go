@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestTrustWhitelistsAndLiftsRestrictions(t *testing.T) {
+	provider := &countingProvider{response: `{"reasoning": "promo", "spam_score": 0.9}`}
+	b, messenger, _ := newTestBot(t, testConfig(), provider)
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	ctx := context.Background()
+
+	command := commandMessage(testAdminID, "/trust")
+	command.ReplyToMessage = textMessage(10, "hello")
+	b.handleCommand(command)
+
+	if !b.isTrusted(ctx, testChatID, testUserID) {
+		t.Error("the replied-to user isn't trusted")
+	}
+	if !slices.Equal(messenger.lifted, []int64{testUserID}) {
+		t.Errorf("lifted = %v, want the replied-to user's restrictions lifted", messenger.lifted)
+	}
+	handle(b, textMessage(11, "cheap followers in my profile"))
+	if provider.callCount() != 0 || len(messenger.deleted) != 0 {
+		t.Errorf("classified %d times and deleted %v, trusted users aren't scanned", provider.callCount(), messenger.deleted)
+	}
+
+	command = commandMessage(testAdminID, "/untrust")
+	command.ReplyToMessage = textMessage(10, "hello")
+	b.handleCommand(command)
+
+	if b.isTrusted(ctx, testChatID, testUserID) {
+		t.Error("the user is still trusted after /untrust")
+	}
+	handle(b, textMessage(12, "cheap likes in my profile"))
+	if provider.callCount() != 1 {
+		t.Errorf("classified %d times, want the untrusted user scanned again", provider.callCount())
+	}
+}
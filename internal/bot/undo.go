@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/locale"
+)
+
+// messageReaction is a change of a user's reactions to a message, which the
+// Telegram library doesn't decode yet
+type messageReaction struct {
+	Chat        tgbotapi.Chat   `json:"chat"`
+	MessageID   int             `json:"message_id"`
+	User        *tgbotapi.User  `json:"user,omitempty"`       // Unset for anonymous reactions
+	ActorChat   *tgbotapi.Chat  `json:"actor_chat,omitempty"` // Chat an anonymous reaction was made on behalf of
+	OldReaction []reactionEmoji `json:"old_reaction"`
+	NewReaction []reactionEmoji `json:"new_reaction"`
+}
+
+type reactionEmoji struct {
+	Type  string `json:"type"`
+	Emoji string `json:"emoji,omitempty"`
+}
+
+// added reports whether the reaction newly includes the emoji
+func (r *messageReaction) added(emoji string) bool {
+	has := func(reactions []reactionEmoji) bool {
+		return slices.ContainsFunc(reactions, func(reaction reactionEmoji) bool {
+			return reaction.Type == "emoji" && reaction.Emoji == emoji
+		})
+	}
+	return has(r.NewReaction) && !has(r.OldReaction)
+}
+
+// undoRecord describes an action that admins can undo by reacting to its notification
+type undoRecord struct {
+	ChatID     int64  `json:"chat_id"`
+	UserID     int64  `json:"user_id"`
+	MessageID  int    `json:"message_id"`
+	Name       string `json:"name"`
+	Hash       string `json:"hash"`
+	Restricted bool   `json:"restricted"`
+}
+
+func (b *Bot) undoKey(logChannelID int64, logMessageID int) string {
+	return b.key("undo:%d:%d", logChannelID, logMessageID)
+}
+
+// rememberUndo keeps what a notification reported for UndoWindow, so an admin reaction can undo it
+func (b *Bot) rememberUndo(ctx context.Context, logChannelID int64, logMessageID int, record undoRecord) {
+	if b.config.UndoWindow <= 0 {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		b.logger.Error("Failed to encode undo record", "error", err)
+		return
+	}
+	if err := b.redis.Set(ctx, b.undoKey(logChannelID, logMessageID), data, b.config.UndoWindow).Err(); err != nil {
+		b.logger.Error("Failed to store undo record", "error", err, "logChannelID", logChannelID)
+	}
+}
+
+// reactionAdmin returns the ID of the log chat admin who made a reaction and whether an admin made it.
+// Anonymous admins react on behalf of the log chat itself, which only admins can do.
+func (b *Bot) reactionAdmin(reaction *messageReaction) (int64, bool) {
+	if reaction.User != nil {
+		return reaction.User.ID, b.isChatAdmin(reaction.Chat.ID, reaction.User.ID)
+	}
+	if reaction.ActorChat != nil && reaction.ActorChat.ID == reaction.Chat.ID {
+		return reaction.ActorChat.ID, true
+	}
+	return 0, false
+}
+
+// handleReaction undoes the action reported by a notification when an admin of
+// the log chat reacts to it with UndoReaction: the user's restrictions are lifted,
+// the message hash leaves the spam cache and deleted content kept by
+// AuditKeepContent is posted again
+func (b *Bot) handleReaction(reaction *messageReaction) {
+	if b.config.UndoWindow <= 0 || !reaction.added(b.config.UndoReaction) {
+		return
+	}
+	logChannelID := reaction.Chat.ID
+	if !b.isLogChannel(logChannelID) {
+		return
+	}
+	adminID, ok := b.reactionAdmin(reaction)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	key := b.undoKey(logChannelID, reaction.MessageID)
+	data, err := b.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			b.logger.Error("Failed to load undo record", "error", err, "logChannelID", logChannelID)
+		}
+		return
+	}
+	// Only the first reaction undoes, even if several admins react at once
+	if deleted, err := b.redis.Del(ctx, key).Result(); err != nil || deleted == 0 {
+		return
+	}
+	var record undoRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		b.logger.Error("Failed to decode undo record", "error", err, "logChannelID", logChannelID)
+		return
+	}
+
+	lang := b.chatLocale(ctx, record.ChatID)
+	result := locale.T(lang, "notify.undone")
+	if record.Restricted {
+		if err := b.messenger.Unrestrict(record.ChatID, record.UserID); err != nil {
+			b.logger.Error("Failed to lift restrictions on undo", "error", err, "userID", record.UserID, "chatID", record.ChatID)
+			result += locale.T(lang, "notify.undo_failed")
+		} else {
+			result += locale.T(lang, "notify.undo_lifted")
+		}
+	}
+	if err := b.removeSpamMessage(ctx, record.ChatID, record.Hash); err != nil {
+		b.logger.Error("Failed to remove message from spam cache", "error", err)
+	}
+	b.recordFeedback(ctx, record.ChatID, feedbackFalsePositives)
+
+	content, err := b.redis.Get(ctx, b.contentKey(record.ChatID, record.MessageID)).Result()
+	if err != nil && err != redis.Nil {
+		b.logger.Error("Failed to load deleted message content", "error", err, "chatID", record.ChatID)
+	}
+	if err == nil {
+		restored := locale.T(lang, "notify.restored", record.Name, content)
+		if _, err := b.messenger.Send(OutgoingMessage{ChatID: record.ChatID, Text: truncate(restored, maxNotificationChars)}); err != nil {
+			b.logger.Error("Failed to restore deleted message", "error", err, "chatID", record.ChatID)
+		} else {
+			result += locale.T(lang, "notify.undo_restored")
+		}
+	}
+
+	b.logger.Info("Undid action by admin reaction", "chatID", record.ChatID, "userID", record.UserID, "admin", adminID)
+	if _, err := b.messenger.Send(OutgoingMessage{ChatID: logChannelID, Text: result, ReplyTo: reaction.MessageID}); err != nil {
+		b.logger.Error("Failed to confirm undo", "error", err, "logChannelID", logChannelID)
+	}
+}
+
+// checkUndoLogChats warns about log chats that are channels when undo reactions are enabled.
+// Reactions in channels are anonymous and only delivered as counts, so they can't undo anything.
+func (b *Bot) checkUndoLogChats() {
+	if b.config.UndoWindow <= 0 {
+		return
+	}
+	checked := make(map[int64]bool)
+	logChannels := []int64{b.config.DefaultLogChannel}
+	for _, logChannelID := range b.config.LogChannels {
+		logChannels = append(logChannels, logChannelID)
+	}
+	for _, logChannelID := range logChannels {
+		if logChannelID == 0 || checked[logChannelID] {
+			continue
+		}
+		checked[logChannelID] = true
+		chat, err := b.api.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: logChannelID}})
+		if err != nil {
+			b.logger.Warn("Failed to check log chat type for undo reactions", "error", err, "logChannelID", logChannelID)
+			continue
+		}
+		if chat.IsChannel() {
+			b.logger.Warn("Undo reactions don't work in channel log chats, use a group", "logChannelID", logChannelID)
+		}
+	}
+}
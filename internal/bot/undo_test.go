@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testAdminID = 7
+
+// undoBot returns a bot with undo reactions enabled and a ban notification at log message 300 to undo
+func undoBot(t *testing.T) (*Bot, *fakeMessenger) {
+	t.Helper()
+	config := testConfig()
+	config.UndoWindow = time.Hour
+	config.UndoReaction = "🕊"
+	b, messenger, _ := newTestBot(t, config, &countingProvider{})
+	messenger.admins = []Administrator{{ID: testAdminID, Name: "Admin"}}
+	b.rememberUndo(context.Background(), testLogChatID, 300, undoRecord{
+		ChatID: testChatID, UserID: testUserID, MessageID: 10, Name: "Test", Restricted: true,
+	})
+	return b, messenger
+}
+
+// undoReaction returns the reaction adding 🕊 to log message 300
+func undoReaction(chat tgbotapi.Chat, user *tgbotapi.User, actor *tgbotapi.Chat) *messageReaction {
+	return &messageReaction{
+		Chat:        chat,
+		MessageID:   300,
+		User:        user,
+		ActorChat:   actor,
+		NewReaction: []reactionEmoji{{Type: "emoji", Emoji: "🕊"}},
+	}
+}
+
+func TestUndoReaction(t *testing.T) {
+	logChat := tgbotapi.Chat{ID: testLogChatID, Type: "supergroup"}
+	tests := []struct {
+		name     string
+		reaction *messageReaction
+		undone   bool
+	}{
+		{"admin", undoReaction(logChat, &tgbotapi.User{ID: testAdminID}, nil), true},
+		{"anonymous admin", undoReaction(logChat, nil, &logChat), true},
+		{"member", undoReaction(logChat, &tgbotapi.User{ID: 99}, nil), false},
+		{"another chat", undoReaction(logChat, nil, &tgbotapi.Chat{ID: -3001, Type: "channel"}), false},
+		{"anonymous", undoReaction(logChat, nil, nil), false},
+		{"not a log chat", undoReaction(tgbotapi.Chat{ID: testChatID, Type: "supergroup"}, &tgbotapi.User{ID: testAdminID}, nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, messenger := undoBot(t)
+
+			b.handleReaction(tt.reaction)
+
+			if undone := len(messenger.lifted) == 1; undone != tt.undone {
+				t.Errorf("lifted = %v, want undone %t", messenger.lifted, tt.undone)
+			}
+		})
+	}
+}
+
+func TestUndoReactionOnlyOnce(t *testing.T) {
+	b, messenger := undoBot(t)
+	reaction := undoReaction(tgbotapi.Chat{ID: testLogChatID, Type: "supergroup"}, &tgbotapi.User{ID: testAdminID}, nil)
+
+	b.handleReaction(reaction)
+	b.handleReaction(reaction)
+
+	if len(messenger.lifted) != 1 {
+		t.Errorf("lifted = %v, want the ban lifted once", messenger.lifted)
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"strings"
+	"time"
+)
+
+// messageGoneErrors are fragments of Telegram errors for deleting a message that no longer exists
+var messageGoneErrors = []string{
+	"message to delete not found",
+	"MESSAGE_ID_INVALID",
+}
+
+func isMessageGone(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	for _, fragment := range messageGoneErrors {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyDeletion confirms that a deleted message is gone. Telegram has no way to
+// look a message up, so the delete is repeated after DeleteVerifyDelay: only a
+// "not found" error confirms the message is gone, while a success means it was
+// still there and is deleted again, up to DeleteVerifyAttempts times.
+func (b *Bot) verifyDeletion(chatID int64, messageID int) {
+	for attempt := 1; attempt <= b.config.DeleteVerifyAttempts; attempt++ {
+		time.Sleep(b.config.DeleteVerifyDelay)
+		err := b.messenger.Delete(chatID, messageID)
+		if isMessageGone(err) {
+			if attempt > 1 {
+				b.logger.Info("Confirmed message deleted on retry", "chatID", chatID, "messageID", messageID, "attempts", attempt)
+			}
+			return
+		}
+		if err == nil {
+			b.logger.Warn("Deleted message was still present, deleted again", "chatID", chatID, "messageID", messageID, "attempt", attempt)
+		} else {
+			b.logger.Warn("Failed to verify message deletion", "error", err, "chatID", chatID, "messageID", messageID, "attempt", attempt)
+		}
+	}
+	b.logger.Error("Could not confirm message was deleted", "chatID", chatID, "messageID", messageID, "attempts", b.config.DeleteVerifyAttempts)
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyDeletionRetriesSilentFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		lingering int // Deletes that succeed without removing the message
+		deletes   int // Delete calls expected until the message is confirmed gone
+	}{
+		{"deleted at once", 0, 2},
+		{"first delete silently failed", 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.DeleteVerifyAttempts = 3
+			config.DeleteVerifyDelay = time.Millisecond
+			b, messenger, _ := newTestBot(t, config, &countingProvider{})
+			messenger.lingering = map[int]int{10: tt.lingering}
+
+			if err := b.deleteMessage(testChatID, 10); err != nil {
+				t.Fatal(err)
+			}
+			confirmed := func() bool {
+				messenger.mutex.Lock()
+				defer messenger.mutex.Unlock()
+				return messenger.lingering[10] < 0 && messenger.deleteCalls == tt.deletes
+			}
+			waitFor(t, "the deletion confirmed", confirmed)
+			time.Sleep(10 * time.Millisecond)
+
+			if !confirmed() {
+				t.Errorf("Delete called %d times, want the checks to stop once the message is gone", messenger.deleteCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// VoicePolicy controls voice messages and video notes from new users, which have no text to classify
+type VoicePolicy string
+
+const (
+	VoicePolicyIgnore   VoicePolicy = "ignore"   // Let them through
+	VoicePolicyRestrict VoicePolicy = "restrict" // Delete them and mute the user for MuteDuration
+	VoicePolicyNotify   VoicePolicy = "notify"   // Report them to the log channel
+)
+
+func ParseVoicePolicy(value string) (VoicePolicy, error) {
+	switch policy := VoicePolicy(value); policy {
+	case VoicePolicyIgnore, VoicePolicyRestrict, VoicePolicyNotify:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown voice policy: %s (expected ignore, restrict or notify)", value)
+	}
+}
+
+// isVoiceMessage reports whether a message is a voice message or video note without a caption
+func isVoiceMessage(message *tgbotapi.Message) bool {
+	return (message.Voice != nil || message.VideoNote != nil) && message.Caption == ""
+}
+
+// handleVoiceMessage applies the voice policy to a voice message or video note from a new user
+func (b *Bot) handleVoiceMessage(ctx context.Context, message *tgbotapi.Message, adminRights AdminRights) {
+	channelID := message.Chat.ID
+	if message.SenderChat != nil || (len(b.whitelistChannels) > 0 && !b.whitelistChannels[channelID]) {
+		return
+	}
+
+	count, err := b.redis.Get(ctx, b.key("%d:%d", message.From.ID, channelID)).Int()
+	if err != nil && err != redis.Nil {
+		b.logger.Error("Error retrieving count from Redis", "error", err)
+		return
+	}
+	count = b.withTenure(ctx, channelID, message.From.ID, count)
+	if count >= b.newUserThreshold(ctx, channelID) || b.isTrusted(ctx, channelID, message.From.ID) {
+		return
+	}
+
+	action := ActionNotify
+	if b.config.VoicePolicy == VoicePolicyRestrict {
+		action = ActionMute
+	}
+	b.logger.Debug("Voice message from new user", "userID", message.From.ID, "channelID", channelID, "policy", b.config.VoicePolicy)
+	b.enforce(message, channelID, message.From.ID, adminRights, action, "Voice message from new user", 1, b.config.Threshold)
+}
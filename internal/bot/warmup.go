@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+func (b *Bot) warmupKey(chatID int64) string {
+	return b.key("warmup:%d", chatID)
+}
+
+func (b *Bot) warmupEnabled() bool {
+	return b.config.WarmupDetections > 0 || b.config.WarmupDuration > 0
+}
+
+// startWarmup puts a chat the bot was just added to into warm-up, where detections
+// are only reported until WarmupDetections or WarmupDuration is reached
+func (b *Bot) startWarmup(ctx context.Context, chatID int64) {
+	if !b.warmupEnabled() {
+		return
+	}
+	key := b.warmupKey(chatID)
+	if err := b.redis.HSet(ctx, key, "started", time.Now().Unix(), "detections", 0).Err(); err != nil {
+		b.logger.Error("Failed to start warm-up", "error", err, "chatID", chatID)
+		return
+	}
+	b.logger.Info("Started warm-up in new chat", "chatID", chatID, "detections", b.config.WarmupDetections, "duration", b.config.WarmupDuration)
+}
+
+// warmingUp reports whether a chat is in warm-up, ending it once its duration passed.
+// Chats the bot was in before warm-up was enabled are never warming up.
+func (b *Bot) warmingUp(ctx context.Context, chatID int64) bool {
+	if !b.warmupEnabled() {
+		return false
+	}
+	state, err := b.redis.HGetAll(ctx, b.warmupKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to get warm-up state", "error", err, "chatID", chatID)
+		return false
+	}
+	if len(state) == 0 {
+		return false
+	}
+	if state["started"] == "" {
+		// A detection was counted just after warm-up ended
+		_ = b.redis.Del(ctx, b.warmupKey(chatID)).Err()
+		return false
+	}
+	started, _ := strconv.ParseInt(state["started"], 10, 64)
+	detections, _ := strconv.Atoi(state["detections"])
+	if (b.config.WarmupDuration > 0 && time.Since(time.Unix(started, 0)) >= b.config.WarmupDuration) ||
+		(b.config.WarmupDetections > 0 && detections >= b.config.WarmupDetections) {
+		b.endWarmup(ctx, chatID, detections)
+		return false
+	}
+	return true
+}
+
+// countWarmupDetection records a detection that was only reported, ending warm-up after the last one
+func (b *Bot) countWarmupDetection(ctx context.Context, chatID int64) int {
+	detections, err := b.redis.HIncrBy(ctx, b.warmupKey(chatID), "detections", 1).Result()
+	if err != nil {
+		b.logger.Error("Failed to count warm-up detection", "error", err, "chatID", chatID)
+		return 0
+	}
+	if b.config.WarmupDetections > 0 && int(detections) >= b.config.WarmupDetections {
+		b.endWarmup(ctx, chatID, int(detections))
+	}
+	return int(detections)
+}
+
+// endWarmup switches a chat to active enforcement and tells its log channel
+func (b *Bot) endWarmup(ctx context.Context, chatID int64, detections int) {
+	deleted, err := b.redis.Del(ctx, b.warmupKey(chatID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to end warm-up", "error", err, "chatID", chatID)
+		return
+	}
+	if deleted == 0 {
+		return // Another worker ended it
+	}
+	b.logger.Info("Warm-up over, enforcing", "chatID", chatID, "detections", detections)
+	if logChannelID, exists := b.logChannel(chatID); exists {
+		b.sendLog(logChannelID, b.t(chatID, "notify.warmup_over", detections, chatID))
+	}
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// secretTokenHeader carries the secret Telegram was given in setWebhook with every update
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookPath returns the path the bot receives webhook updates on, distinct per bot identity
+func (b *Bot) WebhookPath() string {
+	return fmt.Sprintf("/webhook/%d", b.api.Self.ID)
+}
+
+// WebhookHandler receives webhook updates for the bot. With a WebhookSecret, requests
+// without Telegram's matching secret token header are rejected with 401, so updates
+// can't be forged by anyone who learns the URL.
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if b.config.WebhookSecret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(b.config.WebhookSecret)) != 1 {
+			b.logger.Warn("Rejected webhook request with a wrong secret token", "remoteAddr", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var update incomingUpdate
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateBytes)).Decode(&update); err != nil {
+			b.logger.Warn("Failed to decode webhook update", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Telegram waits for the response before sending more, which holds updates back while workers are busy
+		select {
+		case b.webhookUpdates <- receivedUpdate{incomingUpdate: update, received: time.Now()}:
+			w.WriteHeader(http.StatusOK)
+		case <-b.stopChan:
+			w.WriteHeader(http.StatusServiceUnavailable) // Telegram retries it with the next instance
+		case <-r.Context().Done():
+		}
+	})
+}
+
+// receiveWebhook passes on the updates received by the webhook handler until Stop is called
+func (b *Bot) receiveWebhook() <-chan receivedUpdate {
+	updates := make(chan receivedUpdate)
+	b.goSafe(func() {
+		defer close(updates)
+		for {
+			select {
+			case update := <-b.webhookUpdates:
+				updates <- update
+			case <-b.stopChan:
+				return
+			}
+		}
+	})
+	return updates
+}
+
+// setWebhook points Telegram at WebhookURL, registering the secret token it sends back
+func (b *Bot) setWebhook() error {
+	allowed, err := json.Marshal(b.pollConfig().AllowedUpdates)
+	if err != nil {
+		return fmt.Errorf("error encoding allowed updates: %w", err)
+	}
+	params := tgbotapi.Params{
+		"url":             b.config.WebhookURL + b.WebhookPath(),
+		"allowed_updates": string(allowed),
+	}
+	params.AddNonEmpty("secret_token", b.config.WebhookSecret)
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("error setting webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	const update = `{"update_id": 5, "message": {"message_id": 10, "chat": {"id": -1001, "type": "supergroup"}, "text": "hi"}}`
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		want   int
+	}{
+		{"update", http.MethodPost, "s3cret", update, http.StatusOK},
+		{"wrong secret", http.MethodPost, "guess", update, http.StatusUnauthorized},
+		{"missing secret", http.MethodPost, "", update, http.StatusUnauthorized},
+		{"not a POST", http.MethodGet, "s3cret", "", http.StatusMethodNotAllowed},
+		{"malformed", http.MethodPost, "s3cret", `{"update_id":`, http.StatusBadRequest},
+		{"oversized", http.MethodPost, "s3cret", `{"update_id": 5, "message": {"text": "` + strings.Repeat("a", maxUpdateBytes) + `"}}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.WebhookSecret = "s3cret"
+			b, _, _ := newTestBot(t, config, &countingProvider{})
+			request := httptest.NewRequest(tt.method, b.WebhookPath(), strings.NewReader(tt.body))
+			if tt.secret != "" {
+				request.Header.Set(secretTokenHeader, tt.secret)
+			}
+			recorder := httptest.NewRecorder()
+
+			b.WebhookHandler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+			if queued := len(b.webhookUpdates); (queued == 1) != (tt.want == http.StatusOK) {
+				t.Errorf("%d updates queued for the workers", queued)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerWithoutSecret(t *testing.T) {
+	b, _, _ := newTestBot(t, testConfig(), &countingProvider{})
+	request := httptest.NewRequest(http.MethodPost, b.WebhookPath(), strings.NewReader(`{"update_id": 5}`))
+	recorder := httptest.NewRecorder()
+
+	b.WebhookHandler().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("status = %d, want updates accepted without a configured secret", recorder.Code)
+	}
+	if update := <-b.webhookUpdates; update.UpdateID != 5 {
+		t.Errorf("queued update %d, want 5", update.UpdateID)
+	}
+}
@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// messageLinkPattern matches links to messages of private (t.me/c/<id>/<message>) and
+// public (t.me/<username>/<message>) chats, optionally within a topic
+var messageLinkPattern = regexp.MustCompile(`t\.me/(c/\d+|[A-Za-z0-9_]+)/(?:\d+/)?(\d+)`)
+
+// decisionKey holds the decision on a message as a hash, so the classifier's reasoning can be added apart from the action
+func (b *Bot) decisionKey(chatID int64, messageID int) string {
+	return b.key("decision:%d:%d", chatID, messageID)
+}
+
+// decisionNoticeKey maps a log channel notification to the message it reported
+func (b *Bot) decisionNoticeKey(logChannelID int64, logMessageID int) string {
+	return b.key("decision_notice:%d:%d", logChannelID, logMessageID)
+}
+
+// recordDecision keeps the action taken on a message for DecisionTTL, for /why
+func (b *Bot) recordDecision(ctx context.Context, message *tgbotapi.Message, userID int64, action Action, label string, score, threshold float64) {
+	if b.config.DecisionTTL <= 0 {
+		return
+	}
+	key := b.decisionKey(message.Chat.ID, message.MessageID)
+	pipe := b.redis.TxPipeline()
+	pipe.HSet(ctx, key,
+		"user_id", userID,
+		"label", label,
+		"action", string(action),
+		"score", score,
+		"threshold", threshold,
+		"time", time.Now().Unix(),
+	)
+	pipe.Expire(ctx, key, b.config.DecisionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to record decision", "error", err, "chatID", message.Chat.ID, "messageID", message.MessageID)
+	}
+}
+
+// recordClassification adds the model and its reasoning to the decision on a classified message
+func (b *Bot) recordClassification(ctx context.Context, message *tgbotapi.Message, reasoning string) {
+	if b.config.DecisionTTL <= 0 {
+		return
+	}
+	key := b.decisionKey(message.Chat.ID, message.MessageID)
+	pipe := b.redis.TxPipeline()
+	pipe.HSet(ctx, key, "model", b.config.BuildInfo.Model, "reasoning", reasoning)
+	pipe.Expire(ctx, key, b.config.DecisionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		b.logger.Error("Failed to record classification", "error", err, "chatID", message.Chat.ID, "messageID", message.MessageID)
+	}
+}
+
+// recordDecisionNotice remembers which message a notification reported, so /why works as a reply to it
+func (b *Bot) recordDecisionNotice(ctx context.Context, logChannelID int64, logMessageID int, chatID int64, messageID int) {
+	if b.config.DecisionTTL <= 0 {
+		return
+	}
+	target := fmt.Sprintf("%d:%d", chatID, messageID)
+	if err := b.redis.Set(ctx, b.decisionNoticeKey(logChannelID, logMessageID), target, b.config.DecisionTTL).Err(); err != nil {
+		b.logger.Error("Failed to record decision notification", "error", err, "logChannelID", logChannelID)
+	}
+}
+
+// handleWhyCommand shows the recorded decision on a message: /why as a reply to the
+// bot's notification, or /why <message ID|link>
+func (b *Bot) handleWhyCommand(message *tgbotapi.Message) {
+	ctx := context.Background()
+	chatID, messageID, err := b.whyTarget(ctx, message)
+	if err != nil {
+		b.reply(message, err.Error())
+		return
+	}
+	if chatID == 0 {
+		b.reply(message, b.t(message.Chat.ID, "why.not_found"))
+		return
+	}
+
+	fields, err := b.redis.HGetAll(ctx, b.decisionKey(chatID, messageID)).Result()
+	if err != nil {
+		b.logger.Error("Failed to load decision", "error", err, "chatID", chatID, "messageID", messageID)
+		b.reply(message, b.t(message.Chat.ID, "why.failed"))
+		return
+	}
+	// A record holding only the classification lost its decision fields to a partial write
+	if fields["action"] == "" {
+		b.reply(message, b.t(message.Chat.ID, "why.not_found"))
+		return
+	}
+	b.logger.Info("Showed decision", "chatID", chatID, "messageID", messageID, "admin", commandSender(message))
+	b.reply(message, truncate(b.describeDecision(message.Chat.ID, chatID, messageID, fields), maxNotificationChars))
+}
+
+// whyTarget resolves the message /why asks about. It returns a zero chat ID if a replied-to
+// notification's record expired, and an error if the message can't be looked up from this chat.
+func (b *Bot) whyTarget(ctx context.Context, message *tgbotapi.Message) (int64, int, error) {
+	arg := strings.TrimSpace(message.CommandArguments())
+	if arg == "" {
+		// Notifications in channels have no sender, so any replied-to message is looked up
+		if message.ReplyToMessage == nil {
+			return 0, 0, errors.New(b.t(message.Chat.ID, "why.usage"))
+		}
+		target, err := b.redis.Get(ctx, b.decisionNoticeKey(message.Chat.ID, message.ReplyToMessage.MessageID)).Result()
+		if err != nil {
+			if err != redis.Nil {
+				b.logger.Error("Failed to load decision notification", "error", err, "chatID", message.Chat.ID)
+			}
+			return 0, 0, nil
+		}
+		var chatID int64
+		var messageID int
+		if _, err := fmt.Sscanf(target, "%d:%d", &chatID, &messageID); err != nil {
+			return 0, 0, nil
+		}
+		return chatID, messageID, nil
+	}
+
+	chatID, messageID, err := b.parseMessageRef(message, arg)
+	if err != nil {
+		return 0, 0, err
+	}
+	if chatID != message.Chat.ID {
+		if logChannelID, exists := b.logChannel(chatID); !exists || logChannelID != message.Chat.ID {
+			return 0, 0, fmt.Errorf("chat %d doesn't log to this channel", chatID)
+		}
+	}
+	return chatID, messageID, nil
+}
+
+// parseMessageRef parses a message link or ID. A bare ID refers to the current chat,
+// or in a log channel to the only chat logging there.
+func (b *Bot) parseMessageRef(message *tgbotapi.Message, arg string) (int64, int, error) {
+	if match := messageLinkPattern.FindStringSubmatch(arg); match != nil {
+		messageID, err := strconv.Atoi(match[2])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid message link: %s", arg)
+		}
+		if internalID, private := strings.CutPrefix(match[1], "c/"); private {
+			// Private chat links carry the chat ID without its -100 prefix
+			chatID, err := strconv.ParseInt("-100"+internalID, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid message link: %s", arg)
+			}
+			return chatID, messageID, nil
+		}
+		if !strings.EqualFold(match[1], message.Chat.UserName) {
+			return 0, 0, fmt.Errorf("links to public chats only work in the chat itself")
+		}
+		return message.Chat.ID, messageID, nil
+	}
+
+	messageID, err := strconv.Atoi(arg)
+	if err != nil || messageID <= 0 {
+		return 0, 0, errors.New(b.t(message.Chat.ID, "why.usage"))
+	}
+	if _, exists := b.logChannel(message.Chat.ID); exists || !b.isLogChannel(message.Chat.ID) {
+		return message.Chat.ID, messageID, nil
+	}
+	chatID, ok := b.soleLoggedChat(message.Chat.ID)
+	if !ok {
+		return 0, 0, fmt.Errorf("this channel logs several chats, pass a message link instead")
+	}
+	return chatID, messageID, nil
+}
+
+// describeDecision renders a recorded decision in the locale of the chat asking about it
+func (b *Bot) describeDecision(replyChatID, chatID int64, messageID int, fields map[string]string) string {
+	score, _ := strconv.ParseFloat(fields["score"], 64)
+	threshold, _ := strconv.ParseFloat(fields["threshold"], 64)
+	unix, _ := strconv.ParseInt(fields["time"], 10, 64)
+	text := b.t(replyChatID, "why.record", messageID, chatID, fields["user_id"],
+		time.Unix(unix, 0).UTC().Format("2006-01-02 15:04:05 UTC"),
+		fields["label"], score, threshold, fields["action"])
+	if fields["model"] != "" {
+		text += "\n" + b.t(replyChatID, "why.model", fields["model"])
+	}
+	if fields["reasoning"] != "" {
+		text += "\n" + b.t(replyChatID, "why.reasoning", fields["reasoning"])
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Build metadata, overridden at build time via
+// -ldflags "-X github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info describes the running build together with its active configuration
+type Info struct {
+	Version   string          `json:"version"`
+	Commit    string          `json:"commit"`
+	BuildDate string          `json:"build_date"`
+	GoVersion string          `json:"go_version"`
+	Provider  string          `json:"provider,omitempty"`
+	Model     string          `json:"model,omitempty"`
+	Features  map[string]bool `json:"features,omitempty"`
+}
+
+// New returns the build metadata for the given provider, model and feature toggles
+func New(provider, model string, features map[string]bool) Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Provider:  provider,
+		Model:     model,
+		Features:  features,
+	}
+}
+
+// String renders the build metadata as human-readable text
+func (i Info) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Version: %s\n", i.Version)
+	fmt.Fprintf(&sb, "Commit: %s\n", i.Commit)
+	fmt.Fprintf(&sb, "Build date: %s\n", i.BuildDate)
+	fmt.Fprintf(&sb, "Go: %s\n", i.GoVersion)
+	if i.Provider != "" {
+		fmt.Fprintf(&sb, "Provider: %s\n", i.Provider)
+	}
+	if i.Model != "" {
+		fmt.Fprintf(&sb, "Model: %s\n", i.Model)
+	}
+
+	if len(i.Features) > 0 {
+		names := make([]string, 0, len(i.Features))
+		for name := range i.Features {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		sb.WriteString("Features:\n")
+		for _, name := range names {
+			state := "off"
+			if i.Features[name] {
+				state = "on"
+			}
+			fmt.Fprintf(&sb, "  %s: %s\n", name, state)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,49 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	info := New("openai", "gpt-4o-mini", map[string]bool{"undo": true})
+	if info.Version != Version || info.Commit != Commit || info.BuildDate != BuildDate {
+		t.Errorf("New() = %+v, want the ldflags build metadata", info)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+	}
+}
+
+func TestString(t *testing.T) {
+	info := Info{
+		Version:   "1.4.0",
+		Commit:    "abc1234",
+		BuildDate: "2026-10-14",
+		GoVersion: "go1.21.1",
+		Provider:  "anthropic",
+		Model:     "claude-3-haiku",
+		Features:  map[string]bool{"webhook": false, "cas": true, "bulk_delete": true},
+	}
+	want := `Version: 1.4.0
+Commit: abc1234
+Build date: 2026-10-14
+Go: go1.21.1
+Provider: anthropic
+Model: claude-3-haiku
+Features:
+  bulk_delete: on
+  cas: on
+  webhook: off`
+	if got := info.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStringOmitsUnsetFields(t *testing.T) {
+	info := Info{Version: "dev", Commit: "unknown", BuildDate: "unknown", GoVersion: "go1.21.1"}
+	want := "Version: dev\nCommit: unknown\nBuild date: unknown\nGo: go1.21.1"
+	if got := info.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package cas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/httpclient"
+)
+
+const defaultBaseURL = "https://api.cas.chat"
+
+// Client checks users against the Combot Anti-Spam (CAS) ban list
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func New() *Client {
+	return &Client{
+		httpClient: httpclient.New(5 * time.Second),
+		baseURL:    defaultBaseURL,
+	}
+}
+
+type checkResponse struct {
+	OK bool `json:"ok"`
+}
+
+// IsBanned reports whether the user is on the CAS ban list
+func (c *Client) IsBanned(ctx context.Context, userID int64) (bool, error) {
+	url := fmt.Sprintf("%s/check?user_id=%d", c.baseURL, userID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var check checkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&check); err != nil {
+		return false, fmt.Errorf("error decoding response: %w", err)
+	}
+	// CAS answers ok=false with "Record not found" for users that aren't banned
+	return check.OK, nil
+}
@@ -0,0 +1,69 @@
+package debugstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "debug:"
+
+// Record is a single classifier interaction captured for later replay
+type Record struct {
+	ID        string    `json:"id"`
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	MessageID int       `json:"message_id"`
+	Model     string    `json:"model"`
+	Prompt    string    `json:"prompt"`
+	Response  string    `json:"response"`
+	SpamScore float64   `json:"spam_score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store keeps rendered prompts and raw responses in Redis for a limited time
+type Store struct {
+	redis  *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func New(rdb *redis.Client, prefix string, ttl time.Duration) *Store {
+	return &Store{
+		redis:  rdb,
+		prefix: prefix + keyPrefix,
+		ttl:    ttl,
+	}
+}
+
+// RecordID returns the identifier of the record for a message in a chat
+func RecordID(chatID int64, messageID int) string {
+	return fmt.Sprintf("%d:%d", chatID, messageID)
+}
+
+func (s *Store) Save(ctx context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error marshaling debug record: %w", err)
+	}
+	return s.redis.Set(ctx, s.prefix+record.ID, data, s.ttl).Err()
+}
+
+func (s *Store) Load(ctx context.Context, id string) (Record, error) {
+	data, err := s.redis.Get(ctx, s.prefix+id).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return Record{}, fmt.Errorf("debug record %s not found", id)
+		}
+		return Record{}, fmt.Errorf("error loading debug record %s: %w", id, err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, fmt.Errorf("error parsing debug record %s: %w", id, err)
+	}
+	return record, nil
+}
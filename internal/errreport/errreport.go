@@ -0,0 +1,176 @@
+package errreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/httpclient"
+)
+
+// Reporter receives panics and unexpected errors for visibility beyond the logs
+type Reporter interface {
+	CaptureError(err error, tags map[string]string)
+	CapturePanic(value any, stack []byte, tags map[string]string)
+	// Flush waits for queued reports to be sent, at most for timeout
+	Flush(timeout time.Duration)
+}
+
+// Nop discards every report
+type Nop struct{}
+
+func (Nop) CaptureError(error, map[string]string)       {}
+func (Nop) CapturePanic(any, []byte, map[string]string) {}
+func (Nop) Flush(time.Duration)                         {}
+
+// sentryQueueSize bounds the reports waiting to be sent; further ones are dropped
+const sentryQueueSize = 100
+
+// Sentry sends reports to a Sentry project through its envelope endpoint
+type Sentry struct {
+	logger     *slog.Logger
+	httpClient *http.Client
+	endpoint   string
+	auth       string
+	dsn        string
+	queue      chan sentryEvent
+	pending    sync.WaitGroup
+}
+
+// sentryEvent is the subset of the Sentry event payload the bot fills in
+type sentryEvent struct {
+	EventID   string            `json:"event_id"`
+	Timestamp string            `json:"timestamp"`
+	Platform  string            `json:"platform"`
+	Level     string            `json:"level"`
+	Release   string            `json:"release"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Exception struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewSentry creates a reporter for a DSN like https://<key>@o0.ingest.sentry.io/<project>
+func NewSentry(logger *slog.Logger, dsn string) (*Sentry, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.Host == "" || parsed.User == nil || parsed.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN")
+	}
+	path := strings.TrimSuffix(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	s := &Sentry{
+		logger:     logger,
+		httpClient: httpclient.New(10 * time.Second),
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/envelope/", parsed.Scheme, parsed.Host, path[:slash], project),
+		auth:       fmt.Sprintf("Sentry sentry_version=7, sentry_client=giraffe-spam-crasher/%s, sentry_key=%s", buildinfo.Version, parsed.User.Username()),
+		dsn:        dsn,
+		queue:      make(chan sentryEvent, sentryQueueSize),
+	}
+	go s.sendRoutine()
+	return s, nil
+}
+
+func (s *Sentry) CaptureError(err error, tags map[string]string) {
+	s.enqueue("error", fmt.Sprintf("%T", err), err.Error(), tags, nil)
+}
+
+func (s *Sentry) CapturePanic(value any, stack []byte, tags map[string]string) {
+	s.enqueue("fatal", "panic", fmt.Sprint(value), tags, map[string]string{"stack": string(stack)})
+}
+
+func (s *Sentry) enqueue(level, kind, value string, tags, extra map[string]string) {
+	event := sentryEvent{
+		EventID:   newEventID(),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Platform:  "go",
+		Level:     level,
+		Release:   buildinfo.Version,
+		Tags:      tags,
+		Extra:     extra,
+	}
+	event.Exception.Values = []sentryException{{Type: kind, Value: value}}
+
+	s.pending.Add(1)
+	select {
+	case s.queue <- event:
+	default:
+		s.pending.Done()
+		s.logger.Warn("Dropped error report, the queue is full")
+	}
+}
+
+func (s *Sentry) sendRoutine() {
+	for event := range s.queue {
+		if err := s.send(event); err != nil {
+			s.logger.Warn("Failed to send error report", "error", err)
+		}
+		s.pending.Done()
+	}
+}
+
+func (s *Sentry) send(event sentryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling event: %w", err)
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `{"event_id":%q,"dsn":%q}`+"\n", event.EventID, s.dsn)
+	fmt.Fprintf(&body, `{"type":"event","length":%d}`+"\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Sentry) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.logger.Warn("Timed out sending queued error reports")
+	}
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
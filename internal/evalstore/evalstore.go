@@ -0,0 +1,71 @@
+package evalstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const key = "eval_samples"
+
+// Sample is a live classification kept to evaluate models and prompts against
+// recent traffic. Text is empty unless full content is kept, leaving only its hash.
+type Sample struct {
+	ChatID    int64     `json:"chat_id"`
+	MessageID int       `json:"message_id"`
+	Hash      string    `json:"hash"`
+	Text      string    `json:"text,omitempty"`
+	Model     string    `json:"model"`
+	SpamScore float64   `json:"spam_score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store keeps the most recent samples in a Redis list bounded to size entries
+type Store struct {
+	redis *redis.Client
+	key   string
+	size  int
+}
+
+func New(rdb *redis.Client, prefix string, size int) *Store {
+	return &Store{
+		redis: rdb,
+		key:   prefix + key,
+		size:  size,
+	}
+}
+
+// Add stores a sample, dropping the oldest ones beyond the size bound
+func (s *Store) Add(ctx context.Context, sample Sample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("error marshaling eval sample: %w", err)
+	}
+	pipe := s.redis.TxPipeline()
+	pipe.LPush(ctx, s.key, data)
+	pipe.LTrim(ctx, s.key, 0, int64(s.size)-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error storing eval sample: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit samples, newest first, or all of them if limit is 0
+func (s *Store) List(ctx context.Context, limit int) ([]Sample, error) {
+	values, err := s.redis.LRange(ctx, s.key, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error loading eval samples: %w", err)
+	}
+	samples := make([]Sample, 0, len(values))
+	for _, value := range values {
+		var sample Sample
+		if err := json.Unmarshal([]byte(value), &sample); err != nil {
+			return nil, fmt.Errorf("error parsing eval sample: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
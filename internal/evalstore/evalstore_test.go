@@ -0,0 +1,37 @@
+package evalstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStoreKeepsNewestSamples(t *testing.T) {
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	store := New(rdb, "giraffe:", 3)
+	ctx := context.Background()
+
+	for id := 1; id <= 5; id++ {
+		if err := store.Add(ctx, Sample{ChatID: -1001, MessageID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := store.List(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 || samples[0].MessageID != 5 || samples[2].MessageID != 3 {
+		t.Errorf("List() = %v, want the 3 newest samples, newest first", samples)
+	}
+	if limited, err := store.List(ctx, 2); err != nil || len(limited) != 2 || limited[0].MessageID != 5 {
+		t.Errorf("List(2) = %v, %v, want the 2 newest samples", limited, err)
+	}
+	if !server.Exists("giraffe:eval_samples") {
+		t.Error("samples aren't stored under the prefix")
+	}
+}
@@ -1,6 +1,7 @@
 package history
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -28,53 +29,289 @@ type Message struct {
 	ActorID string          `json:"actor_id"`
 }
 
-// ProcessFile reads a Telegram export file and stores message counts in Redis
-func ProcessFile(filePath string, redisClient *redis.Client) error {
-	// Read the file
-	data, err := os.ReadFile(filePath)
+// LoadMode controls how loaded counts are combined with the stored ones
+type LoadMode string
+
+const (
+	LoadModeMerge   LoadMode = "merge"   // Keep the higher of the stored and loaded count of each user
+	LoadModeReplace LoadMode = "replace" // Drop the chat's stored counts before loading
+)
+
+func ParseLoadMode(s string) (LoadMode, error) {
+	switch LoadMode(s) {
+	case LoadModeMerge, LoadModeReplace:
+		return LoadMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown load mode: %s (expected merge or replace)", s)
+	}
+}
+
+// Result reports how many entries of a history file were loaded. Malformed
+// entries are skipped with their error instead of aborting the whole file.
+type Result struct {
+	Loaded  int     // Messages counted towards their sender
+	Skipped int     // Malformed entries left out
+	Errors  []error // Why entries were skipped or counts couldn't be stored
+}
+
+// LoadResult summarizes a loaded history file
+type LoadResult struct {
+	Result
+	ChatID string
+	Users  int
+}
+
+// progressInterval is how many parsed messages pass between progress reports
+const progressInterval = 100000
+
+// ProcessFile reads a Telegram export file and stores message counts in Redis,
+// prepending prefix to every key and writing batchSize counts per round trip
+func ProcessFile(filePath string, redisClient *redis.Client, prefix string, batchSize int) (Result, error) {
+	chatID, userCounts, result, err := parseFile(filePath)
 	if err != nil {
-		return fmt.Errorf("error reading file: %v", err)
+		return result, err
+	}
+
+	// Store counts in Redis
+	userBatches := batches(userCounts, batchSize)
+	for i, userIDs := range userBatches {
+		reportBatch(i, userBatches)
+		pipe := redisClient.Pipeline()
+		cmds := make([]*redis.StatusCmd, len(userIDs))
+		for i, userID := range userIDs {
+			cmds[i] = pipe.Set(context.TODO(), countKey(prefix, userID, chatID), userCounts[userID], 0)
+		}
+		result.Errors = append(result.Errors, storeErrors(pipe, userIDs, chatID, cmds)...)
+	}
+
+	fmt.Printf("Processed %d messages in channel %s\n", result.Loaded, chatID)
+	return result, nil
+}
+
+// Load reads a Telegram export file and combines its message counts with the
+// stored ones according to mode, batchSize counts per round trip. With dryRun
+// nothing is written. A failed batch is reported without undoing earlier ones.
+func Load(ctx context.Context, filePath string, redisClient *redis.Client, prefix string, mode LoadMode, dryRun bool, batchSize int) (LoadResult, error) {
+	chatID, userCounts, parsed, err := parseFile(filePath)
+	result := LoadResult{Result: parsed, ChatID: chatID, Users: len(userCounts)}
+	if err != nil || dryRun {
+		return result, err
+	}
+
+	if mode == LoadModeReplace {
+		iter := redisClient.Scan(ctx, 0, prefix+"[0-9]*:"+chatID, 1000).Iterator()
+		for iter.Next(ctx) {
+			if err := redisClient.Del(ctx, iter.Val()).Err(); err != nil {
+				return result, fmt.Errorf("error removing count %s: %v", iter.Val(), err)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return result, fmt.Errorf("error scanning history keys: %v", err)
+		}
+	}
+
+	userBatches := batches(userCounts, batchSize)
+	for i, batch := range userBatches {
+		reportBatch(i, userBatches)
+		userIDs := batch
+		if mode == LoadModeMerge {
+			userIDs = nil
+			pipe := redisClient.Pipeline()
+			stored := make([]*redis.StringCmd, len(batch))
+			for i, userID := range batch {
+				stored[i] = pipe.Get(ctx, countKey(prefix, userID, chatID))
+			}
+			pipe.Exec(ctx) // Failures are checked per command
+			for i, userID := range batch {
+				count, err := stored[i].Int()
+				if err != nil && err != redis.Nil {
+					result.Errors = append(result.Errors, fmt.Errorf("error retrieving count for user %s in channel %s: %v", userID, chatID, err))
+					continue
+				}
+				if count < userCounts[userID] {
+					userIDs = append(userIDs, userID)
+				}
+			}
+		}
+
+		pipe := redisClient.Pipeline()
+		cmds := make([]*redis.StatusCmd, len(userIDs))
+		for i, userID := range userIDs {
+			cmds[i] = pipe.Set(ctx, countKey(prefix, userID, chatID), userCounts[userID], 0)
+		}
+		result.Errors = append(result.Errors, storeErrors(pipe, userIDs, chatID, cmds)...)
+	}
+	return result, nil
+}
+
+func countKey(prefix, userID, chatID string) string {
+	return fmt.Sprintf("%s%s:%s", prefix, userID, chatID)
+}
+
+// batches splits the users of the counts into batches of up to size users whose
+// counts are written in one pipeline, all in one batch if size isn't positive
+func batches(userCounts map[string]int, size int) [][]string {
+	if size <= 0 {
+		size = len(userCounts)
+	}
+	var batches [][]string
+	var current []string
+	for userID := range userCounts {
+		current = append(current, userID)
+		if len(current) == size {
+			batches = append(batches, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
 	}
+	return batches
+}
+
+// reportBatch prints the progress of writing counts when they take several batches
+func reportBatch(i int, batches [][]string) {
+	if len(batches) > 1 {
+		fmt.Printf("Storing batch %d of %d\n", i+1, len(batches))
+	}
+}
+
+// storeErrors executes a pipeline of count writes, returning an error for each failed write
+func storeErrors(pipe redis.Pipeliner, userIDs []string, chatID string, cmds []*redis.StatusCmd) []error {
+	if len(cmds) == 0 {
+		return nil
+	}
+	pipe.Exec(context.TODO()) // Failures are checked per command
+	var errs []error
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("error storing count for user %s in channel %s: %v", userIDs[i], chatID, err))
+		}
+	}
+	return errs
+}
+
+// parseFile returns the chat ID and the message count of each user in a
+// Telegram export file, streaming the messages so that memory is bound by the
+// number of users rather than the size of the file. Only an unreadable file or
+// chat header is an error.
+func parseFile(filePath string) (string, map[string]int, Result, error) {
+	var result Result
 
-	// Parse JSON
-	var telegramData TelegramData
-	err = json.Unmarshal(data, &telegramData)
+	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("error parsing JSON: %v", err)
+		return "", nil, result, fmt.Errorf("error reading file: %v", err)
 	}
+	defer file.Close()
 
-	// Count messages for each user in the channel
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	if err := expectDelim(decoder, '{'); err != nil {
+		return "", nil, result, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	var chatType string
+	var id int64
 	userCounts := make(map[string]int)
-	for _, message := range telegramData.Messages {
-		if message.Type == "message" && message.FromID != "" {
-			userID := strings.TrimPrefix(message.FromID, "user")
-			userCounts[userID]++
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", nil, result, fmt.Errorf("error parsing JSON: %v", err)
+		}
+		switch token {
+		case "type":
+			err = decoder.Decode(&chatType)
+		case "id":
+			err = decoder.Decode(&id)
+		case "messages":
+			err = countMessages(decoder, userCounts, &result)
+		default:
+			var skipped json.RawMessage
+			err = decoder.Decode(&skipped)
+		}
+		if err != nil {
+			return "", nil, result, fmt.Errorf("error parsing JSON: %v", err)
 		}
 	}
-	chatID := ""
-	switch telegramData.Type {
+
+	switch chatType {
 	case "public_supergroup", "private_supergroup", "channel":
-		chatID = "-100" + strconv.FormatInt(telegramData.ID, 10)
+		return "-100" + strconv.FormatInt(id, 10), userCounts, result, nil
 	default:
-		return fmt.Errorf("wrong chat type: %s", telegramData.Type)
+		return "", nil, result, fmt.Errorf("wrong chat type: %s", chatType)
 	}
+}
 
-	// Store counts in Redis
-	for userID, count := range userCounts {
-		key := fmt.Sprintf("%s:%s", userID, chatID)
-		err = redisClient.Set(context.TODO(), key, count, 0).Err()
-		if err != nil {
-			return fmt.Errorf("error storing count for user %s in channel %s: %v", userID, chatID, err)
+// countMessages decodes the messages array one message at a time, counting them towards their senders
+func countMessages(decoder *json.Decoder, userCounts map[string]int, result *Result) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+	for i := 0; decoder.More(); i++ {
+		if i > 0 && i%progressInterval == 0 {
+			fmt.Printf("Parsed %d messages\n", i)
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+		var message Message
+		if err := json.Unmarshal(raw, &message); err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Errorf("message %d: %v", i, err))
+			continue
+		}
+		if message.Type != "message" || message.FromID == "" {
+			continue
 		}
+		if !strings.HasPrefix(message.FromID, "user") {
+			continue // Posted on behalf of a channel
+		}
+		userID := strings.TrimPrefix(message.FromID, "user")
+		if _, err := strconv.ParseInt(userID, 10, 64); err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Errorf("message %d: invalid sender %q", message.ID, message.FromID))
+			continue
+		}
+		userCounts[userID]++
+		result.Loaded++
 	}
+	return expectDelim(decoder, ']')
+}
 
-	fmt.Printf("Processed %d messages in channel %s\n", len(telegramData.Messages), chatID)
+// expectDelim consumes the next token, failing unless it is delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
 	return nil
 }
 
+// KeyPattern matches the message count keys ("<userID>:<chatID>") under the prefix,
+// leaving out caches, counters and other data sharing the database
+func KeyPattern(prefix string) string {
+	return prefix + "[0-9]*:*"
+}
+
+// CountKeys returns the number of stored message count keys under the prefix
+func CountKeys(ctx context.Context, redisClient *redis.Client, prefix string) (int64, error) {
+	var count int64
+	iter := redisClient.Scan(ctx, 0, KeyPattern(prefix), 1000).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		return 0, fmt.Errorf("error scanning history keys: %v", err)
+	}
+	return count, nil
+}
+
 // GetUserMessageCount retrieves the message count for a specific user and channel from Redis
-func GetUserMessageCount(redisClient *redis.Client, userID string, channelID int64) (int, error) {
-	key := fmt.Sprintf("%s:%d", userID, channelID)
+func GetUserMessageCount(redisClient *redis.Client, prefix, userID string, channelID int64) (int, error) {
+	key := fmt.Sprintf("%s%s:%d", prefix, userID, channelID)
 	count, err := redisClient.Get(context.TODO(), key).Int()
 	if err != nil {
 		if err == redis.Nil {
@@ -0,0 +1,207 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const export = `{
+	"name": "Test chat",
+	"type": "public_supergroup",
+	"id": 1234,
+	"messages": [
+		{"id": 1, "type": "message", "from": "Ann", "from_id": "user11", "text": "hi"},
+		{"id": 2, "type": "message", "from": "Ann", "from_id": "user11", "text": ["bold", {"type": "link", "text": "x.com"}]},
+		{"id": 3, "type": "message", "from": "Bob", "from_id": "user22", "text": "hello"},
+		{"id": 4, "type": "service", "actor_id": "user33", "action": "join_group_by_link"},
+		{"id": 5, "type": "message", "from": "News", "from_id": "channel44", "text": "post"},
+		{"id": 6, "type": "message", "from": "Eve", "from_id": "userX", "text": "bad"},
+		{"id": "7", "type": "message", "from_id": "user55"},
+		{"id": 8, "type": "message", "from": "Bob", "from_id": "user22", "text": "again"}
+	]
+}`
+
+// writeExport writes a Telegram export file and returns its path
+func writeExport(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb, server
+}
+
+func TestParseFile(t *testing.T) {
+	chatID, counts, result, err := parseFile(writeExport(t, export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chatID != "-1001234" {
+		t.Errorf("chat ID = %s, want -1001234", chatID)
+	}
+	if len(counts) != 2 || counts["11"] != 2 || counts["22"] != 2 {
+		t.Errorf("counts = %v, want 2 messages each from users 11 and 22", counts)
+	}
+	if result.Loaded != 4 || result.Skipped != 2 || len(result.Errors) != 2 {
+		t.Errorf("result = %+v, want 4 loaded and the invalid sender and malformed message skipped", result)
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"private chat", `{"type": "personal_chat", "id": 1, "messages": []}`},
+		{"not an object", `[]`},
+		{"truncated", `{"type": "public_supergroup", "id": 1, "messages": [{"id": 1`},
+		{"messages not an array", `{"type": "public_supergroup", "id": 1, "messages": {}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := parseFile(writeExport(t, tt.content)); err == nil {
+				t.Error("parseFile() succeeded, want an error")
+			}
+		})
+	}
+	if _, _, _, err := parseFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("parseFile() of a missing file succeeded")
+	}
+}
+
+func TestBatches(t *testing.T) {
+	counts := map[string]int{"1": 1, "2": 1, "3": 1, "4": 1, "5": 1}
+	tests := []struct {
+		size  int
+		sizes []int
+	}{
+		{0, []int{5}},
+		{-1, []int{5}},
+		{2, []int{2, 2, 1}},
+		{5, []int{5}},
+		{10, []int{5}},
+	}
+	for _, tt := range tests {
+		got := batches(counts, tt.size)
+		var sizes []int
+		var users []string
+		for _, batch := range got {
+			sizes = append(sizes, len(batch))
+			users = append(users, batch...)
+		}
+		sort.Strings(users)
+		if !slices.Equal(sizes, tt.sizes) || len(users) != 5 || users[0] != "1" || users[4] != "5" {
+			t.Errorf("batches(size %d) = %v, want sizes %v covering every user once", tt.size, got, tt.sizes)
+		}
+	}
+	if got := batches(map[string]int{}, 3); len(got) != 0 {
+		t.Errorf("batches of no users = %v, want none", got)
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	rdb, server := testRedis(t)
+
+	result, err := ProcessFile(writeExport(t, export), rdb, "giraffe:", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Loaded != 4 {
+		t.Errorf("loaded = %d, want 4", result.Loaded)
+	}
+	for _, key := range []string{"giraffe:11:-1001234", "giraffe:22:-1001234"} {
+		if value, _ := server.Get(key); value != "2" {
+			t.Errorf("%s = %q, want 2", key, value)
+		}
+	}
+	if count, err := CountKeys(context.Background(), rdb, "giraffe:"); err != nil || count != 2 {
+		t.Errorf("CountKeys() = %d, %v, want 2", count, err)
+	}
+}
+
+func TestCountKeysIgnoresOtherData(t *testing.T) {
+	for _, prefix := range []string{"", "giraffe:"} {
+		rdb, server := testRedis(t)
+		for _, key := range []string{"spam:5d41402abc4b2a76", "threshold:-1001234", "spam_hashes:-1001234", "raid:-1001234", "admins:-1001234"} {
+			server.Set(prefix+key, "1")
+		}
+		if prefix != "" {
+			server.Set("11:-1001234", "2") // History of another namespace
+		}
+
+		if count, err := CountKeys(context.Background(), rdb, prefix); err != nil || count != 0 {
+			t.Errorf("CountKeys(%q) = %d, %v, want no history keys", prefix, count, err)
+		}
+	}
+}
+
+func TestLoadModes(t *testing.T) {
+	ctx := context.Background()
+	path := writeExport(t, export)
+
+	t.Run("merge keeps the higher count", func(t *testing.T) {
+		rdb, server := testRedis(t)
+		server.Set("11:-1001234", "5")
+		server.Set("22:-1001234", "1")
+
+		if _, err := Load(ctx, path, rdb, "", LoadModeMerge, false, 1); err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := server.Get("11:-1001234"); value != "5" {
+			t.Errorf("user 11 = %s, want the stored 5 kept", value)
+		}
+		if value, _ := server.Get("22:-1001234"); value != "2" {
+			t.Errorf("user 22 = %s, want the loaded 2", value)
+		}
+	})
+
+	t.Run("replace drops the chat's counts", func(t *testing.T) {
+		rdb, server := testRedis(t)
+		server.Set("11:-1001234", "5")
+		server.Set("99:-1001234", "3")
+		server.Set("99:-1009999", "3")
+
+		if _, err := Load(ctx, path, rdb, "", LoadModeReplace, false, 0); err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := server.Get("11:-1001234"); value != "2" {
+			t.Errorf("user 11 = %s, want the loaded 2", value)
+		}
+		if server.Exists("99:-1001234") {
+			t.Error("a stored count of the chat survived the replace")
+		}
+		if !server.Exists("99:-1009999") {
+			t.Error("a count of another chat was dropped")
+		}
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		rdb, server := testRedis(t)
+
+		result, err := Load(ctx, path, rdb, "", LoadModeMerge, true, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.ChatID != "-1001234" || result.Users != 2 || result.Loaded != 4 {
+			t.Errorf("result = %+v, want 4 messages from 2 users in -1001234", result)
+		}
+		if keys := server.Keys(); len(keys) != 0 {
+			t.Errorf("keys = %v after a dry run", keys)
+		}
+	})
+}
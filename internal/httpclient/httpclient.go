@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Options configure outbound requests to AI providers and other external APIs
+type Options struct {
+	Proxy   string      // Proxy URL, HTTPS_PROXY and HTTP_PROXY are honored if empty
+	Headers http.Header // Extra headers sent with every request
+}
+
+var options Options
+
+// Configure applies options to every client created afterwards; it must be called before any request is made
+func Configure(o Options) error {
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL: %s", o.Proxy)
+		}
+		// gRPC clients such as Gemini's only read the proxy from the environment
+		for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY"} {
+			if err := os.Setenv(name, o.Proxy); err != nil {
+				return fmt.Errorf("error setting %s: %w", name, err)
+			}
+		}
+	}
+	options = o
+	return nil
+}
+
+// Headers returns the extra headers sent with every request
+func Headers() http.Header {
+	return options.Headers
+}
+
+// New returns an HTTP client using the configured proxy and headers, without a timeout if zero
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Transport(),
+	}
+}
+
+// Transport returns a transport using the configured proxy and headers
+func Transport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.Proxy != "" {
+		proxy, _ := url.Parse(options.Proxy) // Validated by Configure
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if len(options.Headers) == 0 {
+		return transport
+	}
+	return &headerTransport{
+		transport: transport,
+		headers:   options.Headers,
+	}
+}
+
+// headerTransport adds extra headers to requests
+type headerTransport struct {
+	transport http.RoundTripper
+	headers   http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context()) // A RoundTripper must not modify the request
+	for name, values := range t.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,81 @@
+package locale
+
+var english = catalog{
+	// Notifications sent to log channels
+	"notify.logged":             "👻 %s detected and logged",
+	"notify.deleted":            "🤡 %s detected and deleted",
+	"notify.too_old":            "🕰 %s detected, too old to delete",
+	"notify.muted":              "🔇User muted for %s",
+	"notify.banned":             "👩‍⚖️User banned",
+	"notify.flood_deleted":      "🧹 %d more recent messages of the user deleted",
+	"notify.warmup":             "🐣 Warm-up, not acted on",
+	"notify.details":            "User ID: %d\nChannel ID: %d\n%s Score: %.2f/%.2f",
+	"notify.collapsed":          "(%d more detections collapsed since the last notification)",
+	"notify.warmup_over":        "✅ Warm-up over after %d detections\nChannel ID: %d\nSpam is now deleted and spammers are restricted according to the chat's action policy",
+	"notify.undone":             "↩️ Undone",
+	"notify.undo_lifted":        ", user restrictions lifted",
+	"notify.undo_failed":        ", failed to lift the user's restrictions",
+	"notify.undo_restored":      ", message restored",
+	"notify.restored":           "♻️ Message from %s restored by an admin:\n%s",
+	"notify.suspicious_profile": "👤 Suspicious profile of a new member, score %.2f\nUser ID: %d\nChannel ID: %d\n%s",
+	"notify.impersonation":      "🎭 New member may be impersonating admin %s, similarity %.2f\nUser ID: %d\nChannel ID: %d\n%s",
+
+	// Actions described by in-chat notices
+	"notice.removed_banned":  "removed and the sender banned",
+	"notice.removed_muted":   "removed and the sender muted",
+	"notice.reported_banned": "reported and the sender banned",
+	"notice.reported_muted":  "reported and the sender muted",
+	"notice.removed":         "removed",
+
+	// Command replies
+	"policy.show":           "Action policy: %s (global %s)",
+	"policy.reset_failed":   "Failed to reset the action policy",
+	"policy.reset":          "Action policy reset to the global %s",
+	"policy.usage":          "Usage: /policy [delete-only|mute|ban|reset]",
+	"policy.set_failed":     "Failed to set the action policy",
+	"policy.set":            "Action policy set to %s",
+	"threshold.show":        "Spam threshold: %.2f (global %.2f)",
+	"threshold.no_undo":     "No previous threshold to restore",
+	"threshold.undo_failed": "Failed to restore the previous threshold",
+	"threshold.restored":    "Spam threshold restored to %.2f",
+	"threshold.usage":       "Usage: /threshold [value between 0 and 1|undo]",
+	"threshold.set_failed":  "Failed to set the threshold",
+	"threshold.set":         "Spam threshold set to %.2f",
+	"band.show":             "Uncertainty band: ±%.2f around the threshold of %.2f (global ±%.2f), scores within it are reported for review",
+	"band.reset_failed":     "Failed to reset the uncertainty band",
+	"band.reset":            "Uncertainty band reset to the global ±%.2f",
+	"band.usage":            "Usage: /band [distance from the threshold above 0 and up to 0.5|off|reset]",
+	"band.set_failed":       "Failed to set the uncertainty band",
+	"band.set":              "Scores within ±%.2f of the threshold are now reported for review instead of acted on",
+	"band.off":              "Uncertainty band off, every score is acted on automatically",
+	"newuser.show":          "New users: fewer than %d clean messages (global %d)",
+	"newuser.reset_failed":  "Failed to reset the new user threshold",
+	"newuser.reset":         "New user threshold reset to the global %d",
+	"newuser.usage":         "Usage: /newuser [number of clean messages above 0|reset]",
+	"newuser.set_failed":    "Failed to set the new user threshold",
+	"newuser.set":           "Users are new until they post %d clean messages",
+	"trust.usage":           "Reply to a user's message to trust or untrust them",
+	"trust.untrust_failed":  "Failed to untrust the user",
+	"trust.untrusted":       "User is no longer trusted and will be scanned again",
+	"trust.trust_failed":    "Failed to trust the user",
+	"trust.trusted":         "🤝 User trusted, their messages are no longer scanned",
+	"trust.lifted":          " and their restrictions were lifted",
+	"trust.lift_failed":     "\nFailed to lift their restrictions: %v",
+	"forget.usage":          "Reply to a user's message to reset what the bot tracks about them",
+	"forget.failed":         "Failed to reset the user",
+	"forget.done":           "🧽 User reset, they are treated as a new user again",
+	"feedback.usage":        "Reply to a message to mark it as spam or not spam",
+	"feedback.not_spam":     "👍 Marked as not spam",
+	"feedback.spam":         "👎 Marked as spam",
+	"locale.show":           "Locale: %s (supported: %s)",
+	"locale.usage":          "Usage: /locale [%s|reset]",
+	"locale.set_failed":     "Failed to set the locale",
+	"locale.set":            "Locale set to %s",
+	"locale.reset":          "Locale reset, now %s",
+	"why.usage":             "Reply to a notification, or pass a message ID or link: /why 1234",
+	"why.not_found":         "No decision recorded for this message, it may have expired",
+	"why.failed":            "Failed to load the decision",
+	"why.record":            "🔎 Message %d in chat %d\nUser ID: %s\nDecided: %s\n%s Score: %.2f/%.2f\nAction: %s",
+	"why.model":             "Model: %s",
+	"why.reasoning":         "Reasoning: %s",
+}
@@ -0,0 +1,49 @@
+package locale
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Default is the locale used when a chat has none and for messages missing from a catalog
+const Default = "en"
+
+// catalog maps message keys to fmt format strings
+type catalog map[string]string
+
+var catalogs = map[string]catalog{
+	"en": english,
+	"ru": russian,
+}
+
+// Supported reports whether there is a catalog for the locale
+func Supported(code string) bool {
+	_, ok := catalogs[code]
+	return ok
+}
+
+// Codes returns the supported locales in alphabetical order
+func Codes() []string {
+	codes := make([]string, 0, len(catalogs))
+	for code := range catalogs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// T formats the message for key in the locale, falling back to the default
+// locale and, for unknown keys, to the key itself
+func T(code, key string, args ...any) string {
+	format, ok := catalogs[code][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,78 @@
+package locale
+
+var russian = catalog{
+	"notify.logged":             "👻 %s: обнаружено и записано",
+	"notify.deleted":            "🤡 %s: обнаружено и удалено",
+	"notify.too_old":            "🕰 %s: обнаружено, но слишком старое для удаления",
+	"notify.muted":              "🔇Пользователь лишён права писать на %s",
+	"notify.banned":             "👩‍⚖️Пользователь заблокирован",
+	"notify.flood_deleted":      "🧹 Удалено ещё %d недавних сообщений пользователя",
+	"notify.warmup":             "🐣 Пробный период, меры не приняты",
+	"notify.details":            "ID пользователя: %d\nID чата: %d\n%s, оценка: %.2f/%.2f",
+	"notify.collapsed":          "(ещё %d срабатываний скрыто после прошлого уведомления)",
+	"notify.warmup_over":        "✅ Пробный период завершён после %d срабатываний\nID чата: %d\nТеперь спам удаляется, а спамеры ограничиваются согласно политике чата",
+	"notify.undone":             "↩️ Отменено",
+	"notify.undo_lifted":        ", ограничения пользователя сняты",
+	"notify.undo_failed":        ", не удалось снять ограничения пользователя",
+	"notify.undo_restored":      ", сообщение восстановлено",
+	"notify.suspicious_profile": "👤 Подозрительный профиль нового участника, оценка %.2f\nID пользователя: %d\nID канала: %d\n%s",
+	"notify.impersonation":      "🎭 Новый участник, возможно, выдаёт себя за администратора %s, сходство %.2f\nID пользователя: %d\nID канала: %d\n%s",
+	"notify.restored":           "♻️ Сообщение от %s восстановлено администратором:\n%s",
+
+	"notice.removed_banned":  "удалено, отправитель заблокирован",
+	"notice.removed_muted":   "удалено, отправитель лишён права писать",
+	"notice.reported_banned": "передано администраторам, отправитель заблокирован",
+	"notice.reported_muted":  "передано администраторам, отправитель лишён права писать",
+	"notice.removed":         "удалено",
+
+	"policy.show":           "Политика действий: %s (глобальная %s)",
+	"policy.reset_failed":   "Не удалось сбросить политику действий",
+	"policy.reset":          "Политика действий сброшена до глобальной %s",
+	"policy.usage":          "Использование: /policy [delete-only|mute|ban|reset]",
+	"policy.set_failed":     "Не удалось установить политику действий",
+	"policy.set":            "Политика действий: %s",
+	"threshold.show":        "Порог спама: %.2f (глобальный %.2f)",
+	"threshold.no_undo":     "Нет предыдущего порога для восстановления",
+	"threshold.undo_failed": "Не удалось восстановить предыдущий порог",
+	"threshold.restored":    "Порог спама восстановлен: %.2f",
+	"threshold.usage":       "Использование: /threshold [значение от 0 до 1|undo]",
+	"threshold.set_failed":  "Не удалось установить порог",
+	"threshold.set":         "Порог спама: %.2f",
+	"band.show":             "Зона неопределённости: ±%.2f вокруг порога %.2f (глобально ±%.2f), такие оценки отправляются на проверку",
+	"band.reset_failed":     "Не удалось сбросить зону неопределённости",
+	"band.reset":            "Зона неопределённости сброшена на глобальную: ±%.2f",
+	"band.usage":            "Использование: /band [расстояние от порога больше 0 и до 0.5|off|reset]",
+	"band.set_failed":       "Не удалось установить зону неопределённости",
+	"band.set":              "Оценки в пределах ±%.2f от порога теперь отправляются на проверку администраторам",
+	"band.off":              "Зона неопределённости отключена, все оценки обрабатываются автоматически",
+	"newuser.show":          "Новые пользователи: меньше %d чистых сообщений (глобально %d)",
+	"newuser.reset_failed":  "Не удалось сбросить порог новых пользователей",
+	"newuser.reset":         "Порог новых пользователей сброшен на глобальный: %d",
+	"newuser.usage":         "Использование: /newuser [число чистых сообщений больше 0|reset]",
+	"newuser.set_failed":    "Не удалось установить порог новых пользователей",
+	"newuser.set":           "Пользователи считаются новыми до %d чистых сообщений",
+	"trust.usage":           "Ответьте на сообщение пользователя, чтобы доверять ему или перестать",
+	"trust.untrust_failed":  "Не удалось отозвать доверие",
+	"trust.untrusted":       "Пользователь больше не доверенный, его сообщения снова проверяются",
+	"trust.trust_failed":    "Не удалось сделать пользователя доверенным",
+	"trust.trusted":         "🤝 Пользователь доверенный, его сообщения больше не проверяются",
+	"trust.lifted":          ", ограничения сняты",
+	"trust.lift_failed":     "\nНе удалось снять ограничения: %v",
+	"forget.usage":          "Ответьте на сообщение пользователя, чтобы сбросить всё, что бот о нём знает",
+	"forget.failed":         "Не удалось сбросить пользователя",
+	"forget.done":           "🧽 Пользователь сброшен и снова считается новым",
+	"feedback.usage":        "Ответьте на сообщение, чтобы отметить его как спам или не спам",
+	"feedback.not_spam":     "👍 Отмечено как не спам",
+	"feedback.spam":         "👎 Отмечено как спам",
+	"locale.show":           "Язык: %s (доступны: %s)",
+	"locale.usage":          "Использование: /locale [%s|reset]",
+	"locale.set_failed":     "Не удалось установить язык",
+	"locale.set":            "Язык: %s",
+	"locale.reset":          "Язык сброшен, теперь %s",
+	"why.usage":             "Ответьте на уведомление или укажите ID сообщения или ссылку: /why 1234",
+	"why.not_found":         "Для этого сообщения нет сохранённого решения, возможно, оно истекло",
+	"why.failed":            "Не удалось загрузить решение",
+	"why.record":            "🔎 Сообщение %d в чате %d\nID пользователя: %s\nРешение принято: %s\n%s, оценка: %.2f/%.2f\nДействие: %s",
+	"why.model":             "Модель: %s",
+	"why.reasoning":         "Обоснование: %s",
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "giraffe"
+
+// otherChats is the label value shared by chats below the traffic threshold
+const otherChats = "other"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	// SpamScore is the distribution of classifier spam scores per chat
+	SpamScore = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "spam_score",
+		Help:      "Distribution of spam scores returned by the classifier.",
+		Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+	}, []string{"chat"})
+
+	// DecisionLatency is the time from receiving an update to acting on it, including queueing
+	DecisionLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "decision_latency_seconds",
+		Help:      "Time from receiving a message to the final decision, by whether the spam cache decided it.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"cache"})
+
+	// AIPaused is 1 while provider calls are suspended by /pause_ai or -pause-ai
+	AIPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ai_paused",
+		Help:      "Whether AI classification is paused and messages are handled by heuristics.",
+	})
+
+	// CostCapReached is 1 while classification is skipped because the daily cost cap was reached
+	CostCapReached = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cost_cap_reached",
+		Help:      "Whether the daily cost cap was reached and messages are handled by the fallback.",
+	})
+
+	// CostCapSkipped counts messages handled by the cost cap fallback instead of the classifier
+	CostCapSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cost_cap_skipped_total",
+		Help:      "Messages not classified because the daily cost cap was reached, by fallback.",
+	}, []string{"fallback"})
+
+	// ShadowClassifications counts shadow provider results by whether they agreed with the primary provider
+	ShadowClassifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shadow_classifications_total",
+		Help:      "Shadow provider classifications, by result: agreed or disagreed with the primary provider, failed, or dropped while busy.",
+	}, []string{"result"})
+
+	// MessagesSanitized counts messages whose text was cut or cleaned before processing, by problem
+	MessagesSanitized = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_sanitized_total",
+		Help:      "Messages with oversized, invalid UTF-8, control character or garbage text, by problem.",
+	}, []string{"problem"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SpamScore,
+		DecisionLatency,
+		AIPaused,
+		CostCapReached,
+		CostCapSkipped,
+		MessagesSanitized,
+		ShadowClassifications,
+	)
+}
+
+// RegisterProviderBudget exports the request budget of a provider: the share of the
+// current minute's and day's ceiling spent, and the number of calls shed
+func RegisterProviderBudget(provider string, usage func() (minute, day float64, shed uint64)) {
+	labels := prometheus.Labels{"provider": provider}
+	registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "provider_budget_minute_utilization",
+			Help:        "Share of the per-minute request budget spent in the current minute.",
+			ConstLabels: labels,
+		}, func() float64 {
+			minute, _, _ := usage()
+			return minute
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "provider_budget_day_utilization",
+			Help:        "Share of the per-day request budget spent in the current UTC day.",
+			ConstLabels: labels,
+		}, func() float64 {
+			_, day, _ := usage()
+			return day
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "provider_budget_shed_total",
+			Help:        "Provider calls shed because the request budget was spent.",
+			ConstLabels: labels,
+		}, func() float64 {
+			_, _, shed := usage()
+			return float64(shed)
+		}),
+	)
+}
+
+// Gatherer returns the registry of the bot's metrics, for exporting them elsewhere
+func Gatherer() prometheus.Gatherer {
+	return registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ChatLabels bounds the cardinality of the chat label: only chats that have
+// seen at least minMessages get their own label, up to maxChats of them, and
+// everything else is reported as "other".
+type ChatLabels struct {
+	minMessages int
+	maxChats    int
+	counts      map[int64]int
+	labeled     map[int64]bool
+	mutex       sync.Mutex
+}
+
+func NewChatLabels(minMessages, maxChats int) *ChatLabels {
+	return &ChatLabels{
+		minMessages: minMessages,
+		maxChats:    maxChats,
+		counts:      make(map[int64]int),
+		labeled:     make(map[int64]bool),
+	}
+}
+
+// Label counts a message for the chat and returns the label value to use for it
+func (c *ChatLabels) Label(chatID int64) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.labeled[chatID] {
+		return strconv.FormatInt(chatID, 10)
+	}
+
+	c.counts[chatID]++
+	if c.counts[chatID] < c.minMessages || len(c.labeled) >= c.maxChats {
+		return otherChats
+	}
+
+	c.labeled[chatID] = true
+	delete(c.counts, chatID)
+	return strconv.FormatInt(chatID, 10)
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo"
+)
+
+// Server is the admin HTTP server exposing operational endpoints
+type Server struct {
+	httpServer *http.Server
+	mux        *http.ServeMux
+	logger     *slog.Logger
+	info       buildinfo.Info
+	options    Options
+}
+
+// Options secure the admin server; the zero value serves plain HTTP without authentication
+type Options struct {
+	BasicAuth string // Required credentials in the format "user:password"
+	TLSCert   string // Certificate file, TLS is enabled together with TLSKey
+	TLSKey    string
+}
+
+// ParseBasicAuth validates credentials in the format "user:password"
+func ParseBasicAuth(credentials string) error {
+	if user, password, ok := strings.Cut(credentials, ":"); !ok || user == "" || password == "" {
+		return fmt.Errorf("invalid credentials, expected 'user:password'")
+	}
+	return nil
+}
+
+func New(logger *slog.Logger, addr string, info buildinfo.Info, options Options) *Server {
+	mux := http.NewServeMux()
+	s := &Server{
+		mux:     mux,
+		logger:  logger,
+		info:    info,
+		options: options,
+	}
+	var handler http.Handler = mux
+	if options.BasicAuth != "" {
+		handler = s.requireAuth(mux)
+	}
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	mux.HandleFunc("/healthz", s.handleHealth)
+	return s
+}
+
+// requireAuth rejects requests without the configured basic auth credentials
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	expected := sha256.Sum256([]byte(s.options.BasicAuth))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		given := sha256.Sum256([]byte(user + ":" + password))
+		if !ok || subtle.ConstantTimeCompare(given[:], expected[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handle registers an additional handler on the admin server
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(struct {
+		Status string `json:"status"`
+		buildinfo.Info
+	}{
+		Status: "ok",
+		Info:   s.info,
+	})
+	if err != nil {
+		s.logger.Error("Failed to write health response", "error", err)
+	}
+}
+
+// Start serves requests until Stop is called
+func (s *Server) Start() {
+	var err error
+	if s.options.TLSCert != "" && s.options.TLSKey != "" {
+		s.logger.Info("Starting admin server", "addr", s.httpServer.Addr, "tls", true, "auth", s.options.BasicAuth != "")
+		err = s.httpServer.ListenAndServeTLS(s.options.TLSCert, s.options.TLSKey)
+	} else {
+		s.logger.Info("Starting admin server", "addr", s.httpServer.Addr, "tls", false, "auth", s.options.BasicAuth != "")
+		err = s.httpServer.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Error("Admin server failed", "error", err)
+	}
+}
+
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.logger.Error("Failed to shut down admin server", "error", err)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo"
+)
+
+func TestHealthReportsBuildInfo(t *testing.T) {
+	info := buildinfo.Info{Version: "1.4.0", Commit: "abc1234", Model: "gpt-4o-mini", Features: map[string]bool{"undo": true}}
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)), ":0", info, Options{})
+	recorder := httptest.NewRecorder()
+
+	s.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	var body struct {
+		Status   string          `json:"status"`
+		Version  string          `json:"version"`
+		Commit   string          `json:"commit"`
+		Model    string          `json:"model"`
+		Features map[string]bool `json:"features"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Status != "ok" || body.Version != "1.4.0" || body.Commit != "abc1234" || body.Model != "gpt-4o-mini" || !body.Features["undo"] {
+		t.Errorf("health body = %+v, want the status and build info", body)
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)), ":0", buildinfo.Info{}, Options{BasicAuth: "prometheus:secret"})
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		withAuth bool
+		status   int
+	}{
+		{"valid credentials", "prometheus", "secret", true, http.StatusOK},
+		{"wrong password", "prometheus", "guess", true, http.StatusUnauthorized},
+		{"wrong user", "admin", "secret", true, http.StatusUnauthorized},
+		{"password sharing the prefix", "prometheus", "secret2", true, http.StatusUnauthorized},
+		{"no credentials", "", "", false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			if tt.withAuth {
+				request.SetBasicAuth(tt.user, tt.password)
+			}
+			recorder := httptest.NewRecorder()
+
+			s.httpServer.Handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestParseBasicAuth(t *testing.T) {
+	for credentials, valid := range map[string]bool{
+		"prometheus:secret": true,
+		"user:pass:word":    true,
+		"prometheus":        false,
+		":secret":           false,
+		"prometheus:":       false,
+		"":                  false,
+	} {
+		if err := ParseBasicAuth(credentials); (err == nil) != valid {
+			t.Errorf("ParseBasicAuth(%q) = %v, want valid %t", credentials, err, valid)
+		}
+	}
+}
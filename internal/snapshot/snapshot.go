@@ -0,0 +1,224 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	filePrefix = "snapshot-"
+	fileSuffix = ".json.gz"
+	timeLayout = "20060102T150405Z"
+)
+
+// entry is a single Redis key in a snapshot, serialized with DUMP
+type entry struct {
+	Key       string `json:"key"`
+	ExpiresAt int64  `json:"expires_at_ms,omitempty"` // Unix time in milliseconds the key expires at, persistent if zero
+	TTL       int64  `json:"ttl_ms,omitempty"`        // Remaining time to live in snapshots written before ExpiresAt
+	Value     []byte `json:"value"`
+}
+
+// Snapshotter periodically writes the bot's Redis keyspace to compressed files
+type Snapshotter struct {
+	redis    *redis.Client
+	logger   *slog.Logger
+	prefix   string
+	dir      string
+	keep     int
+	stopChan chan struct{}
+}
+
+func New(logger *slog.Logger, rdb *redis.Client, prefix, dir string, keep int) *Snapshotter {
+	return &Snapshotter{
+		redis:    rdb,
+		logger:   logger,
+		prefix:   prefix,
+		dir:      dir,
+		keep:     keep,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start writes a snapshot every interval until Stop is called
+func (s *Snapshotter) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			path, err := s.Write(context.Background())
+			if err != nil {
+				s.logger.Error("Failed to write snapshot", "error", err)
+				continue
+			}
+			s.logger.Info("Wrote snapshot", "path", path)
+			if err := s.prune(); err != nil {
+				s.logger.Error("Failed to remove old snapshots", "error", err)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+func (s *Snapshotter) Stop() {
+	close(s.stopChan)
+}
+
+// Write serializes every key under the prefix to a new snapshot file and returns its path
+func (s *Snapshotter) Write(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+
+	var entries []entry
+	iter := s.redis.Scan(ctx, 0, s.prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		value, err := s.redis.Dump(ctx, key).Result()
+		if err == redis.Nil {
+			continue // Expired while scanning
+		} else if err != nil {
+			return "", fmt.Errorf("error dumping key %s: %w", key, err)
+		}
+		ttl, err := s.redis.PTTL(ctx, key).Result()
+		if err != nil {
+			return "", fmt.Errorf("error getting TTL of key %s: %w", key, err)
+		}
+		e := entry{Key: key, Value: []byte(value)}
+		if ttl > 0 {
+			e.ExpiresAt = time.Now().Add(ttl).UnixMilli()
+		}
+		entries = append(entries, e)
+	}
+	if err := iter.Err(); err != nil {
+		return "", fmt.Errorf("error scanning keys: %w", err)
+	}
+
+	path := filepath.Join(s.dir, filePrefix+time.Now().UTC().Format(timeLayout)+fileSuffix)
+	tmp := path + ".tmp"
+	if err := writeEntries(tmp, entries); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("error saving snapshot: %w", err)
+	}
+	return path, nil
+}
+
+func writeEntries(path string, entries []entry) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating snapshot: %w", err)
+	}
+	defer file.Close()
+
+	zw := gzip.NewWriter(file)
+	if err := json.NewEncoder(zw).Encode(entries); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return file.Close()
+}
+
+// Restore loads the entries of a snapshot file and returns the number of restored keys.
+// Keys that already exist are left untouched, keys that expired since the snapshot are skipped.
+func (s *Snapshotter) Restore(ctx context.Context, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error opening snapshot: %w", err)
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	var entries []entry
+	if err := json.NewDecoder(zr).Decode(&entries); err != nil {
+		return 0, fmt.Errorf("error parsing snapshot: %w", err)
+	}
+
+	restored := 0
+	for _, e := range entries {
+		ttl := time.Duration(e.TTL) * time.Millisecond
+		if e.ExpiresAt != 0 {
+			ttl = time.Until(time.UnixMilli(e.ExpiresAt))
+			if ttl <= 0 {
+				continue
+			}
+		}
+		err := s.redis.Restore(ctx, e.Key, ttl, string(e.Value)).Err()
+		if err != nil && strings.Contains(err.Error(), "BUSYKEY") {
+			continue
+		} else if err != nil {
+			return restored, fmt.Errorf("error restoring key %s: %w", e.Key, err)
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// Latest returns the path of the most recent snapshot in the directory
+func (s *Snapshotter) Latest() (string, error) {
+	files, err := s.files()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", errors.New("no snapshots found")
+	}
+	return files[len(files)-1], nil
+}
+
+// IsEmpty reports whether there are no keys under the prefix
+func (s *Snapshotter) IsEmpty(ctx context.Context) (bool, error) {
+	iter := s.redis.Scan(ctx, 0, s.prefix+"*", 1000).Iterator()
+	if iter.Next(ctx) {
+		return false, nil
+	}
+	return true, iter.Err()
+}
+
+// prune removes all but the most recent snapshots, keeping all of them if keep is zero
+func (s *Snapshotter) prune() error {
+	if s.keep <= 0 {
+		return nil
+	}
+	files, err := s.files()
+	if err != nil || len(files) <= s.keep {
+		return err
+	}
+	for _, path := range files[:len(files)-s.keep] {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// files lists the snapshots in the directory, oldest first
+func (s *Snapshotter) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
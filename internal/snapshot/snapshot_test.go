@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestSnapshotter(t *testing.T) (*Snapshotter, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), rdb, "giraffe:", t.TempDir(), 2), server
+}
+
+func TestWriteRestoreRoundTrip(t *testing.T) {
+	s, server := newTestSnapshotter(t)
+	ctx := context.Background()
+	server.Set("giraffe:count", "3")
+	server.Set("giraffe:trusted", "1")
+	server.SetTTL("giraffe:trusted", time.Hour)
+	server.Set("giraffe:threshold:-1001", "0.6")
+	server.Set("other:key", "ignored")
+
+	path, err := s.Write(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FlushAll()
+	server.Set("giraffe:count", "7") // Written since, kept
+
+	restored, err := s.Restore(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored != 2 {
+		t.Errorf("Restore() = %d, want 2 keys", restored)
+	}
+	if value, _ := server.Get("giraffe:count"); value != "7" {
+		t.Errorf("giraffe:count = %q, want the existing key untouched", value)
+	}
+	if value, _ := server.Get("giraffe:trusted"); value != "1" {
+		t.Errorf("giraffe:trusted = %q, want it restored", value)
+	}
+	if ttl := server.TTL("giraffe:trusted"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("giraffe:trusted TTL = %v, want about an hour", ttl)
+	}
+	if value, _ := server.Get("giraffe:threshold:-1001"); value != "0.6" || server.TTL("giraffe:threshold:-1001") != 0 {
+		t.Errorf("threshold = %q with TTL %v, want it restored without expiry", value, server.TTL("giraffe:threshold:-1001"))
+	}
+	if server.Exists("other:key") {
+		t.Error("a key outside the prefix was snapshotted")
+	}
+}
+
+func TestRestoreSkipsExpiredKeys(t *testing.T) {
+	s, server := newTestSnapshotter(t)
+	ctx := context.Background()
+	server.Set("giraffe:fresh", "1")
+	server.Set("giraffe:stale", "1")
+	dump := func(key string) []byte {
+		value, err := s.redis.Dump(ctx, key).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []byte(value)
+	}
+	path := filepath.Join(s.dir, filePrefix+"20240101T000000Z"+fileSuffix)
+	err := writeEntries(path, []entry{
+		{Key: "giraffe:fresh", ExpiresAt: time.Now().Add(time.Hour).UnixMilli(), Value: dump("giraffe:fresh")},
+		{Key: "giraffe:stale", ExpiresAt: time.Now().Add(-time.Hour).UnixMilli(), Value: dump("giraffe:stale")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FlushAll()
+
+	restored, err := s.Restore(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored != 1 || !server.Exists("giraffe:fresh") || server.Exists("giraffe:stale") {
+		t.Errorf("Restore() = %d, want only the unexpired key restored", restored)
+	}
+}
+
+func TestLatestAndPrune(t *testing.T) {
+	s, _ := newTestSnapshotter(t)
+	for _, stamp := range []string{"20240101T000000Z", "20240103T000000Z", "20240102T000000Z"} {
+		if err := writeEntries(filepath.Join(s.dir, filePrefix+stamp+fileSuffix), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := s.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("files = %v, want the 2 most recent", files)
+	}
+	if latest, _ := s.Latest(); filepath.Base(latest) != filePrefix+"20240103T000000Z"+fileSuffix {
+		t.Errorf("Latest() = %s, want the newest snapshot", latest)
+	}
+}
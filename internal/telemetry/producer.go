@@ -0,0 +1,108 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Producer converts the metrics of a Prometheus registry to OpenTelemetry data, so
+// the counters, gauges and histograms served on /metrics are exported unchanged
+type Producer struct {
+	gatherer prometheus.Gatherer
+	start    time.Time
+}
+
+func NewProducer(gatherer prometheus.Gatherer) *Producer {
+	return &Producer{
+		gatherer: gatherer,
+		start:    time.Now(),
+	}
+}
+
+// Produce gathers the registry, leaving out metric types OpenTelemetry has no equivalent for
+func (p *Producer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("error gathering metrics: %w", err)
+	}
+	now := time.Now()
+	scope := metricdata.ScopeMetrics{Scope: instrumentation.Scope{Name: serviceName}}
+	for _, family := range families {
+		data := metricdata.Metrics{
+			Name:        family.GetName(),
+			Description: family.GetHelp(),
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			sum := metricdata.Sum[float64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+			for _, metric := range family.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, p.dataPoint(metric, metric.GetCounter().GetValue(), now))
+			}
+			data.Data = sum
+		case dto.MetricType_GAUGE:
+			var gauge metricdata.Gauge[float64]
+			for _, metric := range family.GetMetric() {
+				gauge.DataPoints = append(gauge.DataPoints, p.dataPoint(metric, metric.GetGauge().GetValue(), now))
+			}
+			data.Data = gauge
+		case dto.MetricType_HISTOGRAM:
+			histogram := metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality}
+			for _, metric := range family.GetMetric() {
+				histogram.DataPoints = append(histogram.DataPoints, p.histogramDataPoint(metric, now))
+			}
+			data.Data = histogram
+		default:
+			continue
+		}
+		scope.Metrics = append(scope.Metrics, data)
+	}
+	return []metricdata.ScopeMetrics{scope}, nil
+}
+
+func (p *Producer) dataPoint(metric *dto.Metric, value float64, now time.Time) metricdata.DataPoint[float64] {
+	return metricdata.DataPoint[float64]{
+		Attributes: attributes(metric),
+		StartTime:  p.start,
+		Time:       now,
+		Value:      value,
+	}
+}
+
+// histogramDataPoint converts the cumulative Prometheus buckets to per-bucket counts
+func (p *Producer) histogramDataPoint(metric *dto.Metric, now time.Time) metricdata.HistogramDataPoint[float64] {
+	histogram := metric.GetHistogram()
+	point := metricdata.HistogramDataPoint[float64]{
+		Attributes: attributes(metric),
+		StartTime:  p.start,
+		Time:       now,
+		Count:      histogram.GetSampleCount(),
+		Sum:        histogram.GetSampleSum(),
+	}
+	var previous uint64
+	for _, bucket := range histogram.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue // OpenTelemetry implies the last bucket
+		}
+		point.Bounds = append(point.Bounds, bucket.GetUpperBound())
+		point.BucketCounts = append(point.BucketCounts, bucket.GetCumulativeCount()-previous)
+		previous = bucket.GetCumulativeCount()
+	}
+	point.BucketCounts = append(point.BucketCounts, histogram.GetSampleCount()-previous)
+	return point
+}
+
+func attributes(metric *dto.Metric) attribute.Set {
+	labels := make([]attribute.KeyValue, 0, len(metric.GetLabel()))
+	for _, label := range metric.GetLabel() {
+		labels = append(labels, attribute.String(label.GetName(), label.GetValue()))
+	}
+	return attribute.NewSet(labels...)
+}
@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+
+	"github.com/ailabhub/giraffe-spam-crasher/internal/buildinfo"
+	"github.com/ailabhub/giraffe-spam-crasher/internal/metrics"
+)
+
+const serviceName = "giraffe-spam-crasher"
+
+// Config selects where and how often telemetry is exported over OTLP/HTTP
+type Config struct {
+	Endpoint string        // Collector host and port, e.g. otel-collector:4318
+	Insecure bool          // Use plain HTTP instead of HTTPS
+	Interval time.Duration // How often metrics are pushed
+	Traces   bool          // Also export spans around classification and actions
+}
+
+// Setup starts pushing the Prometheus metrics, and spans if enabled, to an OTLP
+// collector. The returned function flushes and stops the exporters.
+func Setup(ctx context.Context, config Config, info buildinfo.Info) (func(context.Context) error, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(info.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating telemetry resource: %w", err)
+	}
+
+	metricOptions := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OTLP metric exporter: %w", err)
+	}
+	reader := sdkmetric.NewPeriodicReader(metricExporter,
+		sdkmetric.WithInterval(config.Interval),
+		sdkmetric.WithProducer(NewProducer(metrics.Gatherer())),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithResource(res), sdkmetric.WithReader(reader))
+	otel.SetMeterProvider(meterProvider)
+	shutdown := []func(context.Context) error{meterProvider.Shutdown}
+
+	if config.Traces {
+		traceOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			traceOptions = append(traceOptions, otlptracehttp.WithInsecure())
+		}
+		traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+		if err != nil {
+			meterProvider.Shutdown(ctx)
+			return nil, fmt.Errorf("error creating OTLP trace exporter: %w", err)
+		}
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithResource(res), sdktrace.WithBatcher(traceExporter))
+		otel.SetTracerProvider(tracerProvider)
+		shutdown = append(shutdown, tracerProvider.Shutdown)
+	}
+
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, stop := range shutdown {
+			errs = append(errs, stop(ctx))
+		}
+		return errors.Join(errs...)
+	}, nil
+}